@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/lf-edge/eden/pkg/attest"
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	attestapi "github.com/lf-edge/eve/api/go/attest"
+	"github.com/lf-edge/eve/api/go/certs"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	attestQuote    string
+	attestNonce    string
+	attestBaseline string
+)
+
+var attestCmd = &cobra.Command{
+	Use:   "attest",
+	Short: "Verify attestation of EVE",
+	Long: `
+Verify attestation certificates and TPM quotes reported by EVE with TPM.
+The last quote reported by EVE is fetched from controller, you can provide another one
+in JSON format of ZAttestReq or ZAttestQuote with --quote flag.`,
+}
+
+var attestCertsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Verify attestation certificates",
+	Long:  `Fetch attestation certificates of EVE from controller and verify them against device certificate.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctrl, dev := getControllerAndDevForAttest()
+		statuses, _, err := attest.VerifyDeviceCerts(ctrl, dev)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+		fmt.Fprintln(w, "TYPE\tSUBJECT\tSTATUS")
+		failed := false
+		for _, el := range statuses {
+			status := "OK"
+			if el.Error != "" {
+				status = el.Error
+				failed = true
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", el.Type, el.Subject, status)
+		}
+		if err = w.Flush(); err != nil {
+			log.Fatal(err)
+		}
+		if failed {
+			log.Fatal("verification of attestation certificates failed")
+		}
+	},
+}
+
+var attestVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify quote",
+	Long: `
+Verify signature of quote with attestation key of EVE, nonce and digest of reported PCR values.
+If --baseline provided, PCR values are compared with it.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		quote := verifyAttestQuote()
+		if attestBaseline == "" {
+			return
+		}
+		baseline, err := attest.LoadBaseline(attestBaseline)
+		if err != nil {
+			log.Fatalf("cannot load baseline: %s", err)
+		}
+		if diff := baseline.Compare(quote.PcrValues); len(diff) > 0 {
+			for _, el := range diff {
+				fmt.Println(el)
+			}
+			log.Fatalf("PCR values do not match baseline %s", attestBaseline)
+		}
+		log.Infof("PCR values match baseline %s", attestBaseline)
+	},
+}
+
+var attestBaselineCmd = &cobra.Command{
+	Use:   "baseline <file>",
+	Short: "Save PCR values from quote as baseline",
+	Long:  `Verify quote and save PCR values from SHA256 bank into file to compare with them later.`,
+	Args:  cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		quote := verifyAttestQuote()
+		baseline := attest.NewBaseline(quote.PcrValues)
+		if len(baseline) == 0 {
+			log.Fatal("no SHA256 PCR values in quote")
+		}
+		if err := baseline.Save(args[0]); err != nil {
+			log.Fatalf("cannot save baseline: %s", err)
+		}
+		log.Infof("baseline with %d PCR values saved into %s", len(baseline), args[0])
+	},
+}
+
+func getControllerAndDevForAttest() (controller.Cloud, *device.Ctx) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDev()
+	if err != nil {
+		log.Fatalf("getControllerAndDev: %s", err)
+	}
+	return ctrl, dev
+}
+
+//verifyAttestQuote loads quote from attestQuote file or from controller if file not set
+//and verifies it with attestation key of device
+func verifyAttestQuote() *attestapi.ZAttestQuote {
+	var nonce []byte
+	var err error
+	if attestNonce != "" {
+		if nonce, err = hex.DecodeString(attestNonce); err != nil {
+			log.Fatalf("cannot decode nonce: %s", err)
+		}
+	}
+	ctrl, dev := getControllerAndDevForAttest()
+	var quote *attestapi.ZAttestQuote
+	if attestQuote != "" {
+		quote, err = attest.LoadQuote(attestQuote)
+	} else {
+		quote, err = attest.GetDeviceQuote(ctrl, dev)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	statuses, zcerts, err := attest.VerifyDeviceCerts(ctrl, dev)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, el := range statuses {
+		if el.Type == certs.ZCertType_CERT_TYPE_DEVICE_RESTRICTED_SIGNING.String() && el.Error != "" {
+			log.Fatalf("attestation key certificate: %s", el.Error)
+		}
+	}
+	akCert, err := attest.GetCert(zcerts, certs.ZCertType_CERT_TYPE_DEVICE_RESTRICTED_SIGNING)
+	if err != nil {
+		log.Fatal(err)
+	}
+	info, err := attest.VerifyQuote(quote, akCert, nonce)
+	if err != nil {
+		log.Fatalf("verification of quote failed: %s", err)
+	}
+	log.Infof("quote verified: nonce %x, %d PCR banks", info.Nonce, len(info.Selection))
+	return quote
+}
+
+func attestInit() {
+	attestCmd.AddCommand(attestCertsCmd)
+	attestCmd.AddCommand(attestVerifyCmd)
+	attestCmd.AddCommand(attestBaselineCmd)
+	for _, cmd := range []*cobra.Command{attestVerifyCmd, attestBaselineCmd} {
+		cmd.Flags().StringVar(&attestQuote, "quote", "", "file with ZAttestReq or ZAttestQuote in JSON format (the last quote from controller if empty)")
+		cmd.Flags().StringVar(&attestNonce, "nonce", "", "expected nonce in hex format (not checked if empty)")
+	}
+	attestVerifyCmd.Flags().StringVar(&attestBaseline, "baseline", "", "file with baseline of PCR values saved by 'eden attest baseline'")
+}
@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -19,6 +24,12 @@ var (
 	certsIP     string
 	certsEVEIP  string
 	certsUUID   string
+
+	certsRotateServer  bool
+	certsRotateSigning bool
+	certsRotateEncrypt bool
+	certsNoRestart     bool
+	certsWarn          time.Duration
 )
 
 var certsCmd = &cobra.Command{
@@ -56,6 +67,146 @@ var certsCmd = &cobra.Command{
 	},
 }
 
+var certsRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "rotate certs of controller",
+	Long: `
+Generate new keys and certificates of controller signed by existing root CA, restart Adam to use them
+and re-encrypt cipher contexts of devices. Previous versions of files are saved with '.old' suffix.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		viperLoaded, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		if viperLoaded {
+			certsDomain = viper.GetString("adam.domain")
+			certsIP = viper.GetString("adam.ip")
+			certsEVEIP = viper.GetString("adam.eve-ip")
+			adamTag = viper.GetString("adam.tag")
+			adamPort = viper.GetInt("adam.port")
+			adamDist = utils.ResolveAbsPath(viper.GetString("adam.dist"))
+			adamRemoteRedisURL = viper.GetString("adam.redis.adam")
+			adamRemoteRedis = viper.GetBool("adam.remote.redis")
+			apiV1 = viper.GetBool("adam.v1")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		var names []string
+		for ind, rotate := range []bool{certsRotateServer, certsRotateSigning, certsRotateEncrypt} {
+			if rotate {
+				names = append(names, eden.ControllerCerts[ind])
+			}
+		}
+		if len(names) == 0 {
+			log.Fatal("nothing to rotate, please use --server, --signing or --encrypt")
+		}
+		if apiV1 && (certsRotateSigning || certsRotateEncrypt) {
+			log.Fatal("signing and encrypt certs are not used with api v1")
+		}
+		ctrl, err := controller.CloudPrepare()
+		if err != nil {
+			log.Fatalf("CloudPrepare: %s", err)
+		}
+		if err = eden.RotateCerts(certsDomain, certsIP, certsEVEIP, names...); err != nil {
+			log.Fatalf("cannot rotate certs: %s", err)
+		}
+		log.Infof("certs %s rotated", strings.Join(names, ", "))
+		if certsNoRestart {
+			log.Info("Please restart Adam with --adam-force to apply new certs")
+			if certsRotateSigning {
+				log.Warn("Cipher blocks of devices are not re-encrypted for the new signing cert, " +
+					"please re-create datastores, apps and wifi configs with secrets after restart of Adam")
+			}
+			return
+		}
+		if !adamRemoteRedis {
+			adamRemoteRedisURL = ""
+		}
+		if err = eden.StartAdam(adamPort, adamDist, true, adamTag, adamRemoteRedisURL, apiV1); err != nil {
+			log.Fatalf("cannot restart adam: %s", err)
+		}
+		log.Info("Adam restarted with new certs")
+		if certsRotateSigning {
+			//cipher contexts use signing cert of controller, so we re-encrypt them after Adam uses the new one
+			certPath, keyPath, err := eden.ControllerCertPaths("signing")
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, dev := range ctrl.ListDevices() {
+				count, err := eden.ReencryptCipherContexts(ctrl, dev, eden.OldCertPath(certPath), eden.OldCertPath(keyPath), certPath, keyPath)
+				if err != nil {
+					log.Errorf("cannot re-encrypt cipher contexts of device %s: %s", dev.GetID(), err)
+					continue
+				}
+				if count == 0 {
+					continue
+				}
+				if err = ctrl.ConfigSync(dev); err != nil {
+					log.Fatalf("configSync error: %s", err)
+				}
+				log.Infof("%d cipher blocks of device %s re-encrypted", count, dev.GetID())
+			}
+		}
+	},
+}
+
+var certsInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "inspect certs",
+	Long: `
+Print subjects, SANs and expiry of certificates of EVE (from certs dist) and of controller.
+Certificates which expire within --warn duration are reported.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		viperLoaded, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		if viperLoaded {
+			certsDir = utils.ResolveAbsPath(viper.GetString("eden.certs-dist"))
+		}
+		if output, err = eve.ParseOutput(outputFormat); err != nil {
+			return err
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		edenHome, err := utils.DefaultEdenDir()
+		if err != nil {
+			log.Fatalf("DefaultEdenDir: %s", err)
+		}
+		certsInfo, err := eden.InspectCerts(certsDir, filepath.Join(edenHome, defaults.DefaultCertsDist))
+		if err != nil {
+			log.Fatalf("cannot inspect certs: %s", err)
+		}
+		if !output.IsTable() {
+			if err = output.Print(os.Stdout, certsInfo); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			w := new(tabwriter.Writer)
+			w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+			fmt.Fprintln(w, "FILE\tSUBJECT\tSANS\tNOT AFTER\tEXPIRES IN")
+			for _, el := range certsInfo {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", el.File, el.Subject, el.SANs(),
+					el.NotAfter.Format(time.RFC3339), el.ExpiresIn().Round(time.Hour))
+			}
+			if err = w.Flush(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		for _, el := range certsInfo {
+			if expiresIn := el.ExpiresIn(); expiresIn <= 0 {
+				log.Errorf("certificate %s expired at %s", el.File, el.NotAfter.Format(time.RFC3339))
+			} else if expiresIn < certsWarn {
+				log.Warnf("certificate %s expires at %s", el.File, el.NotAfter.Format(time.RFC3339))
+			}
+		}
+	},
+}
+
 func certsInit() {
 	currentPath, err := os.Getwd()
 	if err != nil {
@@ -73,4 +224,16 @@ func certsInit() {
 	certsCmd.Flags().StringVarP(&certsUUID, "uuid", "u", defaults.DefaultUUID, "UUID to use for device")
 	certsCmd.Flags().StringVar(&ssid, "ssid", "", "SSID for wifi")
 	certsCmd.Flags().StringVar(&password, "password", "", "password for wifi")
+	certsCmd.AddCommand(certsRotateCmd)
+	certsRotateCmd.Flags().BoolVar(&certsRotateServer, "server", false, "rotate TLS cert of Adam server")
+	certsRotateCmd.Flags().BoolVar(&certsRotateSigning, "signing", false, "rotate cert used to sign and encrypt config")
+	certsRotateCmd.Flags().BoolVar(&certsRotateEncrypt, "encrypt", false, "rotate encrypt cert of Adam")
+	certsRotateCmd.Flags().BoolVar(&certsNoRestart, "no-restart", false, "do not restart Adam after rotation")
+	certsRotateCmd.Flags().StringVarP(&certsDomain, "domain", "d", defaults.DefaultDomain, "FQDN for certificates")
+	certsRotateCmd.Flags().StringVarP(&certsIP, "ip", "i", defaults.DefaultIP, "IP address to use")
+	certsRotateCmd.Flags().StringVarP(&certsEVEIP, "eve-ip", "", defaults.DefaultEVEIP, "IP address to use for EVE")
+	certsCmd.AddCommand(certsInspectCmd)
+	certsInspectCmd.Flags().StringVarP(&certsDir, "certs-dist", "o", filepath.Join(currentPath, defaults.DefaultDist, defaults.DefaultCertsDist), "directory with certs of EVE")
+	certsInspectCmd.Flags().DurationVar(&certsWarn, "warn", defaults.DefaultCertsExpiryWarning, "warn about certificates which expire within this duration")
+	certsInspectCmd.Flags().StringVar(&outputFormat, "output", "table", eve.OutputFormatHelp)
 }
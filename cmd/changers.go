@@ -16,6 +16,7 @@ import (
 )
 
 type configChanger interface {
+	getController() (controller.Cloud, error)
 	getControllerAndDev() (controller.Cloud, *device.Ctx, error)
 	setControllerAndDev(controller.Cloud, *device.Ctx) error
 }
@@ -40,14 +41,28 @@ func changerByControllerMode(controllerMode string) (configChanger, error) {
 	case "file":
 		changer = &fileChanger{fileConfig: modeURL}
 	case "adam":
+		viper.Set("test.controller", controllerMode)
 		changer = &adamChanger{adamURL: modeURL}
-
+	case "zedcloud":
+		viper.Set("test.controller", controllerMode)
+		changer = &controllerChanger{urlKey: "zedcloud.url", url: modeURL}
+	case "proto":
+		viper.Set("test.controller", controllerMode)
+		changer = &controllerChanger{urlKey: "proto.dir", url: modeURL}
 	default:
 		return nil, fmt.Errorf("not implemented type: %s", modeType)
 	}
 	return changer, nil
 }
 
+func (ctx *fileChanger) getController() (controller.Cloud, error) {
+	ctrl, err := controller.CloudPrepare()
+	if err != nil {
+		return nil, fmt.Errorf("CloudPrepare error: %s", err)
+	}
+	return ctrl, nil
+}
+
 func (ctx *fileChanger) getControllerAndDev() (controller.Cloud, *device.Ctx, error) {
 	if ctx.fileConfig == "" {
 		return nil, nil, fmt.Errorf("cannot use empty url for file")
@@ -143,3 +158,39 @@ func (ctx *adamChanger) setControllerAndDev(ctrl controller.Cloud, dev *device.C
 	}
 	return nil
 }
+
+//controllerChanger uses controller defined in test.controller with url saved into urlKey of config
+type controllerChanger struct {
+	urlKey string
+	url    string
+}
+
+func (ctx *controllerChanger) getController() (controller.Cloud, error) {
+	if ctx.url != "" { //overwrite config only if url defined
+		viper.Set(ctx.urlKey, ctx.url)
+	}
+	ctrl, err := controller.CloudPrepare()
+	if err != nil {
+		return nil, fmt.Errorf("CloudPrepare error: %s", err)
+	}
+	return ctrl, nil
+}
+
+func (ctx *controllerChanger) getControllerAndDev() (controller.Cloud, *device.Ctx, error) {
+	ctrl, err := ctx.getController()
+	if err != nil {
+		return nil, nil, fmt.Errorf("getController error: %s", err)
+	}
+	devFirst, err := ctrl.GetDeviceCurrent()
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetDeviceCurrent error: %s", err)
+	}
+	return ctrl, devFirst, nil
+}
+
+func (ctx *controllerChanger) setControllerAndDev(ctrl controller.Cloud, dev *device.Ctx) error {
+	if err := ctrl.ConfigSync(dev); err != nil {
+		return fmt.Errorf("configSync error: %s", err)
+	}
+	return nil
+}
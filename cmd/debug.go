@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/sshclient"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -45,6 +46,37 @@ func initSSHVariables(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
+//dialEVE connects to EVE on host with ssh key from config
+func dialEVE(host string) *sshclient.Client {
+	if _, err := os.Stat(eveSSHKey); err != nil {
+		log.Fatalf("SSH key problem: %s", err)
+	}
+	log.Debugf("Try to ssh %s:%d with key %s", host, eveSSHPort, eveSSHKey)
+	client, err := sshclient.Dial(&sshclient.Config{
+		Host:    host,
+		Port:    eveSSHPort,
+		User:    "root",
+		KeyFile: eveSSHKey,
+		Timeout: defaults.DefaultSSHConnectTimeout,
+	})
+	if err != nil {
+		log.Fatalf("cannot connect to EVE %s:%d: %s", host, eveSSHPort, err)
+	}
+	return client
+}
+
+//runOnEVE runs command on EVE and fails if command returns non-zero exit code
+func runOnEVE(client *sshclient.Client, commandToRun string) {
+	log.Debugf("Run command %s", commandToRun)
+	code, err := client.Run(commandToRun, nil, os.Stdout, os.Stderr)
+	if err != nil {
+		log.Fatalf("ssh error for command %s: %s", commandToRun, err)
+	}
+	if code != 0 {
+		log.Fatalf("command %s exited with code %d", commandToRun, code)
+	}
+}
+
 var debugStartEveCmd = &cobra.Command{
 	Use:     "start",
 	Short:   "start perf in EVE",
@@ -54,16 +86,10 @@ var debugStartEveCmd = &cobra.Command{
 		if eveIP == "" {
 			log.Fatal("Np EVE IP")
 		}
-		if _, err := os.Stat(eveSSHKey); !os.IsNotExist(err) {
-			commandToRun := fmt.Sprintf("perf record %s -o %s", perfOptions, perfLocation)
-			arguments := fmt.Sprintf("-o ConnectTimeout=5 -oStrictHostKeyChecking=no -i %s -p %d root@%s %s", eveSSHKey, eveSSHPort, eveIP, commandToRun)
-			log.Debugf("Try to ssh %s:%d with key %s and command %s", eveHost, eveSSHPort, eveSSHKey, arguments)
-			if _, err := utils.RunCommandBackground("ssh", nil, strings.Fields(arguments)...); err != nil {
-				log.Fatalf("ssh error for command %s: %s", commandToRun, err)
-			}
-		} else {
-			log.Fatalf("SSH key problem: %s", err)
-		}
+		client := dialEVE(eveIP)
+		defer client.Close()
+		//perf must keep running after disconnection
+		runOnEVE(client, fmt.Sprintf("nohup perf record %s -o %s >/dev/null 2>&1 &", perfOptions, perfLocation))
 	},
 }
 
@@ -76,16 +102,9 @@ var debugStopEveCmd = &cobra.Command{
 		if eveIP == "" {
 			log.Fatal("Np EVE IP")
 		}
-		if _, err := os.Stat(eveSSHKey); !os.IsNotExist(err) {
-			commandToRun := "killall perf"
-			arguments := fmt.Sprintf("-o ConnectTimeout=5 -oStrictHostKeyChecking=no -i %s -p %d root@%s %s", eveSSHKey, eveSSHPort, eveIP, commandToRun)
-			log.Debugf("Try to ssh %s:%d with key %s and command %s", eveHost, eveSSHPort, eveSSHKey, arguments)
-			if err := utils.RunCommandForeground("ssh", strings.Fields(arguments)...); err != nil {
-				log.Fatalf("ssh error for command %s: %s", commandToRun, err)
-			}
-		} else {
-			log.Fatalf("SSH key problem: %s", err)
-		}
+		client := dialEVE(eveIP)
+		defer client.Close()
+		runOnEVE(client, "killall perf")
 	},
 }
 
@@ -104,31 +123,22 @@ var debugSaveEveCmd = &cobra.Command{
 		if eveIP == "" {
 			log.Fatal("Np EVE IP")
 		}
-		if _, err := os.Stat(eveSSHKey); !os.IsNotExist(err) {
-			commandToRun := fmt.Sprintf("perf script -i %s > %s", perfLocation, defaults.DefaultPerfScriptEVELocation)
-			arguments := fmt.Sprintf("-o ConnectTimeout=5 -oStrictHostKeyChecking=no -i %s -p %d root@%s %s", eveSSHKey, eveSSHPort, eveIP, commandToRun)
-			log.Debugf("Try to ssh %s:%d with key %s and command %s", eveHost, eveSSHPort, eveSSHKey, arguments)
-			if err := utils.RunCommandForeground("ssh", strings.Fields(arguments)...); err != nil {
-				log.Fatalf("ssh error for command %s: %s", commandToRun, err)
-			}
-			commandToRun = fmt.Sprintf("%s %s", defaults.DefaultPerfScriptEVELocation, tmpFile)
-			arguments = fmt.Sprintf("-o ConnectTimeout=5 -oStrictHostKeyChecking=no -i %s -P %d root@%s:%s", eveSSHKey, eveSSHPort, eveIP, commandToRun)
-			log.Debugf("Try to scp %s:%d with key %s and command %s", eveHost, eveSSHPort, eveSSHKey, arguments)
-			if err := utils.RunCommandForeground("scp", strings.Fields(arguments)...); err != nil {
-				log.Fatalf("scp error for command %s: %s", commandToRun, err)
-			}
-			image := fmt.Sprintf("%s:%s", defaults.DefaultProcContainerRef, defaults.DefaultProcTag)
-			commandToRun = fmt.Sprintf("-i /in/%s -o /out/%s svg", filepath.Base(tmpFile), filepath.Base(absPath))
-			volumeMap := map[string]string{"/in": filepath.Dir(tmpFile), "/out": filepath.Dir(absPath)}
-			var result string
-			if result, err = utils.RunDockerCommand(image, commandToRun, volumeMap); err != nil {
-				log.Fatal(err)
-			}
-			fmt.Println(result)
-			log.Infof("Please see output inside %s", absPath)
-		} else {
-			log.Fatalf("SSH key problem: %s", err)
+		client := dialEVE(eveIP)
+		defer client.Close()
+		runOnEVE(client, fmt.Sprintf("perf script -i %s > %s", perfLocation, defaults.DefaultPerfScriptEVELocation))
+		log.Debugf("Try to copy %s from EVE to %s", defaults.DefaultPerfScriptEVELocation, tmpFile)
+		if err := client.Download(defaults.DefaultPerfScriptEVELocation, tmpFile); err != nil {
+			log.Fatalf("cannot copy %s: %s", defaults.DefaultPerfScriptEVELocation, err)
 		}
+		image := fmt.Sprintf("%s:%s", defaults.DefaultProcContainerRef, defaults.DefaultProcTag)
+		commandToRun := fmt.Sprintf("-i /in/%s -o /out/%s svg", filepath.Base(tmpFile), filepath.Base(absPath))
+		volumeMap := map[string]string{"/in": filepath.Dir(tmpFile), "/out": filepath.Dir(absPath)}
+		var result string
+		if result, err = utils.RunDockerCommand(image, commandToRun, volumeMap); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(result)
+		log.Infof("Please see output inside %s", absPath)
 	},
 }
 
@@ -146,26 +156,18 @@ var debugHardwareEveCmd = &cobra.Command{
 		if eveIP == "" {
 			log.Fatal("Np EVE IP")
 		}
-		if _, err := os.Stat(eveSSHKey); !os.IsNotExist(err) {
-			commandToRun := "lshw"
-			if short {
-				commandToRun = "lshw -short"
-			}
-			arguments := fmt.Sprintf("-o ConnectTimeout=5 -oStrictHostKeyChecking=no -i %s -p %d root@%s %s>%s", eveSSHKey, eveSSHPort, eveIP, commandToRun, hwLocation)
-			log.Debugf("Try to scp %s:%d with key %s and command %s", eveHost, eveSSHPort, eveSSHKey, arguments)
-			if err := utils.RunCommandForeground("ssh", strings.Fields(arguments)...); err != nil {
-				log.Fatalf("ssh error for command %s: %s", arguments, err)
-			}
-			commandToRun = fmt.Sprintf("%s %s", hwLocation, absPath)
-			arguments = fmt.Sprintf("-o ConnectTimeout=5 -oStrictHostKeyChecking=no -i %s -P %d root@%s:%s", eveSSHKey, eveSSHPort, eveIP, commandToRun)
-			log.Debugf("Try to scp %s:%d with key %s and command %s", eveHost, eveSSHPort, eveSSHKey, arguments)
-			if err := utils.RunCommandForeground("scp", strings.Fields(arguments)...); err != nil {
-				log.Fatalf("scp error for command %s: %s", commandToRun, err)
-			}
-			log.Infof("Please see output inside %s", absPath)
-		} else {
-			log.Fatalf("SSH key problem: %s", err)
+		client := dialEVE(eveIP)
+		defer client.Close()
+		commandToRun := "lshw"
+		if short {
+			commandToRun = "lshw -short"
+		}
+		runOnEVE(client, fmt.Sprintf("%s>%s", commandToRun, hwLocation))
+		log.Debugf("Try to copy %s from EVE to %s", hwLocation, absPath)
+		if err := client.Download(hwLocation, absPath); err != nil {
+			log.Fatalf("cannot copy %s: %s", hwLocation, err)
 		}
+		log.Infof("Please see output inside %s", absPath)
 	},
 }
 
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/lf-edge/eden/pkg/manifest"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var manifestFile string
+
+//applyCmd is a command to move EVE to state described in manifest
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply manifest to EVE",
+	Long: `Apply manifest with networks, volumes, apps, datastores and config items to EVE.
+Objects absent in manifest will be removed, except config items and datastores.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if manifestFile == "" {
+			log.Fatal("please define manifest with --file")
+		}
+		desired, err := manifest.Load(manifestFile)
+		if err != nil {
+			log.Fatalf("cannot load manifest: %s", err)
+		}
+		changer, err := changerByControllerMode(controllerMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctrl, dev, err := changer.getControllerAndDev()
+		if err != nil {
+			log.Fatalf("getControllerAndDev: %s", err)
+		}
+		current, err := manifest.Export(ctrl, dev)
+		if err != nil {
+			log.Fatalf("cannot obtain current state: %s", err)
+		}
+		localRegistry := fmt.Sprintf("%s:%d", viper.GetString("registry.ip"), viper.GetInt("registry.port"))
+		desired.ResolveLocalRegistry(localRegistry)
+		changes := manifest.Diff(desired, current)
+		if len(changes) == 0 {
+			log.Info("Nothing to change")
+			return
+		}
+		for _, el := range changes {
+			fmt.Println(el)
+		}
+		if dryRun {
+			return
+		}
+		applier := &manifest.Applier{
+			Ctrl:          ctrl,
+			Dev:           dev,
+			LocalRegistry: localRegistry,
+		}
+		if err = applier.Apply(desired, current, changes); err != nil {
+			log.Fatalf("cannot apply manifest: %s", err)
+		}
+		if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+			log.Fatalf("setControllerAndDev: %s", err)
+		}
+		log.Infof("Manifest %s applied", manifestFile)
+	},
+}
+
+//exportManifestCmd is a command to save current state of EVE into manifest
+var exportManifestCmd = &cobra.Command{
+	Use:   "export-manifest [file]",
+	Short: "Export manifest of EVE",
+	Long:  `Export current networks, volumes, apps, datastores and config items of EVE into manifest to use with apply (prints into stdout if file not defined).`,
+	Args:  cobra.RangeArgs(0, 1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		changer, err := changerByControllerMode(controllerMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctrl, dev, err := changer.getControllerAndDev()
+		if err != nil {
+			log.Fatalf("getControllerAndDev: %s", err)
+		}
+		current, err := manifest.Export(ctrl, dev)
+		if err != nil {
+			log.Fatalf("cannot export manifest: %s", err)
+		}
+		data, err := current.Bytes()
+		if err != nil {
+			log.Fatalf("cannot marshal manifest: %s", err)
+		}
+		if len(args) == 0 {
+			fmt.Print(string(data))
+			return
+		}
+		if err = ioutil.WriteFile(args[0], data, 0644); err != nil {
+			log.Fatalf("cannot write manifest: %s", err)
+		}
+		log.Infof("Manifest saved into %s", args[0])
+	},
+}
+
+func applyInit() {
+	applyCmd.Flags().StringVarP(&manifestFile, "file", "f", "", "manifest file to apply")
+	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print changes without applying them")
+	applyCmd.Flags().StringVarP(&controllerMode, "mode", "m", "", "mode to use [file|proto|adam|zedcloud]://<URL> (default is adam)")
+	exportManifestCmd.Flags().StringVarP(&controllerMode, "mode", "m", "", "mode to use [file|proto|adam|zedcloud]://<URL> (default is adam)")
+}
@@ -9,10 +9,13 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/expect"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve/api/go/config"
@@ -75,6 +78,65 @@ var edgeNodeReboot = &cobra.Command{
 	},
 }
 
+var edgeNodeList = &cobra.Command{
+	Use:   "ls",
+	Short: "list EVE instances",
+	Long:  `List EVE instances known by controller with their state.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading configFile: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		changer, err := changerByControllerMode(controllerMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctrl, err := changer.getController()
+		if err != nil {
+			log.Fatalf("getController error: %s", err)
+		}
+		var currentID string
+		if devCurrent, err := ctrl.GetDeviceCurrent(); err == nil {
+			currentID = devCurrent.GetID().String()
+		} else {
+			log.Debugf("GetDeviceCurrent: %s", err)
+		}
+		devices := ctrl.ListDevices()
+		sort.SliceStable(devices, func(i, j int) bool {
+			return devices[i].GetID().String() < devices[j].GetID().String()
+		})
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+		if _, err = fmt.Fprintln(w, "NAME\tUUID\tSTATE\tSELECTED"); err != nil {
+			log.Fatal(err)
+		}
+		for _, dev := range devices {
+			name := ctrl.GetDeviceName(dev.GetID())
+			if name == "" {
+				name = "-"
+			}
+			state := "ONBOARDED"
+			if dev.GetState() == device.NotOnboarded {
+				state = "NOT ONBOARDED"
+			}
+			selected := ""
+			if dev.GetID().String() == currentID {
+				selected = "*"
+			}
+			if _, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, dev.GetID(), state, selected); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err = w.Flush(); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
 func checkIsFileOrURL(pathToCheck string) (isFile bool, pathToRet string, err error) {
 	res, err := url.Parse(pathToCheck)
 	if err != nil {
@@ -253,10 +315,18 @@ var edgeNodeEVEImageRemove = &cobra.Command{
 }
 
 var edgeNodeUpdate = &cobra.Command{
-	Use:   "update --config key=value --device key=value",
+	Use:   "update --config key=value --device-config key=value",
 	Short: "update EVE config",
 	Long:  `Update EVE config.`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if strings.Contains(deviceSelector, "=") {
+			//compatibility with --device key=value used before --device-config
+			log.Warn("--device key=value is deprecated, use --device-config key=value")
+			split := strings.SplitN(deviceSelector, "=", 2)
+			deviceItems[split[0]] = split[1]
+			deviceSelector = os.Getenv(defaults.DefaultDeviceEnv)
+			viper.Set("eve.device", deviceSelector)
+		}
 		assignCobraToViper(cmd)
 		viperLoaded, err := utils.LoadConfigFile(configFile)
 		if err != nil {
@@ -384,6 +454,7 @@ var edgeNodeSetConfig = &cobra.Command{
 
 func controllerInit() {
 	controllerCmd.AddCommand(edgeNode)
+	edgeNode.AddCommand(edgeNodeList)
 	edgeNode.AddCommand(edgeNodeReboot)
 	edgeNode.AddCommand(edgeNodeEVEImageUpdate)
 	edgeNode.AddCommand(edgeNodeEVEImageRemove)
@@ -405,6 +476,7 @@ func controllerInit() {
 Supported keys are defined in https://github.com/lf-edge/eve/blob/master/docs/CONFIG-PROPERTIES.md`
 	edgeNodeUpdateFlags.StringToStringVar(&configItems, "config", make(map[string]string), configUsage)
 	deviceUsage := `set of key=value items.
-Supported keys: global_profile,local_profile_server,profile_server_token`
-	edgeNodeUpdateFlags.StringToStringVar(&deviceItems, "device", make(map[string]string), deviceUsage)
+Supported keys: global_profile,local_profile_server,profile_server_token
+Use global --device flag or EDEN_DEVICE env to select EVE device for this command`
+	edgeNodeUpdateFlags.StringToStringVar(&deviceItems, "device-config", make(map[string]string), deviceUsage)
 }
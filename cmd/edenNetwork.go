@@ -36,6 +36,9 @@ var networkLsCmd = &cobra.Command{
 		}
 		devModel = viper.GetString("eve.devmodel")
 		qemuPorts = viper.GetStringMapString("eve.hostfwd")
+		if output, err = eve.ParseOutput(outputFormat); err != nil {
+			return err
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -51,7 +54,7 @@ var networkLsCmd = &cobra.Command{
 		if err := ctrl.MetricLastCallback(dev.GetID(), nil, state.MetricCallback()); err != nil {
 			log.Fatalf("fail in get MetricLastCallback: %s", err)
 		}
-		if err := state.NetList(); err != nil {
+		if err := state.NetList(output); err != nil {
 			log.Fatal(err)
 		}
 	},
@@ -200,6 +203,7 @@ var networkCreateCmd = &cobra.Command{
 
 func networkInit() {
 	networkCmd.AddCommand(networkLsCmd)
+	networkLsCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", eve.OutputFormatHelp)
 	networkCmd.AddCommand(networkDeleteCmd)
 	networkCmd.AddCommand(networkNetstatCmd)
 	networkCmd.AddCommand(networkCreateCmd)
@@ -47,12 +47,21 @@ var (
 
 	outputTail   uint
 	outputFields []string
+	outputFormat string
+	output       *eve.Output
 
 	logAppsFormat eapps.LogFormat
 
 	directLoad bool
 	sftpLoad   bool
 
+	registryUser     string
+	registryPassword string
+	registryDocker   bool
+	plaintextCreds   bool
+
+	objectSource string
+
 	openStackMetadata bool
 )
 
@@ -75,7 +84,7 @@ func processAcls(acls []string) map[string][]string {
 
 //podDeployCmd is command for deploy application on EVE
 var podDeployCmd = &cobra.Command{
-	Use:   "deploy (docker|http(s)|file|directory)://(<TAG|PATH>[:<VERSION>] | <URL for qcow2 image> | <path to qcow2 image>)",
+	Use:   "deploy (docker|http(s)|file|directory|s3|azure)://(<TAG|PATH>[:<VERSION>] | <URL for qcow2 image> | <path to qcow2 image> | <bucket>/<key>)",
 	Short: "Deploy app in pod",
 	Long:  `Deploy app in pod.`,
 	Args:  cobra.ExactArgs(1),
@@ -148,6 +157,10 @@ var podDeployCmd = &cobra.Command{
 			registryToUse = ""
 		}
 		opts = append(opts, expect.WithRegistry(registryToUse))
+		opts = append(opts, expect.WithRegistryCredentials(registryUser, registryPassword))
+		opts = append(opts, expect.WithDockerConfigCredentials(registryDocker))
+		opts = append(opts, expect.WithPlaintextCredentials(plaintextCreds))
+		opts = append(opts, expect.WithObjectSource(objectSource))
 		if noHyper {
 			opts = append(opts, expect.WithVirtualizationMode(config.VmMode_NOHYPER))
 		}
@@ -176,6 +189,9 @@ var podPsCmd = &cobra.Command{
 		devModel = viper.GetString("eve.devmodel")
 		qemuPorts = viper.GetStringMapString("eve.hostfwd")
 		eveRemote = viper.GetBool("eve.remote")
+		if output, err = eve.ParseOutput(outputFormat); err != nil {
+			return err
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -191,7 +207,7 @@ var podPsCmd = &cobra.Command{
 		if err := ctrl.MetricLastCallback(dev.GetID(), nil, state.MetricCallback()); err != nil {
 			log.Fatalf("fail in get MetricLastCallback: %s", err)
 		}
-		if err := state.PodsList(); err != nil {
+		if err := state.PodsList(output); err != nil {
 			log.Fatal(err)
 		}
 	},
@@ -359,6 +375,9 @@ var podLogsCmd = &cobra.Command{
 		if err != nil {
 			log.Fatalf("getControllerAndDev: %s", err)
 		}
+		if err = setTimeRange(ctrl); err != nil {
+			log.Fatalf("setTimeRange: %s", err)
+		}
 		for _, el := range dev.GetApplicationInstances() {
 			app, err := ctrl.GetApplicationInstanceConfig(el)
 			if err != nil {
@@ -491,6 +510,11 @@ func podInit() {
 	podDeployCmd.Flags().BoolVar(&aclOnlyHost, "only-host", false, "Allow access only to host and external networks")
 	podDeployCmd.Flags().BoolVar(&noHyper, "no-hyper", false, "Run pod without hypervisor")
 	podDeployCmd.Flags().StringVar(&registry, "registry", "remote", "Select registry to use for containers (remote/local)")
+	podDeployCmd.Flags().StringVar(&registryUser, "registry-user", "", "User to access registry")
+	podDeployCmd.Flags().StringVar(&registryPassword, "registry-password", "", "Password to access registry")
+	podDeployCmd.Flags().BoolVar(&registryDocker, "registry-docker-config", false, "Use credentials from docker config if registry user and password are not set")
+	podDeployCmd.Flags().BoolVar(&plaintextCreds, "plaintext-credentials", false, "Send credentials without encryption if EVE has not reported its ECDH certificate yet")
+	podDeployCmd.Flags().StringVar(&objectSource, "upload", "", "File to upload into object storage before deploy of s3:// or azure:// image")
 	podDeployCmd.Flags().BoolVar(&directLoad, "direct", true, "Use direct download for image instead of eserver")
 	podDeployCmd.Flags().BoolVar(&sftpLoad, "sftp", false, "Force use of sftp to load http/file image from eserver")
 	podDeployCmd.Flags().StringSliceVar(&disks, "disks", nil, `Additional disks to use. You can write it in notation <link> or <mount point>:<link>. Deprecated. Please use volumes instead.`)
@@ -502,6 +526,7 @@ You can set acl for particular network in format '<network_name:acl>'
 To remove acls you can set empty line '<network_name>:'`)
 	podDeployCmd.Flags().BoolVar(&openStackMetadata, "openstack-metadata", false, "Use OpenStack metadata for VM")
 	podCmd.AddCommand(podPsCmd)
+	podPsCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", eve.OutputFormatHelp)
 	podCmd.AddCommand(podStopCmd)
 	podCmd.AddCommand(podStartCmd)
 	podCmd.AddCommand(podDeleteCmd)
@@ -510,5 +535,10 @@ To remove acls you can set empty line '<network_name>:'`)
 	podLogsCmd.Flags().UintVar(&outputTail, "tail", 0, "Show only last N lines")
 	podLogsCmd.Flags().StringSliceVar(&outputFields, "fields", []string{"log", "info", "metric", "netstat", "app"}, "Show defined elements")
 	podLogsCmd.Flags().StringVarP(&logFormatName, "format", "", "lines", "Format to print logs, supports: lines, json")
+	addTimeRangeFlags(podLogsCmd)
 	podModifyInit()
+	podUpdateInit()
+	podOpsInit()
+	podExportInit()
+	podExecInit()
 }
@@ -63,6 +63,8 @@ var startCmd = &cobra.Command{
 			qemuARCH = viper.GetString("eve.arch")
 			qemuOS = viper.GetString("eve.os")
 			qemuAccel = viper.GetBool("eve.accel")
+			qemuTPM = viper.GetBool("eve.tpm")
+			eveNetMode = viper.GetString("eve.net-mode")
 			qemuSMBIOSSerial = viper.GetString("eve.serial")
 			qemuConfigFile = utils.ResolveAbsPath(viper.GetString("eve.qemu-config"))
 			qemuMonitorPort = viper.GetInt("eve.qemu-monitor-port")
@@ -115,8 +117,12 @@ var startCmd = &cobra.Command{
 				log.Infof("EVE is starting in Virtual Box")
 			}
 		} else {
+			bridge, err := startLANForEVE(eveNetMode)
+			if err != nil {
+				log.Fatalf("cannot start LAN: %s", err)
+			}
 			if err := eden.StartEVEQemu(qemuARCH, qemuOS, eveImageFile, qemuSMBIOSSerial, eveTelnetPort, qemuMonitorPort,
-				hostFwd, qemuAccel, qemuConfigFile, eveLogFile, evePidFile, false); err != nil {
+				hostFwd, qemuAccel, qemuTPM, bridge, qemuConfigFile, eveLogFile, evePidFile, false); err != nil {
 				log.Errorf("cannot start eve: %s", err)
 			} else {
 				log.Infof("EVE is starting")
@@ -153,6 +159,8 @@ func startInit() {
 	startCmd.Flags().StringVarP(&qemuARCH, "eve-arch", "", runtime.GOARCH, "arch of system")
 	startCmd.Flags().StringVarP(&qemuOS, "eve-os", "", runtime.GOOS, "os to run on")
 	startCmd.Flags().BoolVarP(&qemuAccel, "eve-accel", "", true, "use acceleration")
+	startCmd.Flags().BoolVarP(&qemuTPM, "eve-tpm", "", defaults.DefaultEVETPM, "run software TPM for EVE")
+	startCmd.Flags().StringVarP(&eveNetMode, "eve-net-mode", "", defaults.DefaultEVENetMode, "networking of EVE in qemu: user or bridge")
 	startCmd.Flags().StringVarP(&qemuSMBIOSSerial, "eve-serial", "", defaults.DefaultEVESerial, "SMBIOS serial")
 	startCmd.Flags().StringVarP(&qemuConfigFile, "qemu-config", "", filepath.Join(currentPath, defaults.DefaultDist, defaults.DefaultQemuFileToSave), "config file to use")
 	startCmd.Flags().IntVarP(&qemuMonitorPort, "qemu-monitor-port", "", defaults.DefaultQemuMonitorPort, "Port for access to QEMU monitor")
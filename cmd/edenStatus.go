@@ -23,6 +23,135 @@ var (
 	allConfigs bool
 )
 
+//componentStatus stores status of harness component for structured output
+type componentStatus struct {
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+//eveStatus stores status of EVE in context for structured output
+type eveStatus struct {
+	Context      string     `json:"context"`
+	State        string     `json:"state"`
+	RemoteIPs    []string   `json:"remoteIPs,omitempty"`
+	LastInfoTime *time.Time `json:"lastInfoTime,omitempty"`
+	MemoryUsed   string     `json:"memoryUsed,omitempty"`
+	MemoryAvail  string     `json:"memoryAvail,omitempty"`
+	Process      string     `json:"process,omitempty"`
+	RequestIP    string     `json:"requestIP,omitempty"`
+}
+
+//harnessStatus stores status of harness for structured output
+type harnessStatus struct {
+	Adam     componentStatus `json:"adam"`
+	Registry componentStatus `json:"registry"`
+	Redis    componentStatus `json:"redis"`
+	EServer  componentStatus `json:"eserver"`
+	EVE      []eveStatus     `json:"eve"`
+}
+
+func newComponentStatus(status string, err error) componentStatus {
+	if err != nil {
+		return componentStatus{Error: err.Error()}
+	}
+	return componentStatus{Status: status}
+}
+
+//eveOnboardState returns state of onboarding of EVE from loaded config
+func eveOnboardState() string {
+	eveUUID := viper.GetString("eve.uuid")
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fi, err := os.Stat(filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", eveUUID)))
+	if err != nil {
+		return "not onboarded"
+	}
+	if fi.Size() > 0 {
+		return "registered"
+	}
+	return "onboarding"
+}
+
+//eveStatusObject returns status of EVE from loaded config
+func eveStatusObject(contextName string, adamRunning bool) eveStatus {
+	status := eveStatus{Context: contextName, State: eveOnboardState()}
+	if adamRunning {
+		changer := &adamChanger{}
+		if ctrl, dev, err := changer.getControllerAndDev(); err == nil {
+			eveState := eve.Init(ctrl, dev)
+			if err = ctrl.InfoLastCallback(dev.GetID(), nil, eveState.InfoCallback()); err != nil {
+				log.Fatalf("Fail in get InfoLastCallback: %s", err)
+			}
+			if err = ctrl.MetricLastCallback(dev.GetID(), nil, eveState.MetricCallback()); err != nil {
+				log.Fatalf("Fail in get MetricLastCallback: %s", err)
+			}
+			if lastDInfo := eveState.InfoAndMetrics().GetDinfo(); lastDInfo != nil {
+				for _, nw := range lastDInfo.Network {
+					status.RemoteIPs = append(status.RemoteIPs, nw.IPAddrs...)
+				}
+				lastSeen := time.Unix(eveState.InfoAndMetrics().GetLastInfoTime().GetSeconds(), 0)
+				status.LastInfoTime = &lastSeen
+			}
+			if lastDMetric := eveState.InfoAndMetrics().GetDeviceMetrics(); lastDMetric != nil {
+				status.MemoryUsed = humanize.Bytes((uint64)(lastDMetric.Memory.GetUsedMem() * humanize.MByte))
+				status.MemoryAvail = humanize.Bytes((uint64)(lastDMetric.Memory.GetAvailMem() * humanize.MByte))
+			}
+		}
+		if ip, err := eveLastRequests(); err == nil {
+			status.RequestIP = ip
+		}
+	}
+	if !eveRemote {
+		var err error
+		if devModel == defaults.DefaultVBoxModel {
+			status.Process, err = eden.StatusEVEVBox(vmName)
+		} else if devModel == defaults.DefaultParallelsModel {
+			status.Process, err = eden.StatusEVEParallels(vmName)
+		} else {
+			status.Process, err = eden.StatusEVEQemu(evePidFile)
+		}
+		if err != nil {
+			status.Process = fmt.Sprintf("error: %s", err)
+		}
+	}
+	return status
+}
+
+//harnessStatusObject returns status of harness components and EVEs in contexts
+func harnessStatusObject() *harnessStatus {
+	statusAdam, errAdam := eden.StatusAdam()
+	statusRegistry, errRegistry := eden.StatusRegistry()
+	statusRedis, errRedis := eden.StatusRedis()
+	statusEServer, errEServer := eden.StatusEServer()
+	status := &harnessStatus{
+		Adam:     newComponentStatus(statusAdam, errAdam),
+		Registry: newComponentStatus(statusRegistry, errRegistry),
+		Redis:    newComponentStatus(statusRedis, errRedis),
+		EServer:  newComponentStatus(statusEServer, errEServer),
+		EVE:      []eveStatus{},
+	}
+	context, err := utils.ContextLoad()
+	if err != nil {
+		log.Fatalf("Load context error: %s", err)
+	}
+	currentContext := context.Current
+	for _, el := range context.ListContexts() {
+		if el == currentContext || allConfigs {
+			context.SetContext(el)
+			configName = el
+			evePidFile = utils.ResolveAbsPath(fmt.Sprintf("%s-eve.pid", el))
+			if _, err := utils.LoadConfigFileContext(context.GetCurrentConfig()); err != nil {
+				log.Fatalf("error reading config: %s", err.Error())
+			}
+			status.EVE = append(status.EVE, eveStatusObject(el, errAdam == nil && statusAdam != "container doesn't exist"))
+		}
+	}
+	context.SetContext(currentContext)
+	return status
+}
+
 const (
 	warnmark = "?" // because some OSes are missing the code for the warnmark ⚠
 	okmark   = "✔"
@@ -118,6 +247,8 @@ func eveStatusQEMU() {
 		fmt.Printf("%s EVE on Qemu status: %s\n", representProcessStatus(statusEVE), statusEVE)
 		fmt.Printf("\tLogs for local EVE at: %s\n", utils.ResolveAbsPath(configName+"-"+"eve.log"))
 	}
+	eveStatusInstances()
+	eveStatusLAN()
 }
 
 func eveStatusVBox() {
@@ -153,9 +284,18 @@ var statusCmd = &cobra.Command{
 			eveRemote = viper.GetBool("eve.remote")
 			devModel = viper.GetString("eve.devmodel")
 		}
+		if output, err = eve.ParseOutput(outputFormat); err != nil {
+			return err
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if !output.IsTable() {
+			if err := output.Print(os.Stdout, harnessStatusObject()); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
 		statusAdam, err := eden.StatusAdam()
 		if err != nil {
 			log.Errorf("%s cannot obtain status of adam: %s", statusWarn(), err)
@@ -205,22 +345,7 @@ var statusCmd = &cobra.Command{
 				if err != nil {
 					log.Fatalf("error reading config: %s", err.Error())
 				}
-				eveUUID := viper.GetString("eve.uuid")
-				edenDir, err := utils.DefaultEdenDir()
-				if err != nil {
-					log.Fatal(err)
-				}
-				fi, err := os.Stat(filepath.Join(edenDir, fmt.Sprintf("state-%s.yml", eveUUID)))
-				if err != nil {
-					fmt.Printf("EVE state: not onboarded\n")
-				} else {
-					size := fi.Size()
-					if size > 0 {
-						fmt.Printf("EVE state: registered\n")
-					} else {
-						fmt.Printf("EVE state: onboarding\n")
-					}
-				}
+				fmt.Printf("EVE state: %s\n", eveOnboardState())
 				fmt.Println()
 				if statusAdam != "container doesn't exist" {
 					eveStatusRemote()
@@ -252,6 +377,7 @@ func statusInit() {
 	statusCmd.Flags().StringVarP(&evePidFile, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file with EVE pid")
 	statusCmd.Flags().BoolVar(&allConfigs, "all", true, "show status for all configs")
 	statusCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
+	statusCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", eve.OutputFormatHelp)
 }
 
 // lastWord get last work in string
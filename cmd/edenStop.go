@@ -30,13 +30,25 @@ var stopCmd = &cobra.Command{
 		}
 		if viperLoaded {
 			evePidFile = utils.ResolveAbsPath(viper.GetString("eve.pid"))
+			eveImageFile = utils.ResolveAbsPath(viper.GetString("eve.image-file"))
 			eveRemote = viper.GetBool("eve.remote")
 			devModel = viper.GetString("eve.devmodel")
+			eveNetMode = viper.GetString("eve.net-mode")
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		eden.StopEden(adamRm, redisRm, registryRm, eserverRm, eveRemote, evePidFile, devModel, vmName)
+		eden.StopEden(adamRm, redisRm, registryRm, eserverRm, eveRemote, evePidFile, filepath.Dir(eveImageFile), devModel, vmName)
+		if !eveRemote && eveNetMode == lanNetMode {
+			if err := eden.StopLAN(viper.GetString("lan.bridge"), viper.GetString("lan.subnet")); err != nil {
+				log.Infof("cannot stop LAN: %s", err)
+			} else {
+				log.Infof("LAN stopped")
+			}
+		}
+		if err := eden.StopObjectStore(objectStoreRm); err != nil {
+			log.Infof("cannot stop object storage: %s", err)
+		}
 	},
 }
 
@@ -49,6 +61,7 @@ func stopInit() {
 	stopCmd.Flags().BoolVarP(&registryRm, "registry-rm", "", false, "registry rm on stop")
 	stopCmd.Flags().BoolVarP(&redisRm, "redis-rm", "", false, "redis rm on stop")
 	stopCmd.Flags().BoolVarP(&eserverRm, "eserver-rm", "", false, "eserver rm on stop")
+	stopCmd.Flags().BoolVarP(&objectStoreRm, "objectstore-rm", "", false, "object storage rm on stop")
 	stopCmd.Flags().StringVarP(&evePidFile, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file with EVE pid")
 	stopCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/tests"
@@ -20,6 +21,7 @@ var (
 	testProg     string
 	testScenario string
 	failScenario string
+	testReport   string
 	curDir       string
 )
 
@@ -32,10 +34,21 @@ test <test_dir> [-s <scenario>] [-t <timewait>] [-v <level>]
 test <test_dir> -l <regexp>
 test <test_dir> -o
 test <test_dir> -r <regexp> [-t <timewait>] [-v <level>]
+test <test_dir> [-s <scenario>] --report <junit.xml|report.json>
 
 `,
 	Args: cobra.MaximumNArgs(1),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if testReport != "" {
+			//resolve path before changing of directory
+			reportPath, err := filepath.Abs(testReport)
+			if err != nil {
+				return err
+			}
+			if err = tests.StartReport(reportPath); err != nil {
+				return err
+			}
+		}
 		if len(args) != 0 {
 			var err error
 			log.Debug("DIR: ", args[0])
@@ -70,6 +83,11 @@ test <test_dir> -r <regexp> [-t <timewait>] [-v <level>]
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		defer func() {
+			if err := tests.FinishReport(); err != nil {
+				log.Fatal(err)
+			}
+		}()
 		defer func() {
 			if curDir != "" {
 				err := os.Chdir(curDir)
@@ -107,5 +125,6 @@ func testInit() {
 	testCmd.Flags().StringVarP(&testList, "list", "l", "", "list tests matching the regular expression")
 	testCmd.Flags().StringVarP(&testScenario, "scenario", "s", "", "scenario for tests bunch running")
 	testCmd.Flags().StringVarP(&failScenario, "fail_scenario", "f", "failScenario.txt", "scenario for test failing")
+	testCmd.Flags().StringVar(&testReport, "report", "", "write results of tests into file in JUnit XML (.xml) or JSON (.json) format")
 	testCmd.Flags().BoolVarP(&testOpts, "opts", "o", false, "Options description for test binary which may be used in test scenarious and '-a|--args' option")
 }
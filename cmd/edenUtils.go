@@ -102,6 +102,9 @@ func utilsInit() {
 	debugInit()
 	utilsCmd.AddCommand(debugCmd)
 	utilsCmd.AddCommand(uploadGitCmd)
+	utilsCmd.AddCommand(sshCmd)
+	utilsCmd.AddCommand(scpCmd)
+	sshInit()
 	sdInfoEveCmd.Flags().StringVar(&syslogOutput, "syslog-out", filepath.Join(currentPath, "syslog.txt"), "File to save syslog.txt")
 	sdInfoEveCmd.Flags().StringVar(&eveReleaseOutput, "everelease-out", filepath.Join(currentPath, "eve-release"), "File to save eve-release")
 }
@@ -31,6 +31,9 @@ var volumeLsCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("error reading config: %s", err.Error())
 		}
+		if output, err = eve.ParseOutput(outputFormat); err != nil {
+			return err
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -46,7 +49,7 @@ var volumeLsCmd = &cobra.Command{
 		if err := ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
 			log.Fatalf("fail in get InfoLastCallback: %s", err)
 		}
-		if err := state.VolumeList(); err != nil {
+		if err := state.VolumeList(output); err != nil {
 			log.Fatal(err)
 		}
 	},
@@ -54,7 +57,7 @@ var volumeLsCmd = &cobra.Command{
 
 //volumeCreateCmd is a command to create volume
 var volumeCreateCmd = &cobra.Command{
-	Use:   "create <(docker|http(s)|file)://(<TAG>[:<VERSION>] | <URL for qcow2 image> | <path to qcow2 image>)>",
+	Use:   "create <(docker|http(s)|file|s3|azure)://(<TAG>[:<VERSION>] | <URL for qcow2 image> | <path to qcow2 image> | <bucket>/<key>)>",
 	Short: "Create volume",
 	Args:  cobra.ExactArgs(1),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -88,6 +91,10 @@ var volumeCreateCmd = &cobra.Command{
 			registryToUse = ""
 		}
 		opts = append(opts, expect.WithRegistry(registryToUse))
+		opts = append(opts, expect.WithRegistryCredentials(registryUser, registryPassword))
+		opts = append(opts, expect.WithDockerConfigCredentials(registryDocker))
+		opts = append(opts, expect.WithPlaintextCredentials(plaintextCreds))
+		opts = append(opts, expect.WithObjectSource(objectSource))
 		expectation := expect.AppExpectationFromURL(ctrl, dev, appLink, volumeName, opts...)
 		volumeConfig := expectation.Volume()
 		if err = changer.setControllerAndDev(ctrl, dev); err != nil {
@@ -253,9 +260,15 @@ var volumeAttachCmd = &cobra.Command{
 
 func volumeInit() {
 	volumeCmd.AddCommand(volumeLsCmd)
+	volumeLsCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", eve.OutputFormatHelp)
 
 	volumeCmd.AddCommand(volumeCreateCmd)
 	volumeCreateCmd.Flags().StringVar(&registry, "registry", "remote", "Select registry to use for containers (remote/local)")
+	volumeCreateCmd.Flags().StringVar(&registryUser, "registry-user", "", "User to access registry")
+	volumeCreateCmd.Flags().StringVar(&registryPassword, "registry-password", "", "Password to access registry")
+	volumeCreateCmd.Flags().BoolVar(&registryDocker, "registry-docker-config", false, "Use credentials from docker config if registry user and password are not set")
+	volumeCreateCmd.Flags().BoolVar(&plaintextCreds, "plaintext-credentials", false, "Send credentials without encryption if EVE has not reported its ECDH certificate yet")
+	volumeCreateCmd.Flags().StringVar(&objectSource, "upload", "", "File to upload into object storage before creation of volume from s3:// or azure:// image")
 	volumeCreateCmd.Flags().StringVar(&diskSize, "disk-size", humanize.Bytes(0), "disk size (empty or 0 - same as in image)")
 	volumeCreateCmd.Flags().StringVarP(&volumeName, "name", "n", "", "name of volume, random if empty")
 	volumeCreateCmd.Flags().StringVar(&volumeType, "format", "", "volume type (qcow2, raw, qcow, vmdk, vhdx or oci)")
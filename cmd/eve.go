@@ -26,6 +26,8 @@ var (
 	qemuARCH          string
 	qemuOS            string
 	qemuAccel         bool
+	qemuTPM           bool
+	eveNetMode        string
 	qemuSMBIOSSerial  string
 	qemuConfigFile    string
 	qemuForeground    bool
@@ -58,6 +60,8 @@ var startEveCmd = &cobra.Command{
 			qemuOS = viper.GetString("eve.os")
 			hostFwd = viper.GetStringMapString("eve.hostfwd")
 			qemuAccel = viper.GetBool("eve.accel")
+			qemuTPM = viper.GetBool("eve.tpm")
+			eveNetMode = viper.GetString("eve.net-mode")
 			qemuSMBIOSSerial = viper.GetString("eve.serial")
 			qemuConfigFile = utils.ResolveAbsPath(viper.GetString("eve.qemu-config"))
 			qemuMonitorPort = viper.GetInt("eve.qemu-monitor-port")
@@ -88,12 +92,17 @@ var startEveCmd = &cobra.Command{
 				log.Infof("EVE is starting in Parallels")
 			}
 		} else {
+			bridge, err := startLANForEVE(eveNetMode)
+			if err != nil {
+				log.Fatalf("cannot start LAN: %s", err)
+			}
 			if err := eden.StartEVEQemu(qemuARCH, qemuOS, eveImageFile, qemuSMBIOSSerial, eveTelnetPort, qemuMonitorPort,
-				hostFwd, qemuAccel, qemuConfigFile, eveLogFile, evePidFile, false); err != nil {
+				hostFwd, qemuAccel, qemuTPM, bridge, qemuConfigFile, eveLogFile, evePidFile, false); err != nil {
 				log.Errorf("cannot start eve: %s", err)
 			} else {
 				log.Infof("EVE is starting")
 			}
+			startEVEInstances(eveCount, bridge)
 		}
 	},
 }
@@ -110,6 +119,7 @@ var stopEveCmd = &cobra.Command{
 		}
 		if viperLoaded {
 			evePidFile = utils.ResolveAbsPath(viper.GetString("eve.pid"))
+			eveImageFile = utils.ResolveAbsPath(viper.GetString("eve.image-file"))
 			eveRemote = viper.GetBool("eve.remote")
 			devModel = viper.GetString("eve.devmodel")
 		}
@@ -138,6 +148,9 @@ var stopEveCmd = &cobra.Command{
 			} else {
 				log.Infof("EVE is stopping")
 			}
+			if err := eden.StopEVEInstances(filepath.Dir(eveImageFile)); err != nil {
+				log.Errorf("cannot stop instances of eve: %s", err)
+			}
 		}
 	},
 }
@@ -325,18 +338,23 @@ var sshEveCmd = &cobra.Command{
 			if err = ctrl.ConfigSync(dev); err != nil {
 				log.Fatal(err)
 			}
-			commandToRun := ""
-			if len(args) > 0 {
-				commandToRun = strings.Join(args, " ")
-			}
 			if !cmd.Flags().Changed("eve-host") {
 				eveHost = getEVEIP()
 			}
-			arguments := fmt.Sprintf("-o ConnectTimeout=5 -oStrictHostKeyChecking=no -i %s -p %d root@%s %s", eveSSHKey, eveSSHPort, eveHost, commandToRun)
-			log.Debugf("Try to ssh %s:%d with key %s and command %s", eveHost, eveSSHPort, eveSSHKey, arguments)
-			if err := utils.RunCommandForeground("ssh", strings.Fields(arguments)...); err != nil {
+			client := dialEVE(eveHost)
+			var code int
+			if len(args) > 0 {
+				code, err = client.Run(strings.Join(args, " "), os.Stdin, os.Stdout, os.Stderr)
+			} else {
+				code, err = client.Shell(os.Stdin, os.Stdout, os.Stderr)
+			}
+			client.Close()
+			if err != nil {
 				log.Fatalf("ssh error: %s", err)
 			}
+			if code != 0 {
+				os.Exit(code)
+			}
 		} else {
 			log.Fatalf("SSH key problem: %s", err)
 		}
@@ -387,6 +405,9 @@ var onboardEveCmd = &cobra.Command{
 		}
 		log.Info("onboarded")
 		log.Info("device UUID: ", dev.GetID().String())
+		if err = onboardEVEInstances(ctrl); err != nil {
+			log.Fatal(err)
+		}
 	},
 }
 
@@ -579,6 +600,8 @@ func eveInit() {
 	startEveCmd.Flags().StringVarP(&qemuARCH, "eve-arch", "", runtime.GOARCH, "arch of system")
 	startEveCmd.Flags().StringVarP(&qemuOS, "eve-os", "", runtime.GOOS, "os to run on")
 	startEveCmd.Flags().BoolVarP(&qemuAccel, "eve-accel", "", true, "use acceleration")
+	startEveCmd.Flags().BoolVarP(&qemuTPM, "eve-tpm", "", defaults.DefaultEVETPM, "run software TPM for EVE")
+	startEveCmd.Flags().StringVarP(&eveNetMode, "eve-net-mode", "", defaults.DefaultEVENetMode, "networking of EVE in qemu: user or bridge")
 	startEveCmd.Flags().StringVarP(&qemuSMBIOSSerial, "eve-serial", "", "", "SMBIOS serial")
 	startEveCmd.Flags().StringVarP(&qemuConfigFile, "qemu-config", "", filepath.Join(currentPath, defaults.DefaultDist, "qemu.conf"), "config file to use")
 	startEveCmd.Flags().StringVarP(&evePidFile, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file for save EVE pid")
@@ -589,6 +612,7 @@ func eveInit() {
 	startEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
 	startEveCmd.Flags().IntVarP(&cpus, "cpus", "", defaults.DefaultCpus, "vbox cpus")
 	startEveCmd.Flags().IntVarP(&mem, "memory", "", defaults.DefaultMemory, "vbox memory size (MB)")
+	startEveCmd.Flags().IntVar(&eveCount, "count", 1, "count of EVE instances to run in qemu (additional instances are named <eve.name>-<index>)")
 	stopEveCmd.Flags().StringVarP(&evePidFile, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file for save EVE pid")
 	stopEveCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
 	statusEveCmd.Flags().StringVarP(&evePidFile, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file for save EVE pid")
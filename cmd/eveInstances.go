@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/models"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var eveCount int
+
+//startEVEInstances prepares and starts additional instances of EVE in qemu with indexes from 1 to count-1
+//and registers their onboarding certificates in controller; instances are attached to bridge if it is not empty
+func startEVEInstances(count int, bridge string) {
+	if count < 2 {
+		return
+	}
+	model, err := models.GetDevModelByName(devModel)
+	if err != nil {
+		log.Fatalf("GetDevModelByName: %s", err)
+	}
+	eveDesc := utils.EVEDescription{
+		Arch:        viper.GetString("eve.arch"),
+		HV:          viper.GetString("eve.hv"),
+		Registry:    viper.GetString("eve.registry"),
+		Tag:         viper.GetString("eve.tag"),
+		Format:      model.DiskFormat(),
+		ImageSizeMB: defaults.DefaultEVEImageSize,
+	}
+	uefiDesc := utils.UEFIDescription{
+		Registry: viper.GetString("eve.registry"),
+		Tag:      viper.GetString("eve.uefi-tag"),
+		Arch:     viper.GetString("eve.arch"),
+	}
+	certsDir := utils.ResolveAbsPath(viper.GetString("eden.certs-dist"))
+	serial := viper.GetString("eve.serial")
+	imagesDist := filepath.Dir(eveImageFile)
+	var ctrl controller.Cloud
+	changer := &adamChanger{}
+	if ctrl, err = changer.getController(); err != nil {
+		log.Errorf("cannot register instances of EVE in controller: %s", err)
+	}
+	for i := 1; i < count; i++ {
+		inst := eden.NewEVEInstance(imagesDist, i)
+		if err := eden.PrepareEVEInstance(inst, certsDir, serial, eveImageFile, qemuConfigFile, eveDesc, uefiDesc); err != nil {
+			log.Fatalf("cannot prepare instance %d of EVE: %s", i, err)
+		}
+		if err := eden.StartEVEQemu(qemuARCH, qemuOS, inst.ImageFile(eveImageFile), inst.Serial(serial),
+			inst.Port(eveTelnetPort), inst.Port(qemuMonitorPort), inst.HostFwd(hostFwd), qemuAccel, qemuTPM,
+			bridge, inst.QemuConfigFile(), inst.LogFile(), inst.PidFile(), false); err != nil {
+			log.Errorf("cannot start instance %d of eve: %s", i, err)
+			continue
+		}
+		log.Infof("EVE instance %s is starting (telnet port %d)", inst.Name(viper.GetString("eve.name")), inst.Port(eveTelnetPort))
+		if ctrl == nil {
+			continue
+		}
+		dev := device.CreateEdgeNode()
+		dev.SetSerial(inst.Serial(serial))
+		dev.SetOnboardKey(inst.OnboardCert())
+		dev.SetDevModel(devModel)
+		if err := ctrl.Register(dev); err != nil {
+			log.Errorf("cannot register instance %d of EVE: %s", i, err)
+		}
+	}
+}
+
+//onboardEVEInstances waits for onboarding of additional instances of EVE, which are not onboarded yet
+func onboardEVEInstances(ctrl controller.Cloud) error {
+	vars := ctrl.GetVars()
+	instances, err := eden.ListEVEInstances(filepath.Dir(utils.ResolveAbsPath(viper.GetString("eve.image-file"))))
+	if err != nil {
+		return err
+	}
+	for _, inst := range instances {
+		name := inst.Name(vars.EveName)
+		if _, err := ctrl.GetDeviceBySelector(name); err == nil {
+			log.Infof("instance %s already onboarded", name)
+			continue
+		}
+		dev := device.CreateEdgeNode()
+		dev.SetSerial(inst.Serial(vars.EveSerial))
+		dev.SetOnboardKey(inst.OnboardCert())
+		dev.SetDevModel(vars.DevModel)
+		if err = ctrl.OnBoardDev(dev); err != nil {
+			return fmt.Errorf("instance %s: %s", name, err)
+		}
+		log.Infof("instance %s onboarded with device UUID %s", name, dev.GetID())
+	}
+	return nil
+}
+
+//eveStatusInstances prints status of additional instances of EVE in qemu
+func eveStatusInstances() {
+	instances, err := eden.ListEVEInstances(filepath.Dir(utils.ResolveAbsPath(viper.GetString("eve.image-file"))))
+	if err != nil {
+		log.Errorf("%s cannot obtain instances of EVE: %s", statusWarn(), err)
+		return
+	}
+	for _, inst := range instances {
+		statusEVE, err := eden.StatusEVEQemu(inst.PidFile())
+		if err != nil {
+			log.Errorf("%s cannot obtain status of EVE instance %d: %s", statusWarn(), inst.Index, err)
+			continue
+		}
+		fmt.Printf("%s EVE instance %s on Qemu status: %s\n", representProcessStatus(statusEVE), inst.Name(viper.GetString("eve.name")), statusEVE)
+		fmt.Printf("\tLogs for EVE instance at: %s\n", inst.LogFile())
+	}
+}
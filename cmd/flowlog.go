@@ -2,10 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve/api/go/flowlog"
 	log "github.com/sirupsen/logrus"
@@ -14,10 +14,10 @@ import (
 )
 
 var netStatCmd = &cobra.Command{
-	Use:   "netstat [field:regexp ...]",
+	Use:   "netstat [field:regexp|(expression) ...]",
 	Short: "Get logs of network packets from a running EVE device",
 	Long: `Scans the ADAM flow messages for correspondence with regular expressions to show network flow statistics
-(TCP and UDP flows with IP addresses, port numbers, counters, whether dropped or accepted)`,
+(TCP and UDP flows with IP addresses, port numbers, counters, whether dropped or accepted)` + queryHelp,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		assignCobraToViper(cmd)
 		viperLoaded, err := utils.LoadConfigFile(configFile)
@@ -36,6 +36,9 @@ var netStatCmd = &cobra.Command{
 		if err != nil {
 			log.Fatalf("CloudPrepare: %s", err)
 		}
+		if err = setTimeRange(ctrl); err != nil {
+			log.Fatalf("setTimeRange: %s", err)
+		}
 		devFirst, err := ctrl.GetDeviceCurrent()
 		if err != nil {
 			log.Fatalf("GetDeviceCurrent error: %s", err)
@@ -46,11 +49,9 @@ var netStatCmd = &cobra.Command{
 			log.Fatalf("Error in get param 'follow'")
 		}
 
-		q := make(map[string]string)
-
-		for _, a := range args[0:] {
-			s := strings.Split(a, ":")
-			q[s[0]] = s[1]
+		q, err := equery.FromArgs(args)
+		if err != nil {
+			log.Fatalf("wrong query: %s", err)
 		}
 
 		handleFunc := func(le *flowlog.FlowMessage) bool {
@@ -85,4 +86,5 @@ func netStatInit() {
 	netStatCmd.Flags().UintVar(&logTail, "tail", 0, "Show only last N lines")
 	netStatCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
 	netStatCmd.Flags().BoolP("follow", "f", false, "Monitor changes in selected directory")
+	addTimeRangeFlags(netStatCmd)
 }
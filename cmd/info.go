@@ -7,9 +7,9 @@ import (
 	"github.com/lf-edge/eve/api/go/info"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
-	"strings"
 
 	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/spf13/cobra"
 )
 
@@ -19,10 +19,10 @@ var (
 )
 
 var infoCmd = &cobra.Command{
-	Use:   "info [field:regexp ...]",
+	Use:   "info [field:regexp|(expression) ...]",
 	Short: "Get information reports from a running EVE device",
 	Long: `
-Scans the ADAM Info for correspondence with regular expressions requests to json fields.`,
+Scans the ADAM Info for correspondence with regular expressions requests to json fields.` + queryHelp,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		assignCobraToViper(cmd)
 		viperLoaded, err := utils.LoadConfigFile(configFile)
@@ -41,6 +41,9 @@ Scans the ADAM Info for correspondence with regular expressions requests to json
 		if err != nil {
 			log.Fatalf("CloudPrepare: %s", err)
 		}
+		if err = setTimeRange(ctrl); err != nil {
+			log.Fatalf("setTimeRange: %s", err)
+		}
 		devFirst, err := ctrl.GetDeviceCurrent()
 		if err != nil {
 			log.Fatalf("GetDeviceCurrent error: %s", err)
@@ -51,10 +54,9 @@ Scans the ADAM Info for correspondence with regular expressions requests to json
 			fmt.Printf("Error in get param 'follow'")
 			return
 		}
-		q := make(map[string]string)
-		for _, a := range args[0:] {
-			s := strings.Split(a, ":")
-			q[s[0]] = s[1]
+		q, err := equery.FromArgs(args)
+		if err != nil {
+			log.Fatalf("wrong query: %s", err)
 		}
 
 		handleInfo := func(im *info.ZInfoMsg, ds []*einfo.ZInfoMsgInterface) bool {
@@ -87,4 +89,5 @@ func infoInit() {
 	infoCmd.Flags().UintVar(&infoTail, "tail", 0, "Show only last N lines")
 	infoCmd.Flags().BoolP("follow", "f", false, "Monitor changes in selected directory")
 	infoCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
+	addTimeRangeFlags(infoCmd)
 }
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/lan"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	lanBridge     string
+	lanSubnet     string
+	lanDNS        string
+	lanHosts      map[string]string
+	lanLeasesFile string
+	lanPidFile    string
+)
+
+//lanNetMode is value of eve.net-mode to attach EVE in qemu to virtual LAN
+const lanNetMode = "bridge"
+
+var lanCmd = &cobra.Command{
+	Use:   "lan",
+	Short: "virtual LAN for EVE in qemu",
+	Long:  `Virtual LAN with bridge, NAT and DHCP/DNS responder for EVE in qemu with eve.net-mode bridge.`,
+}
+
+//lanPreRunE loads settings of LAN from config
+func lanPreRunE(cmd *cobra.Command, args []string) error {
+	assignCobraToViper(cmd)
+	viperLoaded, err := utils.LoadConfigFile(configFile)
+	if err != nil {
+		return fmt.Errorf("error reading config: %s", err.Error())
+	}
+	if viperLoaded {
+		lanBridge = viper.GetString("lan.bridge")
+		lanSubnet = viper.GetString("lan.subnet")
+		lanDNS = viper.GetString("lan.dns")
+	}
+	return nil
+}
+
+//lanGateway returns address of bridge inside lanSubnet
+func lanGateway() (net.IP, error) {
+	_, ipNet, err := net.ParseCIDR(lanSubnet)
+	if err != nil {
+		return nil, err
+	}
+	return lan.Gateway(ipNet), nil
+}
+
+//startLANForEVE starts virtual LAN if EVE uses bridge networking and returns bridge to attach EVE to
+func startLANForEVE(netMode string) (string, error) {
+	switch netMode {
+	case "", defaults.DefaultEVENetMode:
+		return "", nil
+	case lanNetMode:
+	default:
+		return "", fmt.Errorf("unknown eve.net-mode: %s", netMode)
+	}
+	lanBridge = viper.GetString("lan.bridge")
+	lanSubnet = viper.GetString("lan.subnet")
+	gateway, err := lanGateway()
+	if err != nil {
+		return "", err
+	}
+	if eveIP := viper.GetString("adam.eve-ip"); eveIP != gateway.String() {
+		log.Warnf("adam.eve-ip is %s, but EVE can reach controller in LAN with address of bridge %s", eveIP, gateway)
+	}
+	//EVE and applications in LAN resolve domain of controller with DNS responder
+	hosts := map[string]string{viper.GetString("adam.domain"): gateway.String()}
+	if err = eden.StartLAN(lanBridge, lanSubnet, viper.GetString("lan.dns"), hosts); err != nil {
+		return "", err
+	}
+	return lanBridge, nil
+}
+
+//eveStatusLAN prints status of virtual LAN if EVE uses bridge networking
+func eveStatusLAN() {
+	if viper.GetString("eve.net-mode") != lanNetMode {
+		return
+	}
+	bridge := viper.GetString("lan.bridge")
+	statusLAN, err := eden.StatusLAN(bridge)
+	if err != nil {
+		log.Errorf("%s cannot obtain status of LAN: %s", statusWarn(), err)
+		return
+	}
+	fmt.Printf("%s LAN %s status: %s\n", representProcessStatus(statusLAN), bridge, statusLAN)
+	if _, logFile, _, err := eden.LANFiles(bridge); err == nil {
+		fmt.Printf("\tLogs for LAN responders at: %s\n", logFile)
+	}
+}
+
+var startLANCmd = &cobra.Command{
+	Use:     "start",
+	Short:   "start LAN",
+	Long:    `Create bridge with NAT and start DHCP/DNS responder on it.`,
+	PreRunE: lanPreRunE,
+	Run: func(cmd *cobra.Command, args []string) {
+		gateway, err := lanGateway()
+		if err != nil {
+			log.Fatalf("wrong subnet: %s", err)
+		}
+		hosts := map[string]string{viper.GetString("adam.domain"): gateway.String()}
+		if err := eden.StartLAN(lanBridge, lanSubnet, lanDNS, hosts); err != nil {
+			log.Fatalf("cannot start LAN: %s", err)
+		}
+		log.Infof("LAN is running on bridge %s with gateway %s", lanBridge, gateway)
+	},
+}
+
+var stopLANCmd = &cobra.Command{
+	Use:     "stop",
+	Short:   "stop LAN",
+	Long:    `Stop DHCP/DNS responder and remove bridge with NAT.`,
+	PreRunE: lanPreRunE,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := eden.StopLAN(lanBridge, lanSubnet); err != nil {
+			log.Fatalf("cannot stop LAN: %s", err)
+		}
+		log.Infof("LAN on bridge %s stopped", lanBridge)
+	},
+}
+
+var statusLANCmd = &cobra.Command{
+	Use:     "status",
+	Short:   "status of LAN",
+	Long:    `Status of bridge and DHCP/DNS responder.`,
+	PreRunE: lanPreRunE,
+	Run: func(cmd *cobra.Command, args []string) {
+		statusLAN, err := eden.StatusLAN(lanBridge)
+		if err != nil {
+			log.Fatalf("cannot obtain status of LAN: %s", err)
+		}
+		fmt.Printf("LAN %s status: %s\n", lanBridge, statusLAN)
+	},
+}
+
+var leasesLANCmd = &cobra.Command{
+	Use:     "leases",
+	Short:   "show DHCP leases of LAN",
+	Long:    `Show addresses assigned by DHCP responder to EVEs and applications in LAN.`,
+	PreRunE: lanPreRunE,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, _, leasesFile, err := eden.LANFiles(lanBridge)
+		if err != nil {
+			log.Fatal(err)
+		}
+		leases, err := lan.LoadLeases(leasesFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return
+			}
+			log.Fatalf("cannot load leases: %s", err)
+		}
+		fmt.Println("MAC\tIP\tHOSTNAME\tEXPIRES")
+		for _, el := range leases {
+			fmt.Printf("%s\t%s\t%s\t%s\n", el.MAC, el.IP, el.Hostname, el.Expire.Format(time.RFC3339))
+		}
+	},
+}
+
+var serveLANCmd = &cobra.Command{
+	Use:    "serve",
+	Short:  "run DHCP/DNS responder",
+	Long:   `Run DHCP/DNS responder on bridge in foreground, it is started by 'eden lan start'.`,
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, ipNet, err := net.ParseCIDR(lanSubnet)
+		if err != nil {
+			log.Fatalf("wrong subnet: %s", err)
+		}
+		hosts := make(map[string]net.IP, len(lanHosts))
+		for name, ip := range lanHosts {
+			if hosts[name] = net.ParseIP(ip); hosts[name] == nil {
+				log.Fatalf("wrong address of host %s: %s", name, ip)
+			}
+		}
+		server, err := lan.NewServer(&lan.Config{
+			Bridge:     lanBridge,
+			Subnet:     ipNet,
+			Upstream:   lanDNS,
+			Hosts:      hosts,
+			LeasesFile: lanLeasesFile,
+			LeaseTime:  defaults.DefaultLANLeaseTime,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if lanPidFile != "" {
+			if err = ioutil.WriteFile(lanPidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+				log.Fatalf("cannot write pid: %s", err)
+			}
+		}
+		log.Fatal(server.Serve())
+	},
+}
+
+func lanInit() {
+	lanCmd.AddCommand(startLANCmd)
+	lanCmd.AddCommand(stopLANCmd)
+	lanCmd.AddCommand(statusLANCmd)
+	lanCmd.AddCommand(leasesLANCmd)
+	lanCmd.AddCommand(serveLANCmd)
+	for _, cmd := range []*cobra.Command{startLANCmd, stopLANCmd, statusLANCmd, leasesLANCmd, serveLANCmd} {
+		cmd.Flags().StringVarP(&lanBridge, "lan-bridge", "", defaults.DefaultLANBridge, "bridge of LAN")
+		cmd.Flags().StringVarP(&lanSubnet, "lan-subnet", "", defaults.DefaultLANSubnet, "subnet of LAN")
+	}
+	startLANCmd.Flags().StringVarP(&lanDNS, "lan-dns", "", "", "upstream DNS server in host:port format")
+	serveLANCmd.Flags().StringVarP(&lanDNS, "dns", "", "", "upstream DNS server in host:port format")
+	serveLANCmd.Flags().StringToStringVarP(&lanHosts, "host", "", nil, "name=address resolved by DNS responder")
+	serveLANCmd.Flags().StringVarP(&lanLeasesFile, "leases", "", "", "file to store leases")
+	serveLANCmd.Flags().StringVarP(&lanPidFile, "pid-file", "", "", "file to write pid")
+}
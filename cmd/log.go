@@ -2,10 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/equery"
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -16,13 +17,38 @@ var (
 	logTail       uint
 	logFormatName string
 	logFormat     elog.LogFormat
+	timeSince     string
+	timeUntil     string
 )
 
+//queryHelp describes syntax of queries for log, info, metric and netstat commands
+const queryHelp = `
+Arguments in field:regexp form must all match. Arguments enclosed in brackets are expressions which combine
+conditions with AND, OR, NOT and brackets, compare values with ==, !=, >, >=, <, <=,
+match regexp with : or !: and check presence of field with exists(field),
+for example: '(severity>=warning AND NOT source:zedagent)'.`
+
+//addTimeRangeFlags adds since and until flags to filter objects by timestamps
+func addTimeRangeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&timeSince, "since", "", "Show only objects not older than time (RFC3339, date or duration relative to now, e.g. 10m)")
+	cmd.Flags().StringVar(&timeUntil, "until", "", "Show only objects not newer than time (RFC3339, date or duration relative to now, e.g. 10m)")
+}
+
+//setTimeRange parses since and until flags and applies them to controller
+func setTimeRange(ctrl controller.Cloud) error {
+	timeRange, err := types.ParseTimeRange(timeSince, timeUntil)
+	if err != nil {
+		return err
+	}
+	ctrl.SetTimeRange(timeRange)
+	return nil
+}
+
 var logCmd = &cobra.Command{
-	Use:   "log [field:regexp ...]",
+	Use:   "log [field:regexp|(expression) ...]",
 	Short: "Get logs from a running EVE device",
 	Long: `
-Scans the ADAM logs for correspondence with regular expressions requests to json fields.`,
+Scans the ADAM logs for correspondence with regular expressions requests to json fields.` + queryHelp,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		assignCobraToViper(cmd)
 		viperLoaded, err := utils.LoadConfigFile(configFile)
@@ -49,6 +75,9 @@ Scans the ADAM logs for correspondence with regular expressions requests to json
 		if err != nil {
 			log.Fatalf("CloudPrepare: %s", err)
 		}
+		if err = setTimeRange(ctrl); err != nil {
+			log.Fatalf("setTimeRange: %s", err)
+		}
 		devFirst, err := ctrl.GetDeviceCurrent()
 		if err != nil {
 			log.Fatalf("GetDeviceCurrent error: %s", err)
@@ -59,11 +88,9 @@ Scans the ADAM logs for correspondence with regular expressions requests to json
 			log.Fatalf("Error in get param 'follow'")
 		}
 
-		q := make(map[string]string)
-
-		for _, a := range args[0:] {
-			s := strings.Split(a, ":")
-			q[s[0]] = s[1]
+		q, err := equery.FromArgs(args)
+		if err != nil {
+			log.Fatalf("wrong query: %s", err)
 		}
 
 		handleFunc := func(le *elog.FullLogEntry) bool {
@@ -99,4 +126,5 @@ func logInit() {
 	logCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
 	logCmd.Flags().BoolP("follow", "f", false, "Monitor changes in selected directory")
 	logCmd.Flags().StringVarP(&logFormatName, "format", "", "lines", "Format to print logs, supports: lines, json")
+	addTimeRangeFlags(logCmd)
 }
@@ -2,24 +2,31 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
+
 	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/equery"
+	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve/api/go/metrics"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
 
-var metricTail uint
+var (
+	metricTail    uint
+	metricListen  string
+	metricDevices []string
+)
 
 var metricCmd = &cobra.Command{
-	Use:   "metric [field:regexp ...]",
+	Use:   "metric [field:regexp|(expression) ...]",
 	Short: "Get metrics from a running EVE device",
 	Long: `
-Scans the ADAM metrics for correspondence with regular expressions requests to json fields.`,
+Scans the ADAM metrics for correspondence with regular expressions requests to json fields.` + queryHelp,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		assignCobraToViper(cmd)
 		viperLoaded, err := utils.LoadConfigFile(configFile)
@@ -38,6 +45,9 @@ Scans the ADAM metrics for correspondence with regular expressions requests to j
 		if err != nil {
 			log.Fatalf("CloudPrepare: %s", err)
 		}
+		if err = setTimeRange(ctrl); err != nil {
+			log.Fatalf("setTimeRange: %s", err)
+		}
 		devFirst, err := ctrl.GetDeviceCurrent()
 		if err != nil {
 			log.Fatalf("GetDeviceCurrent error: %s", err)
@@ -48,11 +58,9 @@ Scans the ADAM metrics for correspondence with regular expressions requests to j
 			log.Fatalf("Error in get param 'follow'")
 		}
 
-		q := make(map[string]string)
-
-		for _, a := range args[0:] {
-			s := strings.Split(a, ":")
-			q[s[0]] = s[1]
+		q, err := equery.FromArgs(args)
+		if err != nil {
+			log.Fatalf("wrong query: %s", err)
 		}
 
 		handleFunc := func(le *metrics.ZMetricMsg) bool {
@@ -83,8 +91,69 @@ Scans the ADAM metrics for correspondence with regular expressions requests to j
 	},
 }
 
+var metricExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export metrics of EVE devices for Prometheus",
+	Long: `
+Consumes metrics of EVE devices from controller and exposes last values for Prometheus on /metrics endpoint.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctrl, err := controller.CloudPrepare()
+		if err != nil {
+			log.Fatalf("CloudPrepare: %s", err)
+		}
+		var devices []*device.Ctx
+		if len(metricDevices) == 0 {
+			dev, err := ctrl.GetDeviceCurrent()
+			if err != nil {
+				log.Fatalf("GetDeviceCurrent error: %s", err)
+			}
+			devices = append(devices, dev)
+		}
+		for _, el := range metricDevices {
+			dev, err := ctrl.GetDeviceBySelector(el)
+			if err != nil {
+				log.Fatalf("GetDeviceBySelector(%s) error: %s", el, err)
+			}
+			devices = append(devices, dev)
+		}
+		exporter := emetric.NewExporter()
+		for _, dev := range devices {
+			devUUID := dev.GetID()
+			devName := ctrl.GetDeviceName(devUUID)
+			if devName == "" {
+				devName = devUUID.String()
+			}
+			handler := exporter.HandleFactory(devName)
+			if err = ctrl.MetricLastCallback(devUUID, nil, handler); err != nil {
+				log.Warnf("cannot load last metric of %s: %s", devName, err)
+			}
+			go func() {
+				if err := ctrl.MetricChecker(devUUID, nil, handler, emetric.MetricNew, 0); err != nil {
+					log.Errorf("MetricChecker of %s: %s", devName, err)
+				}
+			}()
+			log.Infof("Export metrics of %s", devName)
+		}
+		http.Handle("/metrics", exporter.Handler())
+		log.Infof("Listen on %s", metricListen)
+		log.Fatal(http.ListenAndServe(metricListen, nil))
+	},
+}
+
 func metricInit() {
+	metricCmd.AddCommand(metricExportCmd)
+	metricExportCmd.Flags().StringVar(&metricListen, "listen", ":9100", "Address to listen for Prometheus requests")
+	metricExportCmd.Flags().StringSliceVar(&metricDevices, "devices", nil, "Names or UUIDs of devices to export metrics (current device if empty)")
 	metricCmd.Flags().UintVar(&metricTail, "tail", 0, "Show only last N lines")
 	metricCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
 	metricCmd.Flags().BoolP("follow", "f", false, "Monitor changes in selected metrics")
+	addTimeRangeFlags(metricCmd)
 }
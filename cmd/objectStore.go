@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	minioPort     int
+	azuritePort   int
+	objectStoreRm bool
+)
+
+var objectStoreCmd = &cobra.Command{
+	Use:   "objectstore",
+	Short: "object storage for s3:// and azure:// images",
+}
+
+var startObjectStoreCmd = &cobra.Command{
+	Use:   "start",
+	Short: "start object storage",
+	Long:  `Start MinIO (for s3:// images) and Azurite (for azure:// images) with credentials from objectstore section of config.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := eden.StartObjectStore(minioPort, azuritePort,
+			viper.GetString("objectstore.access-key"), viper.GetString("objectstore.secret-key"),
+			viper.GetString("objectstore.azure-account"), viper.GetString("objectstore.azure-key")); err != nil {
+			log.Errorf("cannot start object storage: %s", err)
+		} else {
+			log.Infof("MinIO is running and accessible on port %d, Azurite is running and accessible on port %d", minioPort, azuritePort)
+		}
+	},
+}
+
+var stopObjectStoreCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "stop object storage",
+	Long:  `Stop MinIO and Azurite.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := eden.StopObjectStore(objectStoreRm); err != nil {
+			log.Errorf("cannot stop object storage: %s", err)
+		}
+	},
+}
+
+var statusObjectStoreCmd = &cobra.Command{
+	Use:   "status",
+	Short: "status of object storage",
+	Long:  `Status of MinIO and Azurite.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		status, err := eden.StatusObjectStore()
+		if err != nil {
+			log.Errorf("cannot obtain status of object storage: %s", err)
+		} else {
+			fmt.Printf("MinIO status: %s\n", status["minio"])
+			fmt.Printf("Azurite status: %s\n", status["azurite"])
+		}
+	},
+}
+
+func objectStoreInit() {
+	objectStoreCmd.AddCommand(startObjectStoreCmd)
+	objectStoreCmd.AddCommand(stopObjectStoreCmd)
+	objectStoreCmd.AddCommand(statusObjectStoreCmd)
+	startObjectStoreCmd.Flags().IntVarP(&minioPort, "minio-port", "", defaults.DefaultObjectStorePort, "MinIO port to start")
+	startObjectStoreCmd.Flags().IntVarP(&azuritePort, "azurite-port", "", defaults.DefaultAzuritePort, "Azurite port to start")
+	stopObjectStoreCmd.Flags().BoolVarP(&objectStoreRm, "objectstore-rm", "", false, "object storage rm on stop")
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/sshclient"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	podExecPort     int
+	podExecUser     string
+	podExecKey      string
+	podExecPassword string
+	podExecOptions  []string
+	podExecTimeout  time.Duration
+)
+
+//podExecCmd is a command to run command inside app with ssh
+var podExecCmd = &cobra.Command{
+	Use:   "exec <app> [-- <command>]",
+	Short: "Run command in pod with ssh",
+	Long: `
+Run command or interactive shell in pod with built-in ssh client.
+Address to access is resolved from published ports of pod and state of EVE.
+Command retries to connect until pod is accessible or timeout expires,
+streams output of command and returns its exit code.`,
+	Args: cobra.MinimumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		if podExecKey == "" && podExecPassword == "" {
+			podExecKey = filepath.Join(utils.ResolveAbsPath(viper.GetString("eden.tests")), defaults.DefaultAppSSHKey)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		appName := args[0]
+		if dash := cmd.ArgsLenAtDash(); dash > 1 || (dash < 0 && len(args) > 1) {
+			log.Fatal("command must be provided after --")
+		}
+		changer := &adamChanger{}
+		ctrl, dev, err := changer.getControllerAndDev()
+		if err != nil {
+			log.Fatalf("getControllerAndDev: %s", err)
+		}
+		if !podExists(ctrl, dev, appName) {
+			log.Fatalf("not found app with name %s", appName)
+		}
+		client := podExecDial(ctrl, dev, appName)
+		var code int
+		if len(args) > 1 {
+			code, err = client.Run(strings.Join(args[1:], " "), os.Stdin, os.Stdout, os.Stderr)
+		} else {
+			code, err = client.Shell(os.Stdin, os.Stdout, os.Stderr)
+		}
+		client.Close()
+		if err != nil {
+			log.Fatalf("ssh error: %s", err)
+		}
+		if code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+//podExists checks if app with appName is in config of dev
+func podExists(ctrl controller.Cloud, dev *device.Ctx, appName string) bool {
+	for _, el := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(el)
+		if err != nil {
+			log.Fatalf("no app in cloud %s: %s", el, err)
+		}
+		if app.Displayname == appName {
+			return true
+		}
+	}
+	return false
+}
+
+//podAddress returns address to access published podExecPort of app from state of EVE
+func podAddress(ctrl controller.Cloud, dev *device.Ctx, appName string) (string, error) {
+	state := eve.Init(ctrl, dev)
+	if err := ctrl.InfoLastCallback(dev.GetID(), nil, state.InfoCallback()); err != nil {
+		return "", fmt.Errorf("fail in get InfoLastCallback: %s", err)
+	}
+	for _, app := range state.Applications() {
+		if app.Name == appName {
+			return app.ExternalAddress(podExecPort)
+		}
+	}
+	return "", fmt.Errorf("no state of app %s", appName)
+}
+
+//podExecDial connects to app with appName, it retries until podExecTimeout expires
+func podExecDial(ctrl controller.Cloud, dev *device.Ctx, appName string) *sshclient.Client {
+	timer := time.NewTimer(podExecTimeout)
+	defer timer.Stop()
+	for {
+		client, err := func() (*sshclient.Client, error) {
+			addr, err := podAddress(ctrl, dev, appName)
+			if err != nil {
+				return nil, err
+			}
+			host, portStr, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			port, err := strconv.Atoi(portStr)
+			if err != nil {
+				return nil, err
+			}
+			cfg := &sshclient.Config{
+				Host:     host,
+				Port:     port,
+				User:     podExecUser,
+				KeyFile:  podExecKey,
+				Password: podExecPassword,
+				Timeout:  defaults.DefaultSSHConnectTimeout,
+			}
+			for _, option := range podExecOptions {
+				if err = cfg.SetOption(option); err != nil {
+					log.Fatal(err)
+				}
+			}
+			log.Debugf("Try to ssh %s@%s", cfg.User, cfg.Addr())
+			return sshclient.Dial(cfg)
+		}()
+		if err == nil {
+			return client
+		}
+		log.Debugf("app %s is not accessible: %s", appName, err)
+		select {
+		case <-timer.C:
+			log.Fatalf("cannot access app %s in %s: %s", appName, podExecTimeout, err)
+		case <-time.After(defaults.DefaultRepeatTimeout):
+		}
+	}
+}
+
+func podExecInit() {
+	podCmd.AddCommand(podExecCmd)
+	podExecCmd.Flags().IntVar(&podExecPort, "port", 22, "Internal port of ssh server in pod, must be published")
+	podExecCmd.Flags().StringVarP(&podExecUser, "user", "u", defaults.DefaultAppSSHUser, "User to log in as")
+	podExecCmd.Flags().StringVarP(&podExecKey, "identity", "i", "", fmt.Sprintf("Private key for authentication (%s inside eden.tests if empty)", defaults.DefaultAppSSHKey))
+	podExecCmd.Flags().StringVar(&podExecPassword, "password", "", "Password for authentication")
+	podExecCmd.Flags().StringArrayVarP(&podExecOptions, "option", "o", nil, "ssh option in OpenSSH format")
+	podExecCmd.Flags().DurationVar(&podExecTimeout, "timeout", defaults.DefaultRepeatTimeout*defaults.DefaultRepeatCount, "Time to wait for pod to be accessible")
+}
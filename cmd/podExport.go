@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var importAppName string
+
+//podExportCmd is a command to export app with referenced objects
+var podExportCmd = &cobra.Command{
+	Use:   "export <app>",
+	Short: "Export pod definition",
+	Long: `
+Export AppInstanceConfig of pod with referenced volumes, content trees, images, datastores and network instances
+from controller into JSON printed to stdout. Use 'eden pod import' to re-create pod from it.`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		appName := args[0]
+		changer := &adamChanger{}
+		ctrl, dev, err := changer.getControllerAndDev()
+		if err != nil {
+			log.Fatalf("getControllerAndDev: %s", err)
+		}
+		for _, appID := range dev.GetApplicationInstances() {
+			app, err := ctrl.GetApplicationInstanceConfig(appID)
+			if err != nil {
+				log.Fatalf("no app in cloud %s: %s", appID, err)
+			}
+			if app.Displayname != appName {
+				continue
+			}
+			bundle, err := controller.GetAppBundle(ctrl, appID)
+			if err != nil {
+				log.Fatalf("cannot export app %s: %s", appName, err)
+			}
+			data, err := json.MarshalIndent(bundle, "", "    ")
+			if err != nil {
+				log.Fatalf("cannot marshal app %s: %s", appName, err)
+			}
+			fmt.Println(string(data))
+			return
+		}
+		log.Fatalf("not found app with name %s", appName)
+	},
+}
+
+//podImportCmd is a command to import app exported by podExportCmd
+var podImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import pod definition",
+	Long: `
+Import pod from JSON file, produced by 'eden pod export', into current device (select another one with global --device flag).
+Objects are re-created with fresh UUIDs, network instances with the same name and datastores
+with the same location are reused. Use '-' as file to read from stdin.`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		var data []byte
+		var err error
+		if args[0] == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(args[0])
+		}
+		if err != nil {
+			log.Fatalf("cannot read %s: %s", args[0], err)
+		}
+		var bundle controller.AppBundle
+		if err = json.Unmarshal(data, &bundle); err != nil {
+			log.Fatalf("cannot parse %s: %s", args[0], err)
+		}
+		changer := &adamChanger{}
+		ctrl, dev, err := changer.getControllerAndDev()
+		if err != nil {
+			log.Fatalf("getControllerAndDev: %s", err)
+		}
+		app, err := controller.ImportAppBundle(ctrl, dev, &bundle, importAppName)
+		if err != nil {
+			log.Fatalf("cannot import app: %s", err)
+		}
+		if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+			log.Fatalf("setControllerAndDev: %s", err)
+		}
+		log.Infof("pod %s (%s) import request sent", app.Displayname, app.Uuidandversion.Uuid)
+	},
+}
+
+func podExportInit() {
+	podCmd.AddCommand(podExportCmd)
+	podCmd.AddCommand(podImportCmd)
+	podImportCmd.Flags().StringVarP(&importAppName, "name", "n", "", "Name for imported pod (name from file is used if empty)")
+}
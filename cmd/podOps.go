@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/config"
+	"github.com/lf-edge/eve/api/go/info"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	opsTimeout time.Duration
+	opsNoWait  bool
+)
+
+//podRestartCmd is a command to restart app
+var podRestartCmd = &cobra.Command{
+	Use:   "restart <app>",
+	Short: "Restart pod",
+	Long: `
+Restart pod by increment of restart counter.
+Command waits for EVE to acknowledge restart (RESTARTING state) and to run app again.`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		podOperation(args[0], "restart", info.ZSwState_RESTARTING, func(app *config.AppInstanceConfig) {
+			if app.Restart == nil {
+				app.Restart = &config.InstanceOpsCmd{Counter: 0}
+			}
+			app.Restart.Counter++
+		})
+	},
+}
+
+//podPurgeCmd is a command to purge app
+var podPurgeCmd = &cobra.Command{
+	Use:   "purge <app>",
+	Short: "Purge pod",
+	Long: `
+Purge pod by increment of purge counter, so EVE will recreate volumes of app.
+Command waits for EVE to acknowledge purge (PURGING state) and to run app again.`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		podOperation(args[0], "purge", info.ZSwState_PURGING, func(app *config.AppInstanceConfig) {
+			if app.Purge == nil {
+				app.Purge = &config.InstanceOpsCmd{Counter: 0}
+			}
+			app.Purge.Counter++
+		})
+	},
+}
+
+//podOperation applies modify to app with appName and waits for EVE to complete operation
+func podOperation(appName, operation string, ack info.ZSwState, modify func(app *config.AppInstanceConfig)) {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDev()
+	if err != nil {
+		log.Fatalf("getControllerAndDev: %s", err)
+	}
+	for _, el := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(el)
+		if err != nil {
+			log.Fatalf("no app in cloud %s: %s", el, err)
+		}
+		if app.Displayname != appName {
+			continue
+		}
+		modify(app)
+		if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+			log.Fatalf("setControllerAndDev: %s", err)
+		}
+		log.Infof("app %s %s request sent", appName, operation)
+		if opsNoWait {
+			return
+		}
+		if err = waitAppRunning(ctrl, dev, app.Uuidandversion.Uuid, "", ack, opsTimeout); err != nil {
+			log.Fatalf("app %s %s failed: %s", appName, operation, err)
+		}
+		log.Infof("app %s %s done", appName, operation)
+		return
+	}
+	log.Infof("not found app with name %s", appName)
+}
+
+func podOpsInit() {
+	podCmd.AddCommand(podRestartCmd)
+	podCmd.AddCommand(podPurgeCmd)
+	for _, cmd := range []*cobra.Command{podRestartCmd, podPurgeCmd} {
+		cmd.Flags().DurationVar(&opsTimeout, "timeout", defaults.DefaultRepeatTimeout*defaults.DefaultRepeatCount, "Time to wait for EVE to complete operation")
+		cmd.Flags().BoolVar(&opsNoWait, "no-wait", false, "Do not wait for EVE to complete operation")
+	}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/expect"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/info"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	updateTimeout    time.Duration
+	updateNoRollback bool
+)
+
+//podUpdateCmd is a command to update image of app
+var podUpdateCmd = &cobra.Command{
+	Use:   "update <app> <new link>",
+	Short: "Update image of pod",
+	Long: `
+Update image of pod with new link, bump version of app and purge it.
+Command waits for app to reach RUNNING state with new image and reverts to the previous image
+if app fails or not runs within timeout.`,
+	Args: cobra.ExactArgs(2),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		appName := args[0]
+		appLink := args[1]
+		changer := &adamChanger{}
+		ctrl, dev, err := changer.getControllerAndDev()
+		if err != nil {
+			log.Fatalf("getControllerAndDev: %s", err)
+		}
+		for _, appID := range dev.GetApplicationInstances() {
+			app, err := ctrl.GetApplicationInstanceConfig(appID)
+			if err != nil {
+				log.Fatalf("no app in cloud %s: %s", appID, err)
+			}
+			if app.Displayname != appName {
+				continue
+			}
+			var opts []expect.ExpectationOption
+			//keep networks of app to not create default one
+			for _, intf := range app.Interfaces {
+				ni, err := ctrl.GetNetworkInstanceConfig(intf.NetworkId)
+				if err != nil {
+					log.Fatalf("no network in cloud %s: %s", intf.NetworkId, err)
+				}
+				opts = append(opts, expect.AddNetInstanceNameAndPortPublish(ni.Displayname, nil))
+			}
+			registryToUse := registry
+			switch registry {
+			case "local":
+				registryToUse = fmt.Sprintf("%s:%d", viper.GetString("registry.ip"), viper.GetInt("registry.port"))
+			case "remote":
+				registryToUse = ""
+			}
+			opts = append(opts, expect.WithRegistry(registryToUse))
+			opts = append(opts, expect.WithRegistryCredentials(registryUser, registryPassword))
+			opts = append(opts, expect.WithDockerConfigCredentials(registryDocker))
+			opts = append(opts, expect.WithPlaintextCredentials(plaintextCreds))
+			opts = append(opts, expect.WithObjectSource(objectSource))
+			opts = append(opts, expect.WithImageFormat(imageFormat))
+			opts = append(opts, expect.WithOldApp(appName))
+			expectation := expect.AppExpectationFromURL(ctrl, dev, appLink, appName, opts...)
+			//new volumes and content trees are added to device, old ones are kept for rollback
+			update := controller.StartAppUpdate(ctrl, dev, app, expectation.Application())
+			if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+				log.Fatalf("setControllerAndDev: %s", err)
+			}
+			log.Infof("update of pod %s to %s request sent", appName, appLink)
+			err = waitAppRunning(ctrl, dev, app.Uuidandversion.Uuid, app.Uuidandversion.Version, info.ZSwState_INVALID, updateTimeout)
+			if err == nil {
+				if err = update.Finish(); err != nil {
+					log.Fatalf("cannot remove old image of app %s: %s", appName, err)
+				}
+				if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+					log.Fatalf("setControllerAndDev: %s", err)
+				}
+				log.Infof("app %s update done", appName)
+				return
+			}
+			if updateNoRollback {
+				log.Fatalf("app %s update failed: %s", appName, err)
+			}
+			log.Errorf("app %s update failed: %s; rollback", appName, err)
+			if err = update.Rollback(); err != nil {
+				log.Fatalf("cannot roll back app %s: %s", appName, err)
+			}
+			if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+				log.Fatalf("setControllerAndDev: %s", err)
+			}
+			if err = waitAppRunning(ctrl, dev, app.Uuidandversion.Uuid, app.Uuidandversion.Version, info.ZSwState_INVALID, updateTimeout); err != nil {
+				log.Fatalf("app %s rollback failed: %s", appName, err)
+			}
+			log.Fatalf("app %s update failed and rolled back", appName)
+		}
+		log.Infof("not found app with name %s", appName)
+	},
+}
+
+//waitAppRunning waits for app to acknowledge operation by moving into ack state
+//(any state except RUNNING if ack is INVALID) and to return into RUNNING state within timeout
+//if version is not empty, only info about app with this version is accepted
+//it returns error if app reaches ERROR state
+func waitAppRunning(ctrl controller.Cloud, dev *device.Ctx, appID, version string, ack info.ZSwState, timeout time.Duration) error {
+	started := false
+	var appErr error
+	processingFunction := func(im *info.ZInfoMsg, ds []*einfo.ZInfoMsgInterface) bool {
+		if im.Ztype != info.ZInfoTypes_ZiApp {
+			return false
+		}
+		if version != "" && im.GetAinfo().AppVersion != version {
+			return false
+		}
+		state := im.GetAinfo().State
+		switch {
+		case state == info.ZSwState_RUNNING:
+			return started
+		case state == info.ZSwState_ERROR:
+			if started {
+				appErr = fmt.Errorf("app in ERROR state: %v", im.GetAinfo().AppErr)
+				return true
+			}
+		case !started && (ack == info.ZSwState_INVALID || state == ack):
+			log.Infof("app %s is in %s state", im.GetAinfo().AppName, state)
+			started = true
+		}
+		return false
+	}
+	infoQ := make(map[string]string)
+	infoQ["InfoContent.Ainfo.AppID"] = appID
+	if err := ctrl.InfoChecker(dev.GetID(), infoQ, processingFunction, einfo.InfoNew, time.Duration(timeout.Seconds())); err != nil {
+		return err
+	}
+	return appErr
+}
+
+func podUpdateInit() {
+	podCmd.AddCommand(podUpdateCmd)
+	podUpdateCmd.Flags().DurationVar(&updateTimeout, "timeout", defaults.DefaultAppUpdateTimeout, "Time to wait for app to run with new image")
+	podUpdateCmd.Flags().BoolVar(&updateNoRollback, "no-rollback", false, "Do not revert to the previous image on failure")
+	podUpdateCmd.Flags().StringVar(&imageFormat, "format", "", "format for image, one of 'container','qcow2','raw','qcow','vmdk','vhdx'; if not provided, defaults to container image for docker and oci transports, qcow2 for file and http/s transports")
+	podUpdateCmd.Flags().StringVar(&registry, "registry", "remote", "Select registry to use for containers (remote/local)")
+	podUpdateCmd.Flags().StringVar(&registryUser, "registry-user", "", "User to access registry")
+	podUpdateCmd.Flags().StringVar(&registryPassword, "registry-password", "", "Password to access registry")
+	podUpdateCmd.Flags().BoolVar(&registryDocker, "registry-docker-config", false, "Use credentials from docker config if registry user and password are not set")
+	podUpdateCmd.Flags().BoolVar(&plaintextCreds, "plaintext-credentials", false, "Send credentials without encryption if EVE has not reported its ECDH certificate yet")
+	podUpdateCmd.Flags().StringVar(&objectSource, "upload", "", "File to upload into object storage before update to s3:// or azure:// image")
+}
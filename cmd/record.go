@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/archive"
+	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/protodir"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/flowlog"
+	"github.com/lf-edge/eve/api/go/info"
+	"github.com/lf-edge/eve/api/go/metrics"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	recordExisting bool
+	recordDuration time.Duration
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record <dir>",
+	Short: "Record objects from EVE into archive",
+	Long: `
+Tails logs, info, metrics, flow logs and API requests of EVE device into compact timestamp-indexed archive inside dir.
+Config and certificate of device are stored in dir too, so it may be used as proto directory
+(set test.controller to proto://<dir>) to replay archive with log, info, metric and netstat commands.
+Use proto.replay-speed to replay archive with pace relative to original (0 to see all objects at once).`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		assignCobraToViper(cmd)
+		_, err := utils.LoadConfigFile(configFile)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s", err.Error())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctrl, err := controller.CloudPrepare()
+		if err != nil {
+			log.Fatalf("CloudPrepare: %s", err)
+		}
+		dev, err := ctrl.GetDeviceCurrent()
+		if err != nil {
+			log.Fatalf("GetDeviceCurrent error: %s", err)
+		}
+		devUUID := dev.GetID()
+		dir := utils.ResolveAbsPath(args[0])
+		protoCtx := &protodir.Ctx{}
+		if err = protoCtx.InitWithVars(&utils.ConfigVars{ProtoDir: dir}); err != nil {
+			log.Fatalf("cannot init directory %s: %s", dir, err)
+		}
+		if err = recordDevice(ctrl, protoCtx, dev); err != nil {
+			log.Fatalf("cannot save device: %s", err)
+		}
+		writer := archive.NewWriter(dir)
+		saveData := func(typeToProcess types.LoaderObjectType, data []byte, err error) bool {
+			if err != nil {
+				log.Errorf("cannot marshal object: %s", err)
+				return false
+			}
+			if err = writer.CheckAndSave(devUUID, uuid.Nil, typeToProcess, data); err != nil {
+				log.Errorf("cannot save object: %s", err)
+			}
+			return false
+		}
+		save := func(typeToProcess types.LoaderObjectType, m proto.Message) bool {
+			data, err := protojson.Marshal(m)
+			return saveData(typeToProcess, data, err)
+		}
+		logHandler := func(le *elog.FullLogEntry) bool {
+			//keep image and version of EVE stored outside of LogEntry
+			data, err := elog.MarshalFullLogEntry(le)
+			return saveData(types.LogsType, data, err)
+		}
+		infoHandler := func(im *info.ZInfoMsg, _ []*einfo.ZInfoMsgInterface) bool {
+			return save(types.InfoType, im)
+		}
+		metricHandler := func(mm *metrics.ZMetricMsg) bool {
+			return save(types.MetricsType, mm)
+		}
+		flowLogHandler := func(fm *flowlog.FlowMessage) bool {
+			return save(types.FlowLogType, fm)
+		}
+		if recordExisting {
+			if err = ctrl.LogLastCallback(devUUID, nil, logHandler); err != nil {
+				log.Warnf("cannot record existing logs: %s", err)
+			}
+			if err = ctrl.InfoLastCallback(devUUID, nil, infoHandler); err != nil {
+				log.Warnf("cannot record existing info: %s", err)
+			}
+			if err = ctrl.MetricLastCallback(devUUID, nil, metricHandler); err != nil {
+				log.Warnf("cannot record existing metrics: %s", err)
+			}
+			if err = ctrl.FlowLogLastCallback(devUUID, nil, flowLogHandler); err != nil {
+				log.Warnf("cannot record existing flow logs: %s", err)
+			}
+		}
+		go func() {
+			if err := ctrl.LogChecker(devUUID, nil, logHandler, elog.LogNew, 0); err != nil {
+				log.Errorf("LogChecker: %s", err)
+			}
+		}()
+		go func() {
+			if err := ctrl.InfoChecker(devUUID, nil, infoHandler, einfo.InfoNew, 0); err != nil {
+				log.Errorf("InfoChecker: %s", err)
+			}
+		}()
+		go func() {
+			if err := ctrl.MetricChecker(devUUID, nil, metricHandler, emetric.MetricNew, 0); err != nil {
+				log.Errorf("MetricChecker: %s", err)
+			}
+		}()
+		go func() {
+			if err := ctrl.FlowLogChecker(devUUID, nil, flowLogHandler, eflowlog.FlowLogNew, 0); err != nil {
+				log.Errorf("FlowLogChecker: %s", err)
+			}
+		}()
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+		if recordDuration > 0 {
+			time.AfterFunc(recordDuration, func() {
+				stop <- syscall.SIGTERM
+			})
+		}
+		var lastRequest time.Time
+		if !recordExisting {
+			lastRequest = time.Now()
+		}
+		ticker := time.NewTicker(defaults.DefaultRepeatTimeout)
+		defer ticker.Stop()
+		log.Infof("Recording of %s into %s started", devUUID, dir)
+	loop:
+		for {
+			lastRequest = recordRequests(ctrl, writer, devUUID, lastRequest)
+			select {
+			case <-stop:
+				break loop
+			case <-ticker.C:
+			}
+		}
+		if err = writer.Close(); err != nil {
+			log.Errorf("cannot close archive: %s", err)
+		}
+		if err = recordDevice(ctrl, protoCtx, dev); err != nil {
+			log.Errorf("cannot save device: %s", err)
+		}
+		log.Infof("Recording of %s into %s stopped", devUUID, dir)
+	},
+}
+
+//recordDevice saves config and certificate of device into protoCtx
+func recordDevice(ctrl controller.Cloud, protoCtx *protodir.Ctx, dev *device.Ctx) error {
+	devConfig, err := ctrl.ConfigGet(dev.GetID())
+	if err != nil {
+		return err
+	}
+	if err = protoCtx.ConfigSet(dev.GetID(), []byte(devConfig)); err != nil {
+		return err
+	}
+	devCert, err := ctrl.GetDeviceCert(dev)
+	if err != nil || devCert == nil || len(devCert.Cert) == 0 {
+		log.Warnf("cannot obtain certificate of device %s: %v", dev.GetID(), err)
+		return nil
+	}
+	return protoCtx.UploadDeviceCert(*devCert)
+}
+
+//recordRequests saves requests newer than since and returns timestamp of the last saved one
+func recordRequests(ctrl controller.Cloud, writer *archive.Writer, devUUID uuid.UUID, since time.Time) time.Time {
+	var requests []*types.APIRequest
+	handler := func(request *types.APIRequest) bool {
+		//requests are processed from the newest, so stop on already saved one
+		if !request.Timestamp.After(since) {
+			return true
+		}
+		requests = append(requests, request)
+		return false
+	}
+	if err := ctrl.RequestLastCallback(devUUID, nil, handler); err != nil {
+		log.Debugf("RequestLastCallback: %s", err)
+		return since
+	}
+	last := since
+	for _, request := range requests {
+		data, err := json.Marshal(request)
+		if err != nil {
+			log.Errorf("cannot marshal request: %s", err)
+			continue
+		}
+		if err = writer.CheckAndSave(devUUID, uuid.Nil, types.RequestType, data); err != nil {
+			log.Errorf("cannot save request: %s", err)
+			continue
+		}
+		if request.Timestamp.After(last) {
+			last = request.Timestamp
+		}
+	}
+	return last
+}
+
+func recordInit() {
+	recordCmd.Flags().BoolVar(&recordExisting, "existing", false, "Record objects already stored in controller too")
+	recordCmd.Flags().DurationVar(&recordDuration, "duration", 0, "Stop recording after duration (0 to record until interrupted)")
+}
@@ -18,6 +18,7 @@ import (
 var verbosity string
 var configName string
 var configFile string
+var deviceSelector string
 
 var rootCmd = &cobra.Command{Use: "eden", PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 	configNameEnv := os.Getenv(defaults.DefaultConfigEnv)
@@ -25,6 +26,12 @@ var rootCmd = &cobra.Command{Use: "eden", PersistentPreRunE: func(cmd *cobra.Com
 		configName = configNameEnv
 	}
 	configFile = utils.GetConfig(configName)
+	if deviceSelector == "" {
+		deviceSelector = os.Getenv(defaults.DefaultDeviceEnv)
+	}
+	if deviceSelector != "" {
+		viper.Set("eve.device", deviceSelector)
+	}
 	if verbosity == "debug" {
 		fmt.Println("configName: ", configName)
 		fmt.Println("configFile: ", configFile)
@@ -71,6 +78,8 @@ func init() {
 	adamInit()
 	rootCmd.AddCommand(registryCmd)
 	registryInit()
+	rootCmd.AddCommand(objectStoreCmd)
+	objectStoreInit()
 	rootCmd.AddCommand(redisCmd)
 	redisInit()
 	rootCmd.AddCommand(eserverCmd)
@@ -95,11 +104,21 @@ func init() {
 	exportImportInit()
 	rootCmd.AddCommand(volumeCmd)
 	volumeInit()
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(exportManifestCmd)
+	applyInit()
+	rootCmd.AddCommand(recordCmd)
+	recordInit()
+	rootCmd.AddCommand(attestCmd)
+	attestInit()
+	rootCmd.AddCommand(lanCmd)
+	lanInit()
 }
 
 // Execute primary function for cobra
 func Execute() {
 	rootCmd.PersistentFlags().StringVar(&configName, "config", defaults.DefaultContext, "Name of config")
+	rootCmd.PersistentFlags().StringVar(&deviceSelector, "device", "", fmt.Sprintf("Name or UUID of EVE device to use (default is eve.uuid from config, also can be set with %s env)", defaults.DefaultDeviceEnv))
 	rootCmd.PersistentFlags().StringVarP(&verbosity, "verbosity", "v", log.InfoLevel.String(), "Log level (debug, info, warn, error, fatal, panic")
 	_ = rootCmd.Execute()
 }
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/sshclient"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sshIdentity   string
+	sshPort       int
+	sshUser       string
+	sshPassword   string
+	sshOptions    []string
+	sshForwards   []string
+	sshNoCommand  bool
+	scpPort       int
+	scpIdentity   string
+	scpSSHOptions []string
+)
+
+//sshConfig returns config of connection to destination in [user@]host format with options in OpenSSH format
+func sshConfig(destination, keyFile string, port int, options []string) (*sshclient.Config, error) {
+	user, host := sshclient.ParseDestination(destination, sshUser)
+	cfg := &sshclient.Config{
+		Host:     host,
+		Port:     port,
+		User:     user,
+		KeyFile:  keyFile,
+		Password: sshPassword,
+		Timeout:  defaults.DefaultSSHConnectTimeout,
+	}
+	for _, option := range options {
+		if err := cfg.SetOption(option); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.User == "" {
+		cfg.User = "root"
+	}
+	return cfg, nil
+}
+
+//parseForward returns local and remote addresses from forward in [bind_address:]port:host:hostport format
+func parseForward(forward string) (string, string, error) {
+	parts := strings.Split(forward, ":")
+	switch len(parts) {
+	case 3:
+		return net.JoinHostPort("localhost", parts[0]), net.JoinHostPort(parts[1], parts[2]), nil
+	case 4:
+		return net.JoinHostPort(parts[0], parts[1]), net.JoinHostPort(parts[2], parts[3]), nil
+	}
+	return "", "", fmt.Errorf("wrong forward %s, expected [bind_address:]port:host:hostport", forward)
+}
+
+var sshCmd = &cobra.Command{
+	Use:   "ssh [user@]host [command]",
+	Short: "run command or shell with ssh",
+	Long: `Run command or interactive shell on host with built-in ssh client.
+Exit code of command is returned. Common options of OpenSSH are supported with -o.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := sshConfig(args[0], sshIdentity, sshPort, sshOptions)
+		if err != nil {
+			log.Fatal(err)
+		}
+		client, err := sshclient.Dial(cfg)
+		if err != nil {
+			log.Fatalf("cannot connect to %s: %s", cfg.Addr(), err)
+		}
+		defer client.Close()
+		for _, forward := range sshForwards {
+			local, remote, err := parseForward(forward)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if _, err = client.Forward(local, remote); err != nil {
+				log.Fatalf("cannot forward %s: %s", forward, err)
+			}
+			log.Infof("Forwarding %s to %s", local, remote)
+		}
+		if sshNoCommand {
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+			<-sig
+			return
+		}
+		var code int
+		if len(args) > 1 {
+			code, err = client.Run(strings.Join(args[1:], " "), os.Stdin, os.Stdout, os.Stderr)
+		} else {
+			code, err = client.Shell(os.Stdin, os.Stdout, os.Stderr)
+		}
+		if err != nil {
+			log.Fatalf("ssh error: %s", err)
+		}
+		if code != 0 {
+			client.Close()
+			os.Exit(code)
+		}
+	},
+}
+
+var scpCmd = &cobra.Command{
+	Use:   "scp <source> <target>",
+	Short: "copy file with sftp",
+	Long: `Copy file from or to host with built-in sftp client.
+Remote file is defined as [user@]host:path.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		srcDest, srcPath, srcRemote := sshclient.ParseRemotePath(args[0])
+		dstDest, dstPath, dstRemote := sshclient.ParseRemotePath(args[1])
+		if srcRemote == dstRemote {
+			log.Fatal("exactly one of source and target must be remote")
+		}
+		destination := srcDest
+		if dstRemote {
+			destination = dstDest
+		}
+		cfg, err := sshConfig(destination, scpIdentity, scpPort, scpSSHOptions)
+		if err != nil {
+			log.Fatal(err)
+		}
+		client, err := sshclient.Dial(cfg)
+		if err != nil {
+			log.Fatalf("cannot connect to %s: %s", cfg.Addr(), err)
+		}
+		defer client.Close()
+		if dstRemote {
+			err = client.Upload(srcPath, dstPath)
+		} else {
+			err = client.Download(srcPath, dstPath)
+		}
+		if err != nil {
+			log.Fatalf("scp error: %s", err)
+		}
+	},
+}
+
+func sshInit() {
+	sshCmd.Flags().SetInterspersed(false)
+	sshCmd.Flags().StringVarP(&sshIdentity, "identity", "i", "", "private key for authentication")
+	sshCmd.Flags().IntVarP(&sshPort, "port", "p", 22, "port to connect to")
+	sshCmd.Flags().StringVarP(&sshUser, "login", "l", "", "user to log in as")
+	sshCmd.Flags().StringVar(&sshPassword, "password", "", "password for authentication")
+	sshCmd.Flags().StringArrayVarP(&sshOptions, "option", "o", nil, "option in OpenSSH format (ConnectTimeout, StrictHostKeyChecking, UserKnownHostsFile, ServerAliveInterval, PasswordAuthentication)")
+	sshCmd.Flags().StringArrayVarP(&sshForwards, "local-forward", "L", nil, "forward local port to remote host in [bind_address:]port:host:hostport format")
+	sshCmd.Flags().BoolVarP(&sshNoCommand, "no-command", "N", false, "do not run command, only forward ports")
+	scpCmd.Flags().StringVarP(&scpIdentity, "identity", "i", "", "private key for authentication")
+	scpCmd.Flags().IntVarP(&scpPort, "port", "P", 22, "port to connect to")
+	scpCmd.Flags().StringVar(&sshPassword, "password", "", "password for authentication")
+	scpCmd.Flags().StringArrayVarP(&scpSSHOptions, "option", "o", nil, "option in OpenSSH format")
+}
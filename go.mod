@@ -47,7 +47,7 @@ require (
 	github.com/onsi/gomega v1.10.0 // indirect
 	github.com/opencontainers/selinux v1.7.0 // indirect
 	github.com/pelletier/go-toml v1.9.0 // indirect
-	github.com/prometheus/client_golang v1.8.0 // indirect
+	github.com/prometheus/client_golang v1.8.0
 	github.com/prometheus/common v0.15.0 // indirect
 	github.com/rogpeppe/go-internal v1.6.0
 	github.com/satori/go.uuid v1.2.1-0.20181028125025-b2ce2384e17b
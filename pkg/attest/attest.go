@@ -0,0 +1,418 @@
+//Package attest provides primitives to verify attestation certificates and TPM quotes
+//reported by EVE and to compare PCR values with stored baseline.
+package attest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha1" //register hash functions used by TPM
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"sort"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/attest"
+	"github.com/lf-edge/eve/api/go/certs"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+//constants from TPM 2.0 specification
+const (
+	tpmGeneratedValue = 0xff544347
+	tpmSTAttestQuote  = 0x8018
+
+	tpmAlgRSASSA = 0x0014
+	tpmAlgRSAPSS = 0x0016
+	tpmAlgECDSA  = 0x0018
+
+	tpmAlgSHA1   = 0x0004
+	tpmAlgSHA256 = 0x000B
+	tpmAlgSHA384 = 0x000C
+	tpmAlgSHA512 = 0x000D
+)
+
+//CertStatus is result of verification of attestation certificate
+type CertStatus struct {
+	Type    string
+	Subject string
+	Error   string `json:",omitempty"`
+}
+
+//PCRSelection is a list of selected PCRs inside bank with hash algorithm HashAlg
+type PCRSelection struct {
+	HashAlg uint16
+	PCRs    []int
+}
+
+//QuoteInfo contains fields of TPMS_ATTEST structure of quote
+type QuoteInfo struct {
+	Nonce     []byte
+	Selection []PCRSelection
+	PCRDigest []byte
+}
+
+//Baseline contains expected values of PCRs from SHA256 bank in hex format indexed by number of PCR
+type Baseline map[uint32]string
+
+//VerifyCerts checks that attestation certificates are signed by device certificate in PEM format
+func VerifyCerts(deviceCert []byte, zcerts []*certs.ZCert) ([]*CertStatus, error) {
+	devCert, err := utils.ParseFirstCertFromBlock(deviceCert)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse device certificate: %s", err)
+	}
+	var result []*CertStatus
+	for _, el := range zcerts {
+		status := &CertStatus{Type: el.Type.String()}
+		cert, err := utils.ParseFirstCertFromBlock(el.Cert)
+		if err != nil {
+			status.Error = fmt.Sprintf("cannot parse certificate: %s", err)
+		} else {
+			status.Subject = cert.Subject.String()
+			if err = cert.CheckSignatureFrom(devCert); err != nil {
+				status.Error = fmt.Sprintf("not signed by device certificate: %s", err)
+			}
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+//GetDeviceCerts returns attestation certificates of dev from controller
+func GetDeviceCerts(ctrl controller.Cloud, dev *device.Ctx) ([]*certs.ZCert, error) {
+	attestData, err := ctrl.CertsGet(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("cannot get attestation certificates from cloud for %s: %s", dev.GetID(), err)
+	}
+	req := &types.Zcerts{}
+	if err := json.Unmarshal([]byte(attestData), req); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal attest: %v", err)
+	}
+	if len(req.Certs) == 0 {
+		return nil, fmt.Errorf("no attestation certificates reported by device %s", dev.GetID())
+	}
+	return req.Certs, nil
+}
+
+//VerifyDeviceCerts verifies attestation certificates of dev from controller against device certificate
+//it returns results of verification and certificates
+func VerifyDeviceCerts(ctrl controller.Cloud, dev *device.Ctx) ([]*CertStatus, []*certs.ZCert, error) {
+	zcerts, err := GetDeviceCerts(ctrl, dev)
+	if err != nil {
+		return nil, nil, err
+	}
+	devCert, err := ctrl.GetDeviceCert(dev)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot get device certificate: %s", err)
+	}
+	statuses, err := VerifyCerts(devCert.Cert, zcerts)
+	return statuses, zcerts, err
+}
+
+//GetCert returns certificate with certType from zcerts
+func GetCert(zcerts []*certs.ZCert, certType certs.ZCertType) (*x509.Certificate, error) {
+	for _, el := range zcerts {
+		if el.Type == certType {
+			return utils.ParseFirstCertFromBlock(el.Cert)
+		}
+	}
+	return nil, fmt.Errorf("no %s certificate", certType)
+}
+
+func readTPM2B(r io.Reader) ([]byte, error) {
+	var size uint16
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+//ParseQuoteInfo parses TPMS_ATTEST structure of quote
+func ParseQuoteInfo(attestData []byte) (*QuoteInfo, error) {
+	r := bytes.NewReader(attestData)
+	var header struct {
+		Magic uint32
+		Type  uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.Magic != tpmGeneratedValue || header.Type != tpmSTAttestQuote {
+		return nil, fmt.Errorf("not a quote: magic %x, type %x", header.Magic, header.Type)
+	}
+	//qualifiedSigner
+	if _, err := readTPM2B(r); err != nil {
+		return nil, err
+	}
+	info := &QuoteInfo{}
+	var err error
+	if info.Nonce, err = readTPM2B(r); err != nil {
+		return nil, err
+	}
+	//clockInfo (17 bytes) and firmwareVersion (8 bytes)
+	if _, err = r.Seek(17+8, io.SeekCurrent); err != nil {
+		return nil, err
+	}
+	var count uint32
+	if err = binary.Read(r, binary.BigEndian, &count); err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < count; i++ {
+		var selection struct {
+			HashAlg      uint16
+			SizeofSelect uint8
+		}
+		if err = binary.Read(r, binary.BigEndian, &selection); err != nil {
+			return nil, err
+		}
+		bitmap := make([]byte, selection.SizeofSelect)
+		if _, err = io.ReadFull(r, bitmap); err != nil {
+			return nil, err
+		}
+		pcrSelection := PCRSelection{HashAlg: selection.HashAlg}
+		for ind, b := range bitmap {
+			for bit := 0; bit < 8; bit++ {
+				if b&(1<<uint(bit)) != 0 {
+					pcrSelection.PCRs = append(pcrSelection.PCRs, ind*8+bit)
+				}
+			}
+		}
+		info.Selection = append(info.Selection, pcrSelection)
+	}
+	if info.PCRDigest, err = readTPM2B(r); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func tpmHash(alg uint16) (crypto.Hash, error) {
+	switch alg {
+	case tpmAlgSHA1:
+		return crypto.SHA1, nil
+	case tpmAlgSHA256:
+		return crypto.SHA256, nil
+	case tpmAlgSHA384:
+		return crypto.SHA384, nil
+	case tpmAlgSHA512:
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported hash algorithm %x", alg)
+}
+
+func pcrHashAlgo(alg uint16) attest.TpmHashAlgo {
+	switch alg {
+	case tpmAlgSHA1:
+		return attest.TpmHashAlgo_TPM_HASH_ALGO_SHA1
+	case tpmAlgSHA256:
+		return attest.TpmHashAlgo_TPM_HASH_ALGO_SHA256
+	case tpmAlgSHA512:
+		return attest.TpmHashAlgo_TPM_HASH_ALGO_SHA512
+	}
+	return attest.TpmHashAlgo_TPM_HASH_ALGO_INVALID
+}
+
+//verifySignature checks TPMT_SIGNATURE signature of data with public key of akCert
+func verifySignature(akCert *x509.Certificate, data, signature []byte) (crypto.Hash, error) {
+	r := bytes.NewReader(signature)
+	var header struct {
+		SigAlg  uint16
+		HashAlg uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return 0, err
+	}
+	hash, err := tpmHash(header.HashAlg)
+	if err != nil {
+		return 0, err
+	}
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+	switch header.SigAlg {
+	case tpmAlgECDSA:
+		pub, ok := akCert.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return 0, fmt.Errorf("ECDSA signature with not ECDSA key")
+		}
+		sigR, err := readTPM2B(r)
+		if err != nil {
+			return 0, err
+		}
+		sigS, err := readTPM2B(r)
+		if err != nil {
+			return 0, err
+		}
+		if !ecdsa.Verify(pub, digest, new(big.Int).SetBytes(sigR), new(big.Int).SetBytes(sigS)) {
+			return 0, fmt.Errorf("ECDSA signature verification failed")
+		}
+	case tpmAlgRSASSA, tpmAlgRSAPSS:
+		pub, ok := akCert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return 0, fmt.Errorf("RSA signature with not RSA key")
+		}
+		sig, err := readTPM2B(r)
+		if err != nil {
+			return 0, err
+		}
+		if header.SigAlg == tpmAlgRSASSA {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+		} else {
+			err = rsa.VerifyPSS(pub, hash, digest, sig, nil)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("RSA signature verification failed: %s", err)
+		}
+	default:
+		return 0, fmt.Errorf("unsupported signature algorithm %x", header.SigAlg)
+	}
+	return hash, nil
+}
+
+//VerifyQuote checks signature of quote with attestation key certificate akCert,
+//nonce inside quote (if nonce is not empty) and digest of reported PCR values
+func VerifyQuote(quote *attest.ZAttestQuote, akCert *x509.Certificate, nonce []byte) (*QuoteInfo, error) {
+	hash, err := verifySignature(akCert, quote.AttestData, quote.Signature)
+	if err != nil {
+		return nil, err
+	}
+	info, err := ParseQuoteInfo(quote.AttestData)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse quote: %s", err)
+	}
+	if len(nonce) > 0 && !bytes.Equal(nonce, info.Nonce) {
+		return nil, fmt.Errorf("nonce mismatch: %x != %x", info.Nonce, nonce)
+	}
+	h := hash.New()
+	for _, selection := range info.Selection {
+		algo := pcrHashAlgo(selection.HashAlg)
+		for _, ind := range selection.PCRs {
+			found := false
+			for _, pcr := range quote.PcrValues {
+				if pcr.Index == uint32(ind) && pcr.HashAlgo == algo {
+					h.Write(pcr.Value)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("no value of PCR %d in quote", ind)
+			}
+		}
+	}
+	if digest := h.Sum(nil); !bytes.Equal(digest, info.PCRDigest) {
+		return nil, fmt.Errorf("PCR digest mismatch: values reported do not match quote")
+	}
+	return info, nil
+}
+
+//ParseQuote parses quote from ZAttestReq or ZAttestQuote in JSON format
+func ParseQuote(data []byte) (*attest.ZAttestQuote, error) {
+	var req attest.ZAttestReq
+	if err := protojson.Unmarshal(data, &req); err == nil && req.Quote != nil {
+		return req.Quote, nil
+	}
+	var quote attest.ZAttestQuote
+	if err := protojson.Unmarshal(data, &quote); err != nil {
+		return nil, fmt.Errorf("cannot parse quote: %s", err)
+	}
+	if len(quote.AttestData) == 0 {
+		return nil, fmt.Errorf("no quote found")
+	}
+	return &quote, nil
+}
+
+//LoadQuote reads quote from file with ZAttestReq or ZAttestQuote in JSON format
+func LoadQuote(fileName string) (*attest.ZAttestQuote, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := ParseQuote(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fileName, err)
+	}
+	return quote, nil
+}
+
+//GetDeviceQuote returns the last quote reported by dev to controller
+func GetDeviceQuote(ctrl controller.Cloud, dev *device.Ctx) (*attest.ZAttestQuote, error) {
+	data, err := ctrl.AttestGet(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("cannot get quote from cloud for %s: %s", dev.GetID(), err)
+	}
+	quote, err := ParseQuote([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("quote of %s from cloud: %s", dev.GetID(), err)
+	}
+	return quote, nil
+}
+
+//NewBaseline returns Baseline with values of PCRs from SHA256 bank
+func NewBaseline(pcrs []*attest.TpmPCRValue) Baseline {
+	baseline := Baseline{}
+	for _, pcr := range pcrs {
+		if pcr.HashAlgo == attest.TpmHashAlgo_TPM_HASH_ALGO_SHA256 {
+			baseline[pcr.Index] = hex.EncodeToString(pcr.Value)
+		}
+	}
+	return baseline
+}
+
+//Compare returns descriptions of PCRs from baseline with values not equal to pcrs
+func (baseline Baseline) Compare(pcrs []*attest.TpmPCRValue) []string {
+	current := NewBaseline(pcrs)
+	var indexes []int
+	for ind := range baseline {
+		indexes = append(indexes, int(ind))
+	}
+	sort.Ints(indexes)
+	var result []string
+	for _, ind := range indexes {
+		expected := baseline[uint32(ind)]
+		value, ok := current[uint32(ind)]
+		if !ok {
+			result = append(result, fmt.Sprintf("PCR %d: not reported", ind))
+		} else if value != expected {
+			result = append(result, fmt.Sprintf("PCR %d: %s != %s", ind, value, expected))
+		}
+	}
+	return result
+}
+
+//LoadBaseline reads baseline from file
+func LoadBaseline(fileName string) (Baseline, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var baseline Baseline
+	if err = json.Unmarshal(data, &baseline); err != nil {
+		return nil, err
+	}
+	return baseline, nil
+}
+
+//Save writes baseline into file
+func (baseline Baseline) Save(fileName string) error {
+	data, err := json.MarshalIndent(baseline, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, data, 0644)
+}
@@ -39,6 +39,7 @@ type Ctx struct {
 	AdamCaching       bool   //enable caching of adam`s logs/info
 	AdamCachingRedis  bool   //caching to redis instead of files
 	AdamCachingPrefix string //custom prefix for file or stream naming for cache
+	timeRange         types.TimeRange
 }
 
 //parseRedisURL try to use string from config to obtain redis url
@@ -88,6 +89,8 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 				URLInfo:    adam.getInfoURL,
 				URLMetrics: adam.getMetricsURL,
 				URLRequest: adam.getRequestURL,
+				URLFlowLog: adam.getFlowLogURL,
+				URLApps:    adam.getAppsLogsURL,
 			}
 			loader = loaders.NewRemoteLoader(adam.getHTTPClient, urlGetters)
 		}
@@ -98,6 +101,8 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 			InfoGetter:    adam.getInfoDir,
 			MetricsGetter: adam.getMetricsDir,
 			RequestGetter: adam.getRequestDir,
+			FlowLogGetter: adam.getFlowLogDir,
+			AppsGetter:    adam.getAppsLogsDir,
 		}
 		loader = loaders.NewFileLoader(dirGetters)
 	}
@@ -113,6 +118,8 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 				StreamInfo:    adam.getInfoRedisStreamCache,
 				StreamMetrics: adam.getMetricsRedisStreamCache,
 				StreamRequest: adam.getRequestRedisStreamCache,
+				StreamFlowLog: adam.getFlowLogRedisStreamCache,
+				StreamApps:    adam.getAppsLogsRedisStreamCache,
 			}
 			cache = cachers.NewRedisCache(addr, password, databaseID, streamGetters)
 		} else {
@@ -121,14 +128,22 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 				InfoGetter:    adam.getInfoDirCache,
 				MetricsGetter: adam.getMetricsDirCache,
 				RequestGetter: adam.getRequestDirCache,
+				FlowLogGetter: adam.getFlowLogDirCache,
+				AppsGetter:    adam.getAppsLogsDirCache,
 			}
 			cache = cachers.NewFileCache(dirGetters)
 		}
 		loader.SetRemoteCache(cache)
 	}
+	loader.SetTimeRange(adam.timeRange)
 	return
 }
 
+//SetTimeRange set range of timestamps for logs, info, metrics and flow logs to process
+func (adam *Ctx) SetTimeRange(timeRange types.TimeRange) {
+	adam.timeRange = timeRange
+}
+
 //InitWithVars use variables from viper for init controller
 func (adam *Ctx) InitWithVars(vars *utils.ConfigVars) error {
 	adam.dir = vars.AdamDir
@@ -196,6 +211,11 @@ func (adam *Ctx) CertsGet(devUUID uuid.UUID) (out string, err error) {
 	return adam.getObj(path.Join("/admin/device", devUUID.String(), "certs"))
 }
 
+//AttestGet get last attestation request with quote for devID
+func (adam *Ctx) AttestGet(devUUID uuid.UUID) (out string, err error) {
+	return adam.getObjOnce(path.Join("/admin/device", devUUID.String(), "attest"))
+}
+
 //RequestLastCallback check request by pattern from existence files with callback
 func (adam *Ctx) RequestLastCallback(devUUID uuid.UUID, q map[string]string, handler erequest.HandlerFunc) (err error) {
 	var loader = adam.getLoader()
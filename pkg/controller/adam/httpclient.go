@@ -85,6 +85,27 @@ func (adam *Ctx) getObj(path string) (out string, err error) {
 	return string(buf), nil
 }
 
+//getObjOnce gets object without repeat of request, it returns error if status code is not OK
+func (adam *Ctx) getObjOnce(path string) (out string, err error) {
+	u, err := utils.ResolveURL(adam.url, path)
+	if err != nil {
+		return "", fmt.Errorf("error constructing URL: %v", err)
+	}
+	response, err := adam.getHTTPClient().Get(u)
+	if err != nil {
+		return "", fmt.Errorf("unable to send request: %v", err)
+	}
+	defer response.Body.Close()
+	buf, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read data from URL %s: %v", u, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status code: %d", response.StatusCode)
+	}
+	return string(buf), nil
+}
+
 func (adam *Ctx) getList(path string) (out []string, err error) {
 	u, err := utils.ResolveURL(adam.url, path)
 	if err != nil {
@@ -0,0 +1,348 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve/api/go/config"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+//AppBundle contains AppInstanceConfig with all objects referenced by it
+//it is used to replicate app from one device to another
+type AppBundle struct {
+	App              *config.AppInstanceConfig
+	Volumes          []*config.Volume
+	ContentTrees     []*config.ContentTree
+	Images           []*config.Image
+	DataStores       []*config.DatastoreConfig
+	NetworkInstances []*config.NetworkInstanceConfig
+}
+
+//appBundleJSON is representation of AppBundle with objects in protojson format
+type appBundleJSON struct {
+	App              json.RawMessage   `json:"app"`
+	Volumes          []json.RawMessage `json:"volumes,omitempty"`
+	ContentTrees     []json.RawMessage `json:"contentTrees,omitempty"`
+	Images           []json.RawMessage `json:"images,omitempty"`
+	DataStores       []json.RawMessage `json:"datastores,omitempty"`
+	NetworkInstances []json.RawMessage `json:"networkInstances,omitempty"`
+}
+
+//GetAppBundle collects app with appID and all objects referenced by it from cloud
+func GetAppBundle(cloud Cloud, appID string) (*AppBundle, error) {
+	app, err := cloud.GetApplicationInstanceConfig(appID)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &AppBundle{App: app}
+	dataStores := make(map[string]bool)
+	addDataStore := func(id string) error {
+		if id == "" || dataStores[id] {
+			return nil
+		}
+		ds, err := cloud.GetDataStore(id)
+		if err != nil {
+			return err
+		}
+		dataStores[id] = true
+		bundle.DataStores = append(bundle.DataStores, ds)
+		return nil
+	}
+	for _, drive := range app.Drives {
+		if drive.Image == nil {
+			continue
+		}
+		bundle.Images = append(bundle.Images, drive.Image)
+		if err = addDataStore(drive.Image.DsId); err != nil {
+			return nil, err
+		}
+	}
+	contentTrees := make(map[string]bool)
+	for _, volRef := range app.VolumeRefList {
+		vol, err := cloud.GetVolume(volRef.Uuid)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Volumes = append(bundle.Volumes, vol)
+		if vol.Origin == nil || vol.Origin.DownloadContentTreeID == "" || contentTrees[vol.Origin.DownloadContentTreeID] {
+			continue
+		}
+		ct, err := cloud.GetContentTree(vol.Origin.DownloadContentTreeID)
+		if err != nil {
+			return nil, err
+		}
+		contentTrees[ct.Uuid] = true
+		bundle.ContentTrees = append(bundle.ContentTrees, ct)
+		if err = addDataStore(ct.DsId); err != nil {
+			return nil, err
+		}
+	}
+	networkInstances := make(map[string]bool)
+	for _, intf := range app.Interfaces {
+		if intf.NetworkId == "" || networkInstances[intf.NetworkId] {
+			continue
+		}
+		ni, err := cloud.GetNetworkInstanceConfig(intf.NetworkId)
+		if err != nil {
+			return nil, err
+		}
+		networkInstances[intf.NetworkId] = true
+		bundle.NetworkInstances = append(bundle.NetworkInstances, ni)
+	}
+	return bundle, nil
+}
+
+//MarshalJSON returns bundle with objects in protojson format
+func (bundle *AppBundle) MarshalJSON() ([]byte, error) {
+	var result appBundleJSON
+	var err error
+	if result.App, err = protojson.Marshal(bundle.App); err != nil {
+		return nil, err
+	}
+	marshal := func(msg proto.Message, to *[]json.RawMessage) error {
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		*to = append(*to, data)
+		return nil
+	}
+	for _, el := range bundle.Volumes {
+		if err = marshal(el, &result.Volumes); err != nil {
+			return nil, err
+		}
+	}
+	for _, el := range bundle.ContentTrees {
+		if err = marshal(el, &result.ContentTrees); err != nil {
+			return nil, err
+		}
+	}
+	for _, el := range bundle.Images {
+		if err = marshal(el, &result.Images); err != nil {
+			return nil, err
+		}
+	}
+	for _, el := range bundle.DataStores {
+		if err = marshal(el, &result.DataStores); err != nil {
+			return nil, err
+		}
+	}
+	for _, el := range bundle.NetworkInstances {
+		if err = marshal(el, &result.NetworkInstances); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(result)
+}
+
+//UnmarshalJSON fills bundle from data produced by MarshalJSON
+func (bundle *AppBundle) UnmarshalJSON(data []byte) error {
+	var result appBundleJSON
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	if len(result.App) == 0 {
+		return fmt.Errorf("no app in bundle")
+	}
+	*bundle = AppBundle{App: &config.AppInstanceConfig{}}
+	if err := protojson.Unmarshal(result.App, bundle.App); err != nil {
+		return fmt.Errorf("app: %s", err)
+	}
+	for _, el := range result.Volumes {
+		vol := &config.Volume{}
+		if err := protojson.Unmarshal(el, vol); err != nil {
+			return fmt.Errorf("volume: %s", err)
+		}
+		bundle.Volumes = append(bundle.Volumes, vol)
+	}
+	for _, el := range result.ContentTrees {
+		ct := &config.ContentTree{}
+		if err := protojson.Unmarshal(el, ct); err != nil {
+			return fmt.Errorf("content tree: %s", err)
+		}
+		bundle.ContentTrees = append(bundle.ContentTrees, ct)
+	}
+	for _, el := range result.Images {
+		img := &config.Image{}
+		if err := protojson.Unmarshal(el, img); err != nil {
+			return fmt.Errorf("image: %s", err)
+		}
+		bundle.Images = append(bundle.Images, img)
+	}
+	for _, el := range result.DataStores {
+		ds := &config.DatastoreConfig{}
+		if err := protojson.Unmarshal(el, ds); err != nil {
+			return fmt.Errorf("datastore: %s", err)
+		}
+		bundle.DataStores = append(bundle.DataStores, ds)
+	}
+	for _, el := range result.NetworkInstances {
+		ni := &config.NetworkInstanceConfig{}
+		if err := protojson.Unmarshal(el, ni); err != nil {
+			return fmt.Errorf("network instance: %s", err)
+		}
+		bundle.NetworkInstances = append(bundle.NetworkInstances, ni)
+	}
+	return nil
+}
+
+//newUUID returns string with random UUID
+func newUUID() (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+//checkCipherBlock returns false if cipherBlock is encrypted with cipher context not known by dev
+func checkCipherBlock(dev *device.Ctx, cipherBlock *config.CipherBlock) bool {
+	if cipherBlock == nil {
+		return true
+	}
+	for _, el := range dev.GetCipherContexts() {
+		if el.ContextId == cipherBlock.CipherContextId {
+			return true
+		}
+	}
+	return false
+}
+
+//ImportAppBundle adds objects from bundle into cloud with fresh UUIDs and assigns them to dev
+//app will be renamed to appName if it is not empty
+//network instances with the same name and datastores with the same location, existing in dev and cloud, are reused
+func ImportAppBundle(cloud Cloud, dev *device.Ctx, bundle *AppBundle, appName string) (*config.AppInstanceConfig, error) {
+	app := proto.Clone(bundle.App).(*config.AppInstanceConfig)
+	if appName != "" {
+		app.Displayname = appName
+	}
+	for _, appID := range dev.GetApplicationInstances() {
+		el, err := cloud.GetApplicationInstanceConfig(appID)
+		if err != nil {
+			return nil, err
+		}
+		if el.Displayname == app.Displayname {
+			return nil, fmt.Errorf("app with name %s already exists", app.Displayname)
+		}
+	}
+	var err error
+	dataStores := make(map[string]string)
+	for _, el := range bundle.DataStores {
+		for _, ds := range cloud.ListDataStore() {
+			if ds.DType == el.DType && ds.Fqdn == el.Fqdn && ds.Dpath == el.Dpath && checkCipherBlock(dev, ds.CipherData) {
+				dataStores[el.Id] = ds.Id
+				break
+			}
+		}
+		if _, ok := dataStores[el.Id]; ok {
+			continue
+		}
+		ds := proto.Clone(el).(*config.DatastoreConfig)
+		if ds.Id, err = newUUID(); err != nil {
+			return nil, err
+		}
+		if !checkCipherBlock(dev, ds.CipherData) {
+			log.Warnf("credentials of datastore %s are encrypted for another device, they will be dropped", ds.Fqdn)
+			ds.CipherData = nil
+		}
+		if err = cloud.AddDataStore(ds); err != nil {
+			return nil, err
+		}
+		dataStores[el.Id] = ds.Id
+	}
+	networkInstances := make(map[string]string)
+	for _, el := range bundle.NetworkInstances {
+		for _, niID := range dev.GetNetworkInstances() {
+			ni, err := cloud.GetNetworkInstanceConfig(niID)
+			if err != nil {
+				return nil, err
+			}
+			if ni.Displayname == el.Displayname {
+				networkInstances[el.Uuidandversion.Uuid] = ni.Uuidandversion.Uuid
+				break
+			}
+		}
+		if _, ok := networkInstances[el.Uuidandversion.Uuid]; ok {
+			continue
+		}
+		ni := proto.Clone(el).(*config.NetworkInstanceConfig)
+		ni.Uuidandversion = &config.UUIDandVersion{Version: "1"}
+		if ni.Uuidandversion.Uuid, err = newUUID(); err != nil {
+			return nil, err
+		}
+		if err = cloud.AddNetworkInstanceConfig(ni); err != nil {
+			return nil, err
+		}
+		dev.SetNetworkInstanceConfig(append(dev.GetNetworkInstances(), ni.Uuidandversion.Uuid))
+		networkInstances[el.Uuidandversion.Uuid] = ni.Uuidandversion.Uuid
+	}
+	contentTrees := make(map[string]string)
+	for _, el := range bundle.ContentTrees {
+		ct := proto.Clone(el).(*config.ContentTree)
+		if ct.Uuid, err = newUUID(); err != nil {
+			return nil, err
+		}
+		ct.DsId = dataStores[ct.DsId]
+		if err = cloud.AddContentTree(ct); err != nil {
+			return nil, err
+		}
+		dev.SetContentTreeConfig(append(dev.GetContentTrees(), ct.Uuid))
+		contentTrees[el.Uuid] = ct.Uuid
+	}
+	volumes := make(map[string]string)
+	for _, el := range bundle.Volumes {
+		vol := proto.Clone(el).(*config.Volume)
+		if vol.Uuid, err = newUUID(); err != nil {
+			return nil, err
+		}
+		vol.GenerationCount = 0
+		if vol.Origin != nil && vol.Origin.DownloadContentTreeID != "" {
+			vol.Origin.DownloadContentTreeID = contentTrees[vol.Origin.DownloadContentTreeID]
+		}
+		if err = cloud.AddVolume(vol); err != nil {
+			return nil, err
+		}
+		dev.SetVolumeConfigs(append(dev.GetVolumes(), vol.Uuid))
+		volumes[el.Uuid] = vol.Uuid
+	}
+	for _, drive := range app.Drives {
+		if drive.Image == nil {
+			continue
+		}
+		if drive.Image.Uuidandversion == nil {
+			drive.Image.Uuidandversion = &config.UUIDandVersion{Version: "1"}
+		}
+		if drive.Image.Uuidandversion.Uuid, err = newUUID(); err != nil {
+			return nil, err
+		}
+		drive.Image.DsId = dataStores[drive.Image.DsId]
+	}
+	for _, volRef := range app.VolumeRefList {
+		volRef.Uuid = volumes[volRef.Uuid]
+	}
+	for _, intf := range app.Interfaces {
+		if intf.NetworkId != "" {
+			intf.NetworkId = networkInstances[intf.NetworkId]
+		}
+	}
+	if !checkCipherBlock(dev, app.CipherData) {
+		log.Warnf("user data of app %s is encrypted for another device, it will be dropped", app.Displayname)
+		app.CipherData = nil
+	}
+	app.Uuidandversion = &config.UUIDandVersion{Version: "1"}
+	if app.Uuidandversion.Uuid, err = newUUID(); err != nil {
+		return nil, err
+	}
+	app.Restart = nil
+	app.Purge = nil
+	if err = cloud.AddApplicationInstanceConfig(app); err != nil {
+		return nil, err
+	}
+	dev.SetApplicationInstanceConfig(append(dev.GetApplicationInstances(), app.Uuidandversion.Uuid))
+	return app, nil
+}
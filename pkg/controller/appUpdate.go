@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/config"
+)
+
+//AppUpdate keeps drives and volumes of app before and after update to finish or roll back it
+type AppUpdate struct {
+	cloud         Cloud
+	dev           *device.Ctx
+	app           *config.AppInstanceConfig
+	newAppID      string
+	oldDrives     []*config.Drive
+	oldVolumeRefs []*config.VolumeRef
+	newVolumeRefs []*config.VolumeRef
+}
+
+//StartAppUpdate moves drives and volumes of newApp into app and bumps version and purge counter of app
+//newApp is config created for the new image, its volumes and content trees must be already added to dev
+func StartAppUpdate(cloud Cloud, dev *device.Ctx, app, newApp *config.AppInstanceConfig) *AppUpdate {
+	update := &AppUpdate{
+		cloud:         cloud,
+		dev:           dev,
+		app:           app,
+		newAppID:      newApp.Uuidandversion.Uuid,
+		oldDrives:     app.Drives,
+		oldVolumeRefs: app.VolumeRefList,
+		newVolumeRefs: newApp.VolumeRefList,
+	}
+	app.Drives = newApp.Drives
+	app.VolumeRefList = newApp.VolumeRefList
+	bumpAppVersion(app)
+	return update
+}
+
+//Finish removes config created for the new image and prunes volumes and content trees of the old image
+func (update *AppUpdate) Finish() error {
+	return update.prune(update.oldVolumeRefs)
+}
+
+//Rollback returns drives and volumes of the old image into app, bumps version and purge counter of app
+//and removes config, volumes and content trees created for the new image
+func (update *AppUpdate) Rollback() error {
+	update.app.Drives = update.oldDrives
+	update.app.VolumeRefList = update.oldVolumeRefs
+	bumpAppVersion(update.app)
+	return update.prune(update.newVolumeRefs)
+}
+
+//prune removes config created for the new image from cloud
+//and removes volumes with refs and their content trees from device if they are no longer referenced
+func (update *AppUpdate) prune(refs []*config.VolumeRef) error {
+	if _, err := update.cloud.GetApplicationInstanceConfig(update.newAppID); err == nil {
+		if err = update.cloud.RemoveApplicationInstanceConfig(update.newAppID); err != nil {
+			return err
+		}
+	}
+	usedVolumes := make(map[string]bool)
+	for _, appID := range update.dev.GetApplicationInstances() {
+		app, err := update.cloud.GetApplicationInstanceConfig(appID)
+		if err != nil {
+			return err
+		}
+		if app.Uuidandversion.Uuid == update.app.Uuidandversion.Uuid {
+			app = update.app
+		}
+		for _, volRef := range app.VolumeRefList {
+			usedVolumes[volRef.Uuid] = true
+		}
+	}
+	removed := make(map[string]bool)
+	removedContentTrees := make(map[string]bool)
+	for _, volRef := range refs {
+		if usedVolumes[volRef.Uuid] {
+			continue
+		}
+		removed[volRef.Uuid] = true
+		vol, err := update.cloud.GetVolume(volRef.Uuid)
+		if err != nil {
+			return err
+		}
+		if vol.Origin != nil {
+			removedContentTrees[vol.Origin.DownloadContentTreeID] = true
+		}
+	}
+	volumeIDs := update.dev.GetVolumes()
+	utils.DelEleInSliceByFunction(&volumeIDs, func(i interface{}) bool {
+		return removed[i.(string)]
+	})
+	update.dev.SetVolumeConfigs(volumeIDs)
+	usedContentTrees := make(map[string]bool)
+	for _, volumeID := range volumeIDs {
+		vol, err := update.cloud.GetVolume(volumeID)
+		if err != nil {
+			return err
+		}
+		if vol.Origin != nil {
+			usedContentTrees[vol.Origin.DownloadContentTreeID] = true
+		}
+	}
+	for _, baseOSID := range update.dev.GetBaseOSConfigs() {
+		baseOS, err := update.cloud.GetBaseOSConfig(baseOSID)
+		if err != nil {
+			return err
+		}
+		if baseOS.VolumeID == "" {
+			continue
+		}
+		if vol, err := update.cloud.GetVolume(baseOS.VolumeID); err == nil && vol.Origin != nil {
+			usedContentTrees[vol.Origin.DownloadContentTreeID] = true
+		}
+	}
+	contentTreeIDs := update.dev.GetContentTrees()
+	utils.DelEleInSliceByFunction(&contentTreeIDs, func(i interface{}) bool {
+		return removedContentTrees[i.(string)] && !usedContentTrees[i.(string)]
+	})
+	update.dev.SetContentTreeConfig(contentTreeIDs)
+	return nil
+}
+
+//bumpAppVersion increments version of app and purge counter to apply changes of volumes
+func bumpAppVersion(app *config.AppInstanceConfig) {
+	version, err := strconv.Atoi(app.Uuidandversion.Version)
+	if err != nil {
+		version = 0
+	}
+	app.Uuidandversion.Version = strconv.Itoa(version + 1)
+	if app.Purge == nil {
+		app.Purge = &config.InstanceOpsCmd{Counter: 0}
+	}
+	app.Purge.Counter++
+}
@@ -0,0 +1,197 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/cachers"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	devicesDir = "devices"
+	archiveDir = "archive"
+	appsDir    = "apps"
+	fileSuffix = ".jsonl.gz"
+)
+
+//Record is an object of archive with its timestamp
+type Record struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Object    json.RawMessage `json:"object"`
+}
+
+//DeviceDir returns directory with archive of device inside root
+//root uses the same layout as proto controller, so it may be used to obtain config of device
+func DeviceDir(root string, devUUID uuid.UUID) string {
+	return filepath.Join(root, devicesDir, devUUID.String(), archiveDir)
+}
+
+//Exists returns true if there is an archive of any device inside root
+func Exists(root string) bool {
+	matches, err := filepath.Glob(filepath.Join(root, devicesDir, "*", archiveDir))
+	return err == nil && len(matches) > 0
+}
+
+//FilePath returns path of archive file with objects of typeToProcess
+func FilePath(dir string, typeToProcess types.LoaderObjectType, appUUID uuid.UUID) (string, error) {
+	switch typeToProcess {
+	case types.LogsType:
+		return filepath.Join(dir, "logs"+fileSuffix), nil
+	case types.InfoType:
+		return filepath.Join(dir, "info"+fileSuffix), nil
+	case types.MetricsType:
+		return filepath.Join(dir, "metrics"+fileSuffix), nil
+	case types.FlowLogType:
+		return filepath.Join(dir, "flowlog"+fileSuffix), nil
+	case types.RequestType:
+		return filepath.Join(dir, "requests"+fileSuffix), nil
+	case types.AppsType:
+		return filepath.Join(dir, appsDir, appUUID.String()+fileSuffix), nil
+	default:
+		return "", fmt.Errorf("not implemented type %d", typeToProcess)
+	}
+}
+
+//Read returns records of archive file with objects of typeToProcess sorted by timestamp
+//it returns empty list if there is no such file
+func Read(dir string, typeToProcess types.LoaderObjectType, appUUID uuid.UUID) ([]*Record, error) {
+	path, err := FilePath(dir, typeToProcess, appUUID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read %s: %s", path, err)
+	}
+	defer gz.Close()
+	var records []*Record
+	dec := json.NewDecoder(gz)
+	for {
+		var record Record
+		if err := dec.Decode(&record); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				//archive may be truncated if recording was interrupted
+				break
+			}
+			return records, fmt.Errorf("cannot decode record from %s: %s", path, err)
+		}
+		records = append(records, &record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+type fileWriter struct {
+	file *os.File
+	gz   *gzip.Writer
+	buf  *bufio.Writer
+}
+
+//Writer appends objects into archive
+//it implements cachers.CacheProcessor, so it may be used as cache of loaders
+type Writer struct {
+	root  string
+	mu    sync.Mutex
+	files map[string]*fileWriter
+}
+
+//NewWriter creates Writer to archive inside root
+func NewWriter(root string) *Writer {
+	return &Writer{root: root, files: make(map[string]*fileWriter)}
+}
+
+func (writer *Writer) getFile(path string) (*fileWriter, error) {
+	if fw, ok := writer.files[path]; ok {
+		return fw, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	//every run appends new gzip member which will be read as continuation of stream
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	fw := &fileWriter{file: f, gz: gz, buf: bufio.NewWriter(gz)}
+	writer.files[path] = fw
+	return fw, nil
+}
+
+//CheckAndSave appends object of typeToProcess from data into archive of device
+func (writer *Writer) CheckAndSave(devUUID uuid.UUID, appUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) error {
+	path, err := FilePath(DeviceDir(writer.root, devUUID), typeToProcess, appUUID)
+	if err != nil {
+		return err
+	}
+	ts, err := cachers.GetTimestamp(typeToProcess, data)
+	if err != nil {
+		return err
+	}
+	var object bytes.Buffer
+	if err = json.Compact(&object, data); err != nil {
+		return fmt.Errorf("cannot compact object: %s", err)
+	}
+	line, err := json.Marshal(&Record{Timestamp: ts.AsTime(), Object: object.Bytes()})
+	if err != nil {
+		return err
+	}
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	fw, err := writer.getFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err = fw.buf.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	//flush every record to not lose them if recording interrupted
+	if err = fw.buf.Flush(); err != nil {
+		return err
+	}
+	return fw.gz.Flush()
+}
+
+//Close finishes all archive files
+func (writer *Writer) Close() error {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	var result error
+	for path, fw := range writer.files {
+		if err := fw.buf.Flush(); err != nil && result == nil {
+			result = err
+		}
+		if err := fw.gz.Close(); err != nil && result == nil {
+			result = err
+		}
+		if err := fw.file.Close(); err != nil && result == nil {
+			result = err
+		}
+		delete(writer.files, path)
+	}
+	return result
+}
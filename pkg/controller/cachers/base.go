@@ -1,11 +1,102 @@
 package cachers
 
 import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve/api/go/flowlog"
+	"github.com/lf-edge/eve/api/go/info"
+	"github.com/lf-edge/eve/api/go/logs"
+	"github.com/lf-edge/eve/api/go/metrics"
 	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 //CacheProcessor for processing objects and save into cache
 type CacheProcessor interface {
-	CheckAndSave(uuid.UUID, types.LoaderObjectType, []byte) error
+	CheckAndSave(devUUID uuid.UUID, appUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) error
+}
+
+//GetTimestamp returns timestamp of object of typeToProcess from data to use as key in cache and for filtering
+func GetTimestamp(typeToProcess types.LoaderObjectType, data []byte) (*timestamp.Timestamp, error) {
+	var itemTimeStamp *timestamp.Timestamp
+	//logs may contain fields out of LogEntry (image, eveVersion)
+	lenient := protojson.UnmarshalOptions{DiscardUnknown: true}
+	switch typeToProcess {
+	case types.LogsType:
+		//logs are stored as separate entries, but we keep support of bundles
+		var emp logs.LogEntry
+		if err := lenient.Unmarshal(data, &emp); err == nil && emp.Timestamp != nil {
+			itemTimeStamp = emp.Timestamp
+			break
+		}
+		var bundle logs.LogBundle
+		if err := lenient.Unmarshal(data, &bundle); err != nil {
+			return nil, err
+		}
+		itemTimeStamp = bundle.Timestamp
+	case types.InfoType:
+		var emp info.ZInfoMsg
+		if err := protojson.Unmarshal(data, &emp); err != nil {
+			return nil, err
+		}
+		itemTimeStamp = emp.AtTimeStamp
+	case types.MetricsType:
+		var emp metrics.ZMetricMsg
+		if err := protojson.Unmarshal(data, &emp); err != nil {
+			return nil, err
+		}
+		itemTimeStamp = emp.AtTimeStamp
+	case types.AppsType:
+		var emp logs.LogEntry
+		if err := lenient.Unmarshal(data, &emp); err != nil {
+			return nil, err
+		}
+		itemTimeStamp = emp.Timestamp
+	case types.FlowLogType:
+		var emp flowlog.FlowMessage
+		if err := protojson.Unmarshal(data, &emp); err != nil {
+			return nil, err
+		}
+		itemTimeStamp = flowMessageTimestamp(&emp)
+	case types.RequestType:
+		var emp types.APIRequest
+		if err := json.Unmarshal(data, &emp); err != nil {
+			return nil, err
+		}
+		if !emp.Timestamp.IsZero() {
+			itemTimeStamp = timestamppb.New(emp.Timestamp)
+		}
+	default:
+		return nil, fmt.Errorf("not implemented type %d", typeToProcess)
+	}
+	if itemTimeStamp == nil {
+		return nil, fmt.Errorf("nil timestamp for data: %s", string(data))
+	}
+	return itemTimeStamp, nil
+}
+
+//flowMessageTimestamp returns the latest time of flows and dns requests inside FlowMessage
+//FlowMessage itself does not contain timestamp
+func flowMessageTimestamp(msg *flowlog.FlowMessage) *timestamp.Timestamp {
+	var latest *timestamp.Timestamp
+	check := func(ts *timestamp.Timestamp) {
+		if ts == nil {
+			return
+		}
+		if latest == nil || ts.AsTime().After(latest.AsTime()) {
+			latest = ts
+		}
+	}
+	for _, flow := range msg.Flows {
+		check(flow.GetStartTime())
+		check(flow.GetEndTime())
+	}
+	for _, dns := range msg.DnsReqs {
+		check(dns.GetRequestTime())
+	}
+	return latest
 }
@@ -1,19 +1,13 @@
 package cachers
 
 import (
-	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
-	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/lf-edge/eden/pkg/controller/types"
-	"github.com/lf-edge/eve/api/go/info"
-	"github.com/lf-edge/eve/api/go/logs"
-	"github.com/lf-edge/eve/api/go/metrics"
 	uuid "github.com/satori/go.uuid"
-	"google.golang.org/protobuf/encoding/protojson"
 )
 
 //FileCache object provides caching objects from controller into directory
@@ -29,38 +23,27 @@ func NewFileCache(dirGetters types.DirGetters) *FileCache {
 }
 
 //CheckAndSave process LoaderObjectType from data
-func (cacher *FileCache) CheckAndSave(devUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) error {
+func (cacher *FileCache) CheckAndSave(devUUID uuid.UUID, appUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) error {
 	var pathToCheck string
-	var itemTimeStamp *timestamp.Timestamp
-	var buf bytes.Buffer
-	buf.Write(data)
 	switch typeToProcess {
 	case types.LogsType:
 		pathToCheck = cacher.dirGetters.LogsGetter(devUUID)
-		var emp logs.LogBundle
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.Timestamp
 	case types.InfoType:
 		pathToCheck = cacher.dirGetters.InfoGetter(devUUID)
-		var emp info.ZInfoMsg
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.AtTimeStamp
 	case types.MetricsType:
 		pathToCheck = cacher.dirGetters.MetricsGetter(devUUID)
-		var emp metrics.ZMetricMsg
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.AtTimeStamp
+	case types.FlowLogType:
+		pathToCheck = cacher.dirGetters.FlowLogGetter(devUUID)
+	case types.RequestType:
+		pathToCheck = cacher.dirGetters.RequestGetter(devUUID)
+	case types.AppsType:
+		pathToCheck = cacher.dirGetters.AppsGetter(devUUID, appUUID)
 	default:
 		return fmt.Errorf("not implemented type %d", typeToProcess)
 	}
-	if itemTimeStamp == nil {
-		return fmt.Errorf("nil timestamp for data: %s", string(data))
+	itemTimeStamp, err := GetTimestamp(typeToProcess, data)
+	if err != nil {
+		return err
 	}
 	pathToCheck = filepath.Join(pathToCheck, fmt.Sprintf("%d:%09d", itemTimeStamp.GetSeconds(), itemTimeStamp.GetNanos()))
 	if err := os.MkdirAll(filepath.Dir(pathToCheck), 0755); err != nil {
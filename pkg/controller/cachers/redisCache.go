@@ -1,16 +1,10 @@
 package cachers
 
 import (
-	"bytes"
 	"fmt"
-	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/go-redis/redis/v7"
-	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/lf-edge/eden/pkg/controller/types"
-	"github.com/lf-edge/eve/api/go/info"
-	"github.com/lf-edge/eve/api/go/logs"
-	"github.com/lf-edge/eve/api/go/metrics"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 )
@@ -45,7 +39,7 @@ func (cacher *RedisCache) newRedisClient() (*redis.Client, error) {
 }
 
 //CheckAndSave process LoaderObjectType from data
-func (cacher *RedisCache) CheckAndSave(devUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) (err error) {
+func (cacher *RedisCache) CheckAndSave(devUUID uuid.UUID, appUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) (err error) {
 	if cacher.client == nil {
 		if cacher.client, err = cacher.newRedisClient(); err != nil {
 			return err
@@ -53,62 +47,41 @@ func (cacher *RedisCache) CheckAndSave(devUUID uuid.UUID, typeToProcess types.Lo
 	}
 
 	var streamToWrite string
-	var itemTimeStamp *timestamp.Timestamp
-	var buf bytes.Buffer
-	buf.Write(data)
 	switch typeToProcess {
 	case types.LogsType:
 		streamToWrite = cacher.streamGetters.StreamLogs(devUUID)
-		var emp logs.LogBundle
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.Timestamp
 	case types.InfoType:
 		streamToWrite = cacher.streamGetters.StreamInfo(devUUID)
-		var emp info.ZInfoMsg
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.AtTimeStamp
 	case types.MetricsType:
 		streamToWrite = cacher.streamGetters.StreamMetrics(devUUID)
-		var emp metrics.ZMetricMsg
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.AtTimeStamp
+	case types.FlowLogType:
+		streamToWrite = cacher.streamGetters.StreamFlowLog(devUUID)
+	case types.RequestType:
+		streamToWrite = cacher.streamGetters.StreamRequest(devUUID)
+	case types.AppsType:
+		streamToWrite = cacher.streamGetters.StreamApps(devUUID, appUUID)
 	default:
 		return fmt.Errorf("not implemented type %d", typeToProcess)
 	}
+	itemTimeStamp, err := GetTimestamp(typeToProcess, data)
+	if err != nil {
+		return err
+	}
 	rr, err := cacher.client.XRange(streamToWrite, "-", "+").Result()
 	if err != nil {
 		return err
 	}
 	for _, r := range rr {
-		switch typeToProcess {
-		case types.LogsType:
-			var buf bytes.Buffer
-			buf.Write([]byte(r.Values["object"].(string)))
-			var emp logs.LogBundle
-			if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-				return err
-			}
-			if emp.Timestamp.GetSeconds() == itemTimeStamp.GetSeconds() && emp.Timestamp.GetNanos() == itemTimeStamp.GetNanos() {
-				return
-			}
-		case types.InfoType:
-			var buf bytes.Buffer
-			buf.Write([]byte(r.Values["object"].(string)))
-			var emp info.ZInfoMsg
-			if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-				return err
-			}
-			if emp.AtTimeStamp.GetSeconds() == itemTimeStamp.GetSeconds() && emp.AtTimeStamp.GetNanos() == itemTimeStamp.GetNanos() {
-				return
-			}
-		default:
-			return fmt.Errorf("not implemented type %d", typeToProcess)
+		obj, ok := r.Values["object"].(string)
+		if !ok {
+			continue
+		}
+		ts, err := GetTimestamp(typeToProcess, []byte(obj))
+		if err != nil {
+			return err
+		}
+		if ts.GetSeconds() == itemTimeStamp.GetSeconds() && ts.GetNanos() == itemTimeStamp.GetNanos() {
+			return nil
 		}
 	}
 
@@ -56,6 +56,9 @@ type Cloud interface {
 	ListVolume() []*config.Volume
 	GetConfigBytes(dev *device.Ctx, pretty bool) ([]byte, error)
 	GetDeviceCurrent() (dev *device.Ctx, err error)
+	GetDeviceBySelector(selector string) (dev *device.Ctx, err error)
+	GetDeviceName(devUUID uuid.UUID) string
+	ListDevices() []*device.Ctx
 	ConfigSync(dev *device.Ctx) (err error)
 	ConfigParse(config *config.EdgeDevConfig) (dev *device.Ctx, err error)
 	GetNetworkConfig(id string) (networkConfig *config.NetworkConfig, err error)
@@ -18,6 +18,7 @@ import (
 //Controller is an interface of controller
 type Controller interface {
 	CertsGet(devUUID uuid.UUID) (out string, err error)
+	AttestGet(devUUID uuid.UUID) (out string, err error)
 	ConfigGet(devUUID uuid.UUID) (out string, err error)
 	ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error)
 	LogAppsChecker(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc, mode eapps.LogCheckerMode, timeout time.Duration) (err error)
@@ -31,6 +32,7 @@ type Controller interface {
 	MetricChecker(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc, mode emetric.MetricCheckerMode, timeout time.Duration) (err error)
 	MetricLastCallback(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error)
 	RequestLastCallback(devUUID uuid.UUID, q map[string]string, handler erequest.HandlerFunc) (err error)
+	SetTimeRange(timeRange types.TimeRange)
 	DeviceList(types.DeviceStateFilter) (out []string, err error)
 	DeviceGetByOnboard(eveCert string) (devUUID uuid.UUID, err error)
 	DeviceGetByOnboardUUID(onboardUUID string) (devUUID uuid.UUID, err error)
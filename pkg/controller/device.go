@@ -229,7 +229,11 @@ func (cloud *CloudCtx) GetDeviceUUID(devUUID uuid.UUID) (dev *device.Ctx, err er
 }
 
 //GetDeviceCurrent return current device object
+//it will use device selected by EveDevice if defined or EveUUID otherwise
 func (cloud *CloudCtx) GetDeviceCurrent() (dev *device.Ctx, err error) {
+	if cloud.vars.EveDevice != "" {
+		return cloud.GetDeviceBySelector(cloud.vars.EveDevice)
+	}
 	id, err := cloud.DeviceGetByOnboardUUID(cloud.vars.EveUUID)
 	if err != nil {
 		return nil, err
@@ -240,6 +244,50 @@ func (cloud *CloudCtx) GetDeviceCurrent() (dev *device.Ctx, err error) {
 	return cloud.GetDeviceUUID(id)
 }
 
+//GetDeviceBySelector return device object by selector
+//selector may be name of node from test.eve, eve.name, UUID of device or onboarding UUID
+func (cloud *CloudCtx) GetDeviceBySelector(selector string) (dev *device.Ctx, err error) {
+	if len(cloud.devices) == 0 {
+		return nil, errors.New("no device found")
+	}
+	var id uuid.UUID
+	if selector == cloud.vars.EveName {
+		id, err = cloud.DeviceGetByOnboardUUID(cloud.vars.EveUUID)
+	} else if cert, ok := cloud.vars.EveOnboardCerts[selector]; ok {
+		id, err = cloud.DeviceGetByOnboard(cert)
+	} else if id, err = uuid.FromString(selector); err == nil {
+		if dev, err = cloud.GetDeviceUUID(id); err == nil {
+			return dev, nil
+		}
+		id, err = cloud.DeviceGetByOnboardUUID(selector)
+	} else {
+		return nil, fmt.Errorf("cannot find device with name or UUID %s", selector)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot find device %s: %s", selector, err)
+	}
+	return cloud.GetDeviceUUID(id)
+}
+
+//GetDeviceName return name of device with devUUID from eve.name or test.eve
+//it returns empty string if device not found in config
+func (cloud *CloudCtx) GetDeviceName(devUUID uuid.UUID) string {
+	if id, err := cloud.DeviceGetByOnboardUUID(cloud.vars.EveUUID); err == nil && uuid.Equal(id, devUUID) {
+		return cloud.vars.EveName
+	}
+	for name, cert := range cloud.vars.EveOnboardCerts {
+		if id, err := cloud.DeviceGetByOnboard(cert); err == nil && uuid.Equal(id, devUUID) {
+			return name
+		}
+	}
+	return ""
+}
+
+//ListDevices return all devices obtained from controller
+func (cloud *CloudCtx) ListDevices() []*device.Ctx {
+	return cloud.devices
+}
+
 func (cloud *CloudCtx) processDev(id uuid.UUID, state device.EdgeNodeState) {
 	configString, err := cloud.ConfigGet(id)
 	if err != nil {
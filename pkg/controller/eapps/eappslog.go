@@ -6,17 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve/api/go/logs"
 	uuid "github.com/satori/go.uuid"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
@@ -52,32 +50,9 @@ func ParseLogEntry(data []byte) (logEntry *logs.LogEntry, err error) {
 	return &le, err
 }
 
-//LogItemFind find LogItem records by reqexps in 'query' corresponded to LogItem structure.
+//LogItemFind find LogItem records by 'query' corresponded to LogItem structure.
 func LogItemFind(le *logs.LogEntry, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, strings.Title(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
-	}
-	return matched
+	return equery.MatchMap(le, query)
 }
 
 //HandleFactory implements HandlerFunc which prints log in the provided format
@@ -113,12 +88,18 @@ func LogPrn(le *logs.LogEntry, format LogFormat) {
 type HandlerFunc func(*logs.LogEntry) bool
 
 func logProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFunction {
+	expr, err := equery.Compile(query)
+	if err != nil {
+		return func(bytes []byte) (bool, error) {
+			return false, err
+		}
+	}
 	return func(bytes []byte) (bool, error) {
 		le, err := ParseLogEntry(bytes)
 		if err != nil {
 			return true, nil
 		}
-		if LogItemFind(le, query) {
+		if expr.Match(le) {
 			if handler(le) {
 				return false, nil
 			}
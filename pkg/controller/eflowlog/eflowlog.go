@@ -7,16 +7,15 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve/api/go/flowlog"
 	uuid "github.com/satori/go.uuid"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
@@ -60,32 +59,9 @@ func FlowLogItemPrint(le *flowlog.FlowMessage, query []string) *types.PrintResul
 	return &result
 }
 
-//FlowLogItemFind find FlowMessage records by reqexps in 'query' corresponded to FlowMessage structure.
+//FlowLogItemFind find FlowMessage records by 'query' corresponded to FlowMessage structure.
 func FlowLogItemFind(le *flowlog.FlowMessage, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, strings.Title(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
-	}
-	return matched
+	return equery.MatchMap(le, query)
 }
 
 //HandleFactory implements HandlerFunc which prints log in the provided format
@@ -115,6 +91,12 @@ func flowLogProcess(query map[string]string, handler HandlerFunc) loaders.Proces
 	if ok {
 		delete(query, "eveVersion")
 	}
+	expr, err := equery.Compile(query)
+	if err != nil {
+		return func(bytes []byte) (bool, error) {
+			return false, err
+		}
+	}
 	return func(bytes []byte) (bool, error) {
 		lb, err := ParseFullLogEntry(bytes)
 		if err != nil {
@@ -123,7 +105,7 @@ func flowLogProcess(query map[string]string, handler HandlerFunc) loaders.Proces
 		if devID != "" && devID != lb.DevId {
 			return true, nil
 		}
-		if FlowLogItemFind(lb, query) {
+		if expr.Match(lb) {
 			if handler(lb) {
 				return false, nil
 			}
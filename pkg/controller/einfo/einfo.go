@@ -9,12 +9,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve/api/go/info"
 	uuid "github.com/satori/go.uuid"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
@@ -79,30 +79,7 @@ func HandleAll(im *info.ZInfoMsg, ds []*ZInfoMsgInterface) bool {
 }
 
 func processElem(value reflect.Value, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, strings.Title(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(value).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			break
-		}
-	}
-	return matched
+	return equery.MatchMap(value.Interface(), query)
 }
 
 //ZInfoPrint finds ZInfoMsg records by path in 'query'
@@ -124,7 +101,7 @@ func ZInfoPrint(im *info.ZInfoMsg, query []string) *types.PrintResult {
 }
 
 //ZInfoFind finds ZInfoMsg records with 'devid' and ZInfoDevSWF structure fields
-//by reqexps in 'query'
+//by 'query'
 func ZInfoFind(im *info.ZInfoMsg, query map[string]string) []*ZInfoMsgInterface {
 	var dsws []*ZInfoMsgInterface
 	if processElem(reflect.ValueOf(im), query) {
@@ -171,6 +148,11 @@ const (
 )
 
 func infoProcess(query map[string]string, qhandler QHandlerFunc, handler HandlerFunc) loaders.ProcessFunction {
+	if _, err := equery.Compile(query); err != nil {
+		return func(bytes []byte) (bool, error) {
+			return false, err
+		}
+	}
 	return func(bytes []byte) (bool, error) {
 		im, err := ParseZInfoMsg(bytes)
 		if err != nil {
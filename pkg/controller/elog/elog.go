@@ -7,16 +7,15 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve/api/go/logs"
 	uuid "github.com/satori/go.uuid"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
@@ -52,11 +51,45 @@ const (
 	LogAny   LogCheckerMode = -1 // use both mechanisms
 )
 
+//fullLogEntryMeta contains fields of FullLogEntry outside of LogEntry
+type fullLogEntryMeta struct {
+	Image      string `json:"image,omitempty"`
+	EveVersion string `json:"eveVersion,omitempty"`
+}
+
 //ParseFullLogEntry unmarshal FullLogEntry
 func ParseFullLogEntry(data []byte) (fullLogEntry *FullLogEntry, err error) {
 	var lb FullLogEntry
-	err = protojson.Unmarshal(data, &lb)
-	return &lb, err
+	if err = (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, &lb.LogEntry); err != nil {
+		return &lb, err
+	}
+	var meta fullLogEntryMeta
+	if err = json.Unmarshal(data, &meta); err != nil {
+		return &lb, err
+	}
+	lb.Image = meta.Image
+	lb.EveVersion = meta.EveVersion
+	return &lb, nil
+}
+
+//MarshalFullLogEntry marshal FullLogEntry to be parsed with ParseFullLogEntry
+func MarshalFullLogEntry(le *FullLogEntry) ([]byte, error) {
+	data, err := protojson.Marshal(&le.LogEntry)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	meta, err := json.Marshal(fullLogEntryMeta{Image: le.Image, EveVersion: le.EveVersion})
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(meta, &fields); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
 }
 
 //LogItemPrint find LogItem elements by paths in 'query'
@@ -77,32 +110,9 @@ func LogItemPrint(le *FullLogEntry, _ LogFormat, query []string) *types.PrintRes
 	return &result
 }
 
-//LogItemFind find LogItem records by reqexps in 'query' corresponded to LogItem structure.
+//LogItemFind find LogItem records by 'query' corresponded to LogItem structure.
 func LogItemFind(le *FullLogEntry, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, strings.Title(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
-	}
-	return matched
+	return equery.MatchMap(le, query)
 }
 
 //HandleFactory implements HandlerFunc which prints log in the provided format
@@ -146,6 +156,12 @@ func logProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFun
 	if ok {
 		delete(query, "eveVersion")
 	}
+	expr, err := equery.Compile(query)
+	if err != nil {
+		return func(bytes []byte) (bool, error) {
+			return false, err
+		}
+	}
 	return func(bytes []byte) (bool, error) {
 		lb, err := ParseFullLogEntry(bytes)
 		if err != nil {
@@ -154,7 +170,7 @@ func logProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFun
 		if eveVersion != "" && eveVersion != lb.EveVersion {
 			return true, nil
 		}
-		if LogItemFind(lb, query) {
+		if expr.Match(lb) {
 			if handler(lb) {
 				return false, nil
 			}
@@ -5,16 +5,15 @@ package emetric
 import (
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve/api/go/metrics"
 	uuid "github.com/satori/go.uuid"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
@@ -62,37 +61,12 @@ func MetricItemPrint(mm *metrics.ZMetricMsg, query []string) *types.PrintResult
 	return &result
 }
 
-//MetricItemFind find ZMetricMsg records by reqexps in 'query' corresponded to ZMetricMsg structure.
+//metricAliases used to access dm located in MetricContent
+var metricAliases = map[string]string{"dm": "MetricContent.dm"}
+
+//MetricItemFind find ZMetricMsg records by 'query' corresponded to ZMetricMsg structure.
 func MetricItemFind(mm *metrics.ZMetricMsg, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		splitRequest := strings.Split(k, ".")
-		if len(splitRequest) > 0 && strings.ToLower(splitRequest[0]) == "dm" { //dm is located in MetricContent
-			query[strings.Join(append([]string{"MetricContent"}, splitRequest...), ".")] = v
-			delete(query, k)
-		}
-		// Uppercase of filed's name first letter
-		var n []string
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, strings.Title(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(mm)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
-	}
-	return matched
+	return equery.MatchMapWithAliases(mm, query, metricAliases)
 }
 
 //HandleFirst runs once and interrupts the workflow of LogWatch
@@ -124,6 +98,12 @@ func metricProcess(query map[string]string, handler HandlerFunc) loaders.Process
 	if ok {
 		delete(query, "devId")
 	}
+	expr, err := equery.CompileWithAliases(query, metricAliases)
+	if err != nil {
+		return func(bytes []byte) (bool, error) {
+			return false, err
+		}
+	}
 	return func(bytes []byte) (bool, error) {
 		lb, err := ParseMetricsBundle(bytes)
 		if err != nil {
@@ -132,7 +112,7 @@ func metricProcess(query map[string]string, handler HandlerFunc) loaders.Process
 		if devID != "" && devID != lb.DevID {
 			return true, nil
 		}
-		if MetricItemFind(lb, query) {
+		if expr.Match(lb) {
 			if handler(lb) {
 				return false, nil
 			}
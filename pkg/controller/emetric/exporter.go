@@ -0,0 +1,195 @@
+package emetric
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/lf-edge/eve/api/go/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "eve"
+	mbyte     = 1024 * 1024
+)
+
+var (
+	deviceLabels  = []string{"device"}
+	appLabels     = []string{"device", "app"}
+	networkLabels = []string{"device", "network"}
+	volumeLabels  = []string{"device", "volume"}
+
+	lastTimestampDesc = newDesc("metric_timestamp_seconds", "Time of last metric message from device", deviceLabels)
+
+	deviceCPUDesc         = newDesc("device_cpu_seconds_total", "Total CPU time of device", deviceLabels)
+	deviceMemoryUsedDesc  = newDesc("device_memory_used_bytes", "Used memory of device", deviceLabels)
+	deviceMemoryAvailDesc = newDesc("device_memory_available_bytes", "Available memory of device", deviceLabels)
+	deviceDiskUsedDesc    = newDesc("device_disk_used_bytes", "Used space of device disk", []string{"device", "disk", "mount"})
+	deviceDiskTotalDesc   = newDesc("device_disk_total_bytes", "Total space of device disk", []string{"device", "disk", "mount"})
+	deviceDiskReadDesc    = newDesc("device_disk_read_bytes_total", "Bytes read from device disk", []string{"device", "disk", "mount"})
+	deviceDiskWriteDesc   = newDesc("device_disk_write_bytes_total", "Bytes written to device disk", []string{"device", "disk", "mount"})
+	deviceNetRxDesc       = newDesc("device_network_receive_bytes_total", "Bytes received by device interface", []string{"device", "interface"})
+	deviceNetTxDesc       = newDesc("device_network_transmit_bytes_total", "Bytes transmitted by device interface", []string{"device", "interface"})
+
+	appCPUDesc         = newDesc("app_cpu_seconds_total", "Total CPU time of app", appLabels)
+	appMemoryUsedDesc  = newDesc("app_memory_used_bytes", "Used memory of app", appLabels)
+	appMemoryAvailDesc = newDesc("app_memory_available_bytes", "Available memory of app", appLabels)
+	appDiskUsedDesc    = newDesc("app_disk_used_bytes", "Used space of app disk", []string{"device", "app", "disk"})
+	appDiskProvDesc    = newDesc("app_disk_provisioned_bytes", "Provisioned space of app disk", []string{"device", "app", "disk"})
+	appNetRxDesc       = newDesc("app_network_receive_bytes_total", "Bytes received by app interface", []string{"device", "app", "interface"})
+	appNetTxDesc       = newDesc("app_network_transmit_bytes_total", "Bytes transmitted by app interface", []string{"device", "app", "interface"})
+
+	networkRxBytesDesc   = newDesc("network_receive_bytes_total", "Bytes received by network instance", networkLabels)
+	networkTxBytesDesc   = newDesc("network_transmit_bytes_total", "Bytes transmitted by network instance", networkLabels)
+	networkRxPacketsDesc = newDesc("network_receive_packets_total", "Packets received by network instance", networkLabels)
+	networkTxPacketsDesc = newDesc("network_transmit_packets_total", "Packets transmitted by network instance", networkLabels)
+	networkRxDropsDesc   = newDesc("network_receive_drops_total", "Dropped received packets of network instance", networkLabels)
+	networkTxDropsDesc   = newDesc("network_transmit_drops_total", "Dropped transmitted packets of network instance", networkLabels)
+
+	volumeUsedDesc  = newDesc("volume_used_bytes", "Used space of volume", volumeLabels)
+	volumeTotalDesc = newDesc("volume_total_bytes", "Total space of volume", volumeLabels)
+	volumeReadDesc  = newDesc("volume_read_bytes_total", "Bytes read from volume", volumeLabels)
+	volumeWriteDesc = newDesc("volume_write_bytes_total", "Bytes written to volume", volumeLabels)
+)
+
+func newDesc(name, help string, labels []string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+}
+
+//Exporter exposes last metrics of EVE devices in Prometheus format
+//values are taken from the last ZMetricMsg of every device
+type Exporter struct {
+	mu       sync.RWMutex
+	last     map[string]*metrics.ZMetricMsg
+	registry *prometheus.Registry
+}
+
+//NewExporter creates Exporter registered in its own registry
+func NewExporter() *Exporter {
+	exporter := &Exporter{
+		last:     make(map[string]*metrics.ZMetricMsg),
+		registry: prometheus.NewRegistry(),
+	}
+	exporter.registry.MustRegister(exporter)
+	return exporter
+}
+
+//Handler returns http handler to scrape metrics
+func (exporter *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(exporter.registry, promhttp.HandlerOpts{})
+}
+
+//Process saves metric message of device to expose it
+func (exporter *Exporter) Process(device string, msg *metrics.ZMetricMsg) {
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if last, ok := exporter.last[device]; ok && last.GetAtTimeStamp().AsTime().After(msg.GetAtTimeStamp().AsTime()) {
+		return
+	}
+	exporter.last[device] = msg
+}
+
+//HandleFactory returns HandlerFunc to feed metrics of device into exporter
+func (exporter *Exporter) HandleFactory(device string) HandlerFunc {
+	return func(msg *metrics.ZMetricMsg) bool {
+		exporter.Process(device, msg)
+		return false
+	}
+}
+
+//Describe implements prometheus.Collector
+func (exporter *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		lastTimestampDesc,
+		deviceCPUDesc, deviceMemoryUsedDesc, deviceMemoryAvailDesc,
+		deviceDiskUsedDesc, deviceDiskTotalDesc, deviceDiskReadDesc, deviceDiskWriteDesc,
+		deviceNetRxDesc, deviceNetTxDesc,
+		appCPUDesc, appMemoryUsedDesc, appMemoryAvailDesc, appDiskUsedDesc, appDiskProvDesc,
+		appNetRxDesc, appNetTxDesc,
+		networkRxBytesDesc, networkTxBytesDesc, networkRxPacketsDesc, networkTxPacketsDesc,
+		networkRxDropsDesc, networkTxDropsDesc,
+		volumeUsedDesc, volumeTotalDesc, volumeReadDesc, volumeWriteDesc,
+	} {
+		ch <- desc
+	}
+}
+
+//Collect implements prometheus.Collector
+func (exporter *Exporter) Collect(ch chan<- prometheus.Metric) {
+	exporter.mu.RLock()
+	defer exporter.mu.RUnlock()
+	for device, msg := range exporter.last {
+		collectMsg(ch, device, msg)
+	}
+}
+
+func gauge(ch chan<- prometheus.Metric, desc *prometheus.Desc, value float64, labels ...string) {
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+}
+
+func counter(ch chan<- prometheus.Metric, desc *prometheus.Desc, value float64, labels ...string) {
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, labels...)
+}
+
+func collectMsg(ch chan<- prometheus.Metric, device string, msg *metrics.ZMetricMsg) {
+	if msg.GetAtTimeStamp() != nil {
+		gauge(ch, lastTimestampDesc, float64(msg.GetAtTimeStamp().AsTime().Unix()), device)
+	}
+	if dm := msg.GetDm(); dm != nil {
+		if dm.GetCpuMetric() != nil {
+			counter(ch, deviceCPUDesc, float64(dm.GetCpuMetric().GetTotal()), device)
+		}
+		if dm.GetMemory() != nil {
+			gauge(ch, deviceMemoryUsedDesc, float64(dm.GetMemory().GetUsedMem())*mbyte, device)
+			gauge(ch, deviceMemoryAvailDesc, float64(dm.GetMemory().GetAvailMem())*mbyte, device)
+		}
+		for _, disk := range dm.GetDisk() {
+			gauge(ch, deviceDiskUsedDesc, float64(disk.GetUsed())*mbyte, device, disk.GetDisk(), disk.GetMountPath())
+			gauge(ch, deviceDiskTotalDesc, float64(disk.GetTotal())*mbyte, device, disk.GetDisk(), disk.GetMountPath())
+			counter(ch, deviceDiskReadDesc, float64(disk.GetReadBytes())*mbyte, device, disk.GetDisk(), disk.GetMountPath())
+			counter(ch, deviceDiskWriteDesc, float64(disk.GetWriteBytes())*mbyte, device, disk.GetDisk(), disk.GetMountPath())
+		}
+		for _, nw := range dm.GetNetwork() {
+			counter(ch, deviceNetRxDesc, float64(nw.GetRxBytes()), device, nw.GetIName())
+			counter(ch, deviceNetTxDesc, float64(nw.GetTxBytes()), device, nw.GetIName())
+		}
+	}
+	for _, am := range msg.GetAm() {
+		if am.GetCpu() != nil {
+			counter(ch, appCPUDesc, float64(am.GetCpu().GetTotal()), device, am.GetAppName())
+		}
+		if am.GetMemory() != nil {
+			gauge(ch, appMemoryUsedDesc, float64(am.GetMemory().GetUsedMem())*mbyte, device, am.GetAppName())
+			gauge(ch, appMemoryAvailDesc, float64(am.GetMemory().GetAvailMem())*mbyte, device, am.GetAppName())
+		}
+		for _, disk := range am.GetDisk() {
+			gauge(ch, appDiskUsedDesc, float64(disk.GetUsed())*mbyte, device, am.GetAppName(), disk.GetDisk())
+			gauge(ch, appDiskProvDesc, float64(disk.GetProvisioned())*mbyte, device, am.GetAppName(), disk.GetDisk())
+		}
+		for _, nw := range am.GetNetwork() {
+			counter(ch, appNetRxDesc, float64(nw.GetRxBytes()), device, am.GetAppName(), nw.GetIName())
+			counter(ch, appNetTxDesc, float64(nw.GetTxBytes()), device, am.GetAppName(), nw.GetIName())
+		}
+	}
+	for _, nm := range msg.GetNm() {
+		stats := nm.GetNetworkStats()
+		if stats == nil {
+			continue
+		}
+		name := nm.GetDisplayname()
+		counter(ch, networkRxBytesDesc, float64(stats.GetRx().GetTotalBytes()), device, name)
+		counter(ch, networkTxBytesDesc, float64(stats.GetTx().GetTotalBytes()), device, name)
+		counter(ch, networkRxPacketsDesc, float64(stats.GetRx().GetTotalPackets()), device, name)
+		counter(ch, networkTxPacketsDesc, float64(stats.GetTx().GetTotalPackets()), device, name)
+		counter(ch, networkRxDropsDesc, float64(stats.GetRx().GetDrops()), device, name)
+		counter(ch, networkTxDropsDesc, float64(stats.GetTx().GetDrops()), device, name)
+	}
+	for _, vm := range msg.GetVm() {
+		name := vm.GetDisplayName()
+		gauge(ch, volumeUsedDesc, float64(vm.GetUsedBytes()), device, name)
+		gauge(ch, volumeTotalDesc, float64(vm.GetTotalBytes()), device, name)
+		counter(ch, volumeReadDesc, float64(vm.GetReadBytes()), device, name)
+		counter(ch, volumeWriteDesc, float64(vm.GetWriteBytes()), device, name)
+	}
+}
@@ -0,0 +1,236 @@
+package equery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//operators of conditions ordered to check longer ones first
+var conditionOperators = []Operator{OpNotMatch, OpEqual, OpNotEqual, OpGreaterOrEqual, OpLessOrEqual, OpMatch, "=", OpGreater, OpLess}
+
+var fieldRe = regexp.MustCompile(`^[A-Za-z_][\w.\[\]]*`)
+
+type parser struct {
+	input string
+	pos   int
+}
+
+//Parse parses expression with conditions joined with AND, OR and NOT
+//conditions are <field><op><value> with op one of : (regexp), !: (not regexp),
+//== or =, !=, >, >=, <, <=, or exists(<field>) to check presence of field
+//terms without operator between them are joined with AND
+func Parse(expression string) (*Expr, error) {
+	p := &parser{input: expression}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos != len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	if expr == nil {
+		return &Expr{Op: OpAnd}, nil
+	}
+	return expr, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("cannot parse query %q at %d: %s", p.input, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+//keyword checks if one of keywords is next in input and skips it
+func (p *parser) keyword(keywords ...string) bool {
+	p.skipSpaces()
+	for _, k := range keywords {
+		if !strings.HasPrefix(p.input[p.pos:], k) {
+			continue
+		}
+		end := p.pos + len(k)
+		//words must be separated from the next term
+		if unicode.IsLetter(rune(k[0])) && end < len(p.input) && !unicode.IsSpace(rune(p.input[end])) && p.input[end] != '(' {
+			continue
+		}
+		p.pos = end
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (*Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR", "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if right == nil {
+			return nil, p.errorf("expression expected after OR")
+		}
+		left = join(OpOr, left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (*Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		explicit := p.keyword("AND", "&&")
+		p.skipSpaces()
+		if !explicit && (p.pos == len(p.input) || p.input[p.pos] == ')' || p.peekOr()) {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if right == nil {
+			return nil, p.errorf("expression expected after AND")
+		}
+		left = join(OpAnd, left, right)
+	}
+}
+
+func (p *parser) peekOr() bool {
+	pos := p.pos
+	defer func() { p.pos = pos }()
+	return p.keyword("OR", "||")
+}
+
+func (p *parser) parseUnary() (*Expr, error) {
+	p.skipSpaces()
+	if p.pos == len(p.input) {
+		return nil, nil
+	}
+	if p.keyword("NOT", "!") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if expr == nil {
+			return nil, p.errorf("expression expected after NOT")
+		}
+		return &Expr{Op: OpNot, Children: []*Expr{expr}}, nil
+	}
+	if p.input[p.pos] == '(' {
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.pos == len(p.input) || p.input[p.pos] != ')' {
+			return nil, p.errorf("closing bracket expected")
+		}
+		p.pos++
+		if expr == nil {
+			return nil, p.errorf("empty brackets")
+		}
+		return expr, nil
+	}
+	return p.parseCondition()
+}
+
+func (p *parser) parseCondition() (*Expr, error) {
+	field := fieldRe.FindString(p.input[p.pos:])
+	if field == "" {
+		return nil, p.errorf("field name expected")
+	}
+	p.pos += len(field)
+	if field == "exists" && p.pos < len(p.input) && p.input[p.pos] == '(' {
+		p.pos++
+		p.skipSpaces()
+		field = fieldRe.FindString(p.input[p.pos:])
+		if field == "" {
+			return nil, p.errorf("field name expected inside exists")
+		}
+		p.pos += len(field)
+		p.skipSpaces()
+		if p.pos == len(p.input) || p.input[p.pos] != ')' {
+			return nil, p.errorf("closing bracket expected")
+		}
+		p.pos++
+		return &Expr{Op: OpExists, Field: field}, nil
+	}
+	var op Operator
+	for _, el := range conditionOperators {
+		if strings.HasPrefix(p.input[p.pos:], string(el)) {
+			op = el
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.errorf("operator expected after field %s", field)
+	}
+	p.pos += len(op)
+	if op == "=" {
+		op = OpEqual
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return newCondition(field, op, value)
+}
+
+//parseValue reads quoted string or characters till space or unbalanced closing bracket
+func (p *parser) parseValue() (string, error) {
+	if p.pos < len(p.input) && p.input[p.pos] == '"' {
+		end := p.pos + 1
+		for end < len(p.input) && p.input[end] != '"' {
+			if p.input[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.input) {
+			return "", p.errorf("unterminated string")
+		}
+		value, err := strconv.Unquote(p.input[p.pos : end+1])
+		if err != nil {
+			return "", p.errorf("wrong string: %s", err)
+		}
+		p.pos = end + 1
+		return value, nil
+	}
+	start := p.pos
+	depth := 0
+loop:
+	for ; p.pos < len(p.input); p.pos++ {
+		switch c := p.input[p.pos]; {
+		case unicode.IsSpace(rune(c)):
+			break loop
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				break loop
+			}
+			depth--
+		}
+	}
+	return p.input[start:p.pos], nil
+}
+
+func join(op Operator, left, right *Expr) *Expr {
+	if left.Op == op {
+		left.Children = append(left.Children, right)
+		return left
+	}
+	return &Expr{Op: op, Children: []*Expr{left, right}}
+}
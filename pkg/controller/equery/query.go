@@ -0,0 +1,359 @@
+package equery
+
+import (
+	"container/list"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+//ExprKey is a key of query map to pass expression parsed with Parse
+//other keys of map are fields with regexp to match
+const ExprKey = "_expr"
+
+//Operator of expression
+type Operator string
+
+//operators of expression
+const (
+	OpAnd            Operator = "AND"
+	OpOr             Operator = "OR"
+	OpNot            Operator = "NOT"
+	OpExists         Operator = "exists"
+	OpMatch          Operator = ":"
+	OpNotMatch       Operator = "!:"
+	OpEqual          Operator = "=="
+	OpNotEqual       Operator = "!="
+	OpGreater        Operator = ">"
+	OpGreaterOrEqual Operator = ">="
+	OpLess           Operator = "<"
+	OpLessOrEqual    Operator = "<="
+)
+
+//severities ordered by importance to compare with > and <
+var severities = map[string]int{
+	"trace":    0,
+	"debug":    1,
+	"info":     2,
+	"notice":   3,
+	"warn":     4,
+	"warning":  4,
+	"err":      5,
+	"error":    5,
+	"crit":     6,
+	"critical": 6,
+	"fatal":    6,
+	"panic":    7,
+}
+
+//Expr is a node of parsed query
+//AND, OR and NOT nodes have Children, other nodes are conditions on Field
+type Expr struct {
+	Op       Operator
+	Field    string
+	Value    string
+	Children []*Expr
+	re       *regexp.Regexp
+	path     string
+}
+
+func newCondition(field string, op Operator, value string) (*Expr, error) {
+	expr := &Expr{Op: op, Field: field, Value: value}
+	if op == OpMatch || op == OpNotMatch {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("wrong regexp for %s: %s", field, err)
+		}
+		expr.re = re
+	}
+	return expr, nil
+}
+
+//String returns expression in form accepted by Parse
+func (expr *Expr) String() string {
+	switch expr.Op {
+	case OpAnd, OpOr:
+		var parts []string
+		for _, el := range expr.Children {
+			if len(el.Children) > 1 {
+				parts = append(parts, fmt.Sprintf("(%s)", el))
+			} else {
+				parts = append(parts, el.String())
+			}
+		}
+		return strings.Join(parts, fmt.Sprintf(" %s ", expr.Op))
+	case OpNot:
+		return fmt.Sprintf("NOT (%s)", expr.Children[0])
+	case OpExists:
+		return fmt.Sprintf("exists(%s)", expr.Field)
+	default:
+		return fmt.Sprintf("%s%s%s", expr.Field, expr.Op, strconv.Quote(expr.Value))
+	}
+}
+
+//fieldPath returns path for utils.LookupWithCallback with uppercase first letters of field`s name parts
+func fieldPath(field string, aliases map[string]string) string {
+	parts := strings.Split(field, ".")
+	if alias, ok := aliases[strings.ToLower(parts[0])]; ok {
+		parts = append(strings.Split(alias, "."), parts[1:]...)
+	}
+	for i, pathElement := range parts {
+		parts[i] = strings.Title(pathElement)
+	}
+	return strings.Join(parts, ".")
+}
+
+func (expr *Expr) setAliases(aliases map[string]string) {
+	if expr.Field != "" {
+		expr.path = fieldPath(expr.Field, aliases)
+	}
+	for _, el := range expr.Children {
+		el.setAliases(aliases)
+	}
+}
+
+//Match returns true if obj satisfies expression
+//condition on repeated field is satisfied if any of values satisfies it
+func (expr *Expr) Match(obj interface{}) bool {
+	switch expr.Op {
+	case OpAnd:
+		for _, el := range expr.Children {
+			if !el.Match(obj) {
+				return false
+			}
+		}
+		return true
+	case OpOr:
+		for _, el := range expr.Children {
+			if el.Match(obj) {
+				return true
+			}
+		}
+		return false
+	case OpNot:
+		return !expr.Children[0].Match(obj)
+	}
+	path := expr.path
+	if path == "" {
+		path = fieldPath(expr.Field, nil)
+	}
+	matched := false
+	utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(obj)).Interface(), path, func(inp reflect.Value) {
+		if !matched {
+			matched = expr.check(inp)
+		}
+	})
+	return matched
+}
+
+func (expr *Expr) check(inp reflect.Value) bool {
+	if expr.Op == OpExists {
+		return !isEmpty(inp)
+	}
+	f := fmt.Sprint(inp)
+	switch expr.Op {
+	case OpMatch:
+		return expr.re.MatchString(f)
+	case OpNotMatch:
+		return !expr.re.MatchString(f)
+	case OpEqual:
+		return compare(f, expr.Value) == 0
+	case OpNotEqual:
+		return compare(f, expr.Value) != 0
+	case OpGreater:
+		return compare(f, expr.Value) > 0
+	case OpGreaterOrEqual:
+		return compare(f, expr.Value) >= 0
+	case OpLess:
+		return compare(f, expr.Value) < 0
+	case OpLessOrEqual:
+		return compare(f, expr.Value) <= 0
+	}
+	return false
+}
+
+func isEmpty(inp reflect.Value) bool {
+	switch inp.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return inp.IsNil()
+	case reflect.Slice, reflect.Map, reflect.String:
+		return inp.Len() == 0
+	case reflect.Invalid:
+		return true
+	}
+	return false
+}
+
+//compare compares values as numbers, as severities or as strings
+func compare(a, b string) int {
+	if fa, err := strconv.ParseFloat(a, 64); err == nil {
+		if fb, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case fa < fb:
+				return -1
+			case fa > fb:
+				return 1
+			}
+			return 0
+		}
+	}
+	if sa, ok := severities[strings.ToLower(a)]; ok {
+		if sb, ok := severities[strings.ToLower(b)]; ok {
+			return sa - sb
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+//compiledCacheSize is maximum number of compiled queries to keep
+const compiledCacheSize = 1024
+
+type compiledEntry struct {
+	key  string
+	expr *Expr
+}
+
+//compiled is LRU cache of compiled queries
+var (
+	compiled     = map[string]*list.Element{}
+	compiledList = list.New()
+	compiledMu   sync.Mutex
+)
+
+//getCompiled returns cached expression for key, must be called with lock
+func getCompiled(key string) (*Expr, bool) {
+	el, ok := compiled[key]
+	if !ok {
+		return nil, false
+	}
+	compiledList.MoveToFront(el)
+	return el.Value.(*compiledEntry).expr, true
+}
+
+//putCompiled adds expression for key into cache removing the least recently used one if full, must be called with lock
+func putCompiled(key string, expr *Expr) {
+	compiled[key] = compiledList.PushFront(&compiledEntry{key: key, expr: expr})
+	if compiledList.Len() > compiledCacheSize {
+		oldest := compiledList.Back()
+		compiledList.Remove(oldest)
+		delete(compiled, oldest.Value.(*compiledEntry).key)
+	}
+}
+
+//Compile returns expression for query map with field:regexp pairs which all must match
+//and with optional expression under ExprKey
+//results are cached to parse every query once
+func Compile(q map[string]string) (*Expr, error) {
+	return CompileWithAliases(q, nil)
+}
+
+//CompileWithAliases is Compile with replacement of first parts of field names with aliases
+func CompileWithAliases(q map[string]string, aliases map[string]string) (*Expr, error) {
+	key := cacheKey(q, aliases)
+	compiledMu.Lock()
+	defer compiledMu.Unlock()
+	if expr, ok := getCompiled(key); ok {
+		return expr, nil
+	}
+	expr := &Expr{Op: OpAnd}
+	var keys []string
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == ExprKey {
+			parsed, err := Parse(q[k])
+			if err != nil {
+				return nil, err
+			}
+			expr.Children = append(expr.Children, parsed)
+			continue
+		}
+		cond, err := newCondition(k, OpMatch, q[k])
+		if err != nil {
+			return nil, err
+		}
+		expr.Children = append(expr.Children, cond)
+	}
+	expr.setAliases(aliases)
+	putCompiled(key, expr)
+	return expr, nil
+}
+
+func cacheKey(q map[string]string, aliases map[string]string) string {
+	var parts []string
+	for k, v := range q {
+		parts = append(parts, strconv.Quote(k)+strconv.Quote(v))
+	}
+	sort.Strings(parts)
+	var aliasParts []string
+	for k, v := range aliases {
+		aliasParts = append(aliasParts, strconv.Quote(k)+strconv.Quote(v))
+	}
+	sort.Strings(aliasParts)
+	return strings.Join(parts, ",") + "|" + strings.Join(aliasParts, ",")
+}
+
+//MatchMap returns true if obj satisfies query map
+//wrong queries never match
+func MatchMap(obj interface{}, q map[string]string) bool {
+	return MatchMapWithAliases(obj, q, nil)
+}
+
+//MatchMapWithAliases is MatchMap with aliases of fields
+func MatchMapWithAliases(obj interface{}, q map[string]string, aliases map[string]string) bool {
+	expr, err := CompileWithAliases(q, aliases)
+	if err != nil {
+		return false
+	}
+	return expr.Match(obj)
+}
+
+var simpleConditionRe = regexp.MustCompile(`^([A-Za-z_][\w.\[\]]*):(.*)$`)
+
+//SimpleCondition returns field and regexp if arg is in field:regexp form
+//value is used as is even if it contains keywords of expressions
+func SimpleCondition(arg string) (field, value string, ok bool) {
+	if res := simpleConditionRe.FindStringSubmatch(arg); res != nil {
+		return res[1], res[2], true
+	}
+	return "", "", false
+}
+
+//IsExpression returns true if arg is expression enclosed in brackets
+func IsExpression(arg string) bool {
+	arg = strings.TrimSpace(arg)
+	return strings.HasPrefix(arg, "(") && strings.HasSuffix(arg, ")")
+}
+
+//FromArgs returns query map from arguments of command line
+//arguments in (expression) form are joined with AND into expression, others must be in field:regexp form
+func FromArgs(args []string) (map[string]string, error) {
+	q := make(map[string]string)
+	var expressions []string
+	for _, a := range args {
+		if IsExpression(a) {
+			expressions = append(expressions, strings.TrimSpace(a))
+			continue
+		}
+		field, value, ok := SimpleCondition(a)
+		if !ok {
+			return nil, fmt.Errorf("%s is not in field:regexp form, enclose expressions in brackets: (%s)", a, a)
+		}
+		q[field] = value
+	}
+	if len(expressions) > 0 {
+		q[ExprKey] = strings.Join(expressions, " AND ")
+	}
+	if _, err := Compile(q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
@@ -6,13 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"reflect"
-	"regexp"
-	"strings"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
-	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -33,32 +30,9 @@ func ParseRequestItem(data []byte) (logItem *types.APIRequest, err error) {
 	return &le, err
 }
 
-//RequestItemFind find APIRequest records by reqexps in 'query' corresponded to APIRequest structure.
+//RequestItemFind find APIRequest records by 'query' corresponded to APIRequest structure.
 func RequestItemFind(le *types.APIRequest, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, strings.Title(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
-	}
-	return matched
+	return equery.MatchMap(le, query)
 }
 
 //RequestPrn print APIRequest data
@@ -85,12 +59,18 @@ func RequestPrn(le *types.APIRequest, format RequestFormat) {
 type HandlerFunc func(request *types.APIRequest) bool
 
 func requestProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFunction {
+	expr, err := equery.Compile(query)
+	if err != nil {
+		return func(bytes []byte) (bool, error) {
+			return false, err
+		}
+	}
 	return func(bytes []byte) (bool, error) {
 		le, err := ParseRequestItem(bytes)
 		if err != nil {
 			log.Debugf("logProcess: %s", err)
 		}
-		if RequestItemFind(le, query) {
+		if expr.Match(le) {
 			if handler(le) {
 				return false, nil
 			}
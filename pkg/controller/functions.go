@@ -13,6 +13,9 @@ import (
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/adam"
+	"github.com/lf-edge/eden/pkg/controller/protodir"
+	"github.com/lf-edge/eden/pkg/controller/zedcloud"
+	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -23,13 +26,66 @@ import (
 	"golang.org/x/term"
 )
 
+//GetControllerMode parse url with controller
+func GetControllerMode(controllerMode string) (modeType, modeURL string, err error) {
+	params := utils.GetParams(controllerMode, defaults.DefaultControllerModePattern)
+	if len(params) == 0 {
+		return "", "", fmt.Errorf("cannot parse mode (not [file|proto|adam|zedcloud]://<URL>): %s", controllerMode)
+	}
+	ok := false
+	if modeType, ok = params["Type"]; !ok {
+		return "", "", fmt.Errorf("cannot parse modeType (not [file|proto|adam|zedcloud]://<URL>): %s", controllerMode)
+	}
+	if modeURL, ok = params["URL"]; !ok {
+		return "", "", fmt.Errorf("cannot parse modeURL (not [file|proto|adam|zedcloud]://<URL>): %s", controllerMode)
+	}
+	return
+}
+
+//NewController returns Controller implementation for modeType
+func NewController(modeType string) (Controller, error) {
+	switch modeType {
+	case "", "adam":
+		return &adam.Ctx{}, nil
+	case "zedcloud":
+		return &zedcloud.Ctx{}, nil
+	case "proto":
+		return &protodir.Ctx{}, nil
+	default:
+		return nil, fmt.Errorf("not implemented controller type %s", modeType)
+	}
+}
+
 //CloudPrepare is for init controller connection and obtain device list
+//it uses controller defined in test.controller (adam by default)
 func CloudPrepare() (Cloud, error) {
 	vars, err := utils.InitVars()
 	if err != nil {
 		return nil, fmt.Errorf("utils.InitVars: %s", err)
 	}
-	ctx := &CloudCtx{vars: vars, Controller: &adam.Ctx{}}
+	modeType := ""
+	if vars.ControllerMode != "" {
+		var modeURL string
+		modeType, modeURL, err = GetControllerMode(vars.ControllerMode)
+		if err != nil {
+			log.Debug(err)
+		}
+		switch modeType {
+		case "zedcloud":
+			if vars.ZedcloudURL == "" {
+				vars.ZedcloudURL = modeURL
+			}
+		case "proto":
+			if modeURL != "" {
+				vars.ProtoDir = utils.ResolveAbsPath(modeURL)
+			}
+		}
+	}
+	ctrl, err := NewController(modeType)
+	if err != nil {
+		return nil, err
+	}
+	ctx := &CloudCtx{vars: vars, Controller: ctrl}
 	if err := ctx.InitWithVars(vars); err != nil {
 		return nil, fmt.Errorf("cloud.InitWithVars: %s", err)
 	}
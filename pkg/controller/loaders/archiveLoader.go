@@ -0,0 +1,185 @@
+package loaders
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/archive"
+	"github.com/lf-edge/eden/pkg/controller/cachers"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+//ArchiveLoader implements loader which replays archive recorded with archive.Writer
+//with zero speed all objects of archive are existing and no new objects appear,
+//otherwise archive is replayed from the first object with speed relative to original pace
+//starting from the time defined in NewArchiveLoader
+type ArchiveLoader struct {
+	root      string
+	speed     float64
+	started   time.Time
+	devUUID   uuid.UUID
+	appUUID   uuid.UUID
+	cache     cachers.CacheProcessor
+	timeRange types.TimeRange
+}
+
+//NewArchiveLoader return loader from archive inside root
+func NewArchiveLoader(root string, speed float64, started time.Time) *ArchiveLoader {
+	log.Debugf("NewArchiveLoader init")
+	return &ArchiveLoader{root: root, speed: speed, started: started}
+}
+
+//SetRemoteCache add cache layer
+func (loader *ArchiveLoader) SetRemoteCache(cache cachers.CacheProcessor) {
+	loader.cache = cache
+}
+
+//Clone create copy
+func (loader *ArchiveLoader) Clone() Loader {
+	return &ArchiveLoader{
+		root:      loader.root,
+		speed:     loader.speed,
+		started:   loader.started,
+		devUUID:   loader.devUUID,
+		appUUID:   loader.appUUID,
+		cache:     loader.cache,
+		timeRange: loader.timeRange,
+	}
+}
+
+//SetUUID set device UUID
+func (loader *ArchiveLoader) SetUUID(devUUID uuid.UUID) {
+	loader.devUUID = devUUID
+}
+
+//SetAppUUID set app UUID
+func (loader *ArchiveLoader) SetAppUUID(appUUID uuid.UUID) {
+	loader.appUUID = appUUID
+}
+
+//SetTimeRange set range of timestamps of objects to process
+func (loader *ArchiveLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
+//replayStart returns timestamp of the first object in archive of device
+func (loader *ArchiveLoader) replayStart() (time.Time, error) {
+	var start time.Time
+	for _, typeToProcess := range []types.LoaderObjectType{types.LogsType, types.InfoType, types.MetricsType, types.FlowLogType, types.RequestType} {
+		records, err := archive.Read(archive.DeviceDir(loader.root, loader.devUUID), typeToProcess, loader.appUUID)
+		if err != nil {
+			return start, err
+		}
+		if len(records) > 0 && (start.IsZero() || records[0].Timestamp.Before(start)) {
+			start = records[0].Timestamp
+		}
+	}
+	return start, nil
+}
+
+//split returns objects which already replayed and which must be replayed later
+//with the time of archive when split happens
+func (loader *ArchiveLoader) split(typeToProcess types.LoaderObjectType) (existing, next []*archive.Record, now time.Time, err error) {
+	records, err := archive.Read(archive.DeviceDir(loader.root, loader.devUUID), typeToProcess, loader.appUUID)
+	if err != nil {
+		return nil, nil, now, err
+	}
+	if loader.speed <= 0 {
+		return records, nil, now, nil
+	}
+	start, err := loader.replayStart()
+	if err != nil {
+		return nil, nil, now, err
+	}
+	now = loader.archiveTime(start)
+	for i, el := range records {
+		if el.Timestamp.After(now) {
+			return records[:i], records[i:], now, nil
+		}
+	}
+	return records, nil, now, nil
+}
+
+//archiveTime returns current time of replay
+func (loader *ArchiveLoader) archiveTime(start time.Time) time.Time {
+	return start.Add(time.Duration(float64(time.Since(loader.started)) * loader.speed))
+}
+
+func (loader *ArchiveLoader) processRecord(process ProcessFunction, typeToProcess types.LoaderObjectType, record *archive.Record) (bool, error) {
+	if loader.cache != nil {
+		if err := loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, record.Object); err != nil {
+			log.Errorf("error in cache: %s", err)
+		}
+	}
+	return process(record.Object)
+}
+
+//ProcessExisting for observe objects replayed from archive, the newest first
+func (loader *ArchiveLoader) ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	process = filterByTimeRange(process, typeToProcess, loader.timeRange, false)
+	existing, _, _, err := loader.split(typeToProcess)
+	if err != nil {
+		return err
+	}
+	for i := len(existing) - 1; i >= 0; i-- {
+		doContinue, err := loader.processRecord(process, typeToProcess, existing[i])
+		if err != nil {
+			return err
+		}
+		if !doContinue {
+			return nil
+		}
+	}
+	return nil
+}
+
+//ProcessStream for observe objects which will be replayed from archive
+//archive is finite, so with zero timeout it returns after replay of the rest of archive
+func (loader *ArchiveLoader) ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) error {
+	process = filterByTimeRange(process, typeToProcess, loader.timeRange, true)
+	_, next, now, err := loader.split(typeToProcess)
+	if err != nil {
+		return err
+	}
+	done := make(chan error, 2)
+	if timeoutSeconds != 0 {
+		time.AfterFunc(timeoutSeconds*time.Second, func() {
+			done <- fmt.Errorf("timeout")
+		})
+	}
+	stop := make(chan struct{})
+	finished := make(chan struct{})
+	defer func() {
+		//do not return while process may be called
+		close(stop)
+		<-finished
+	}()
+	go func() {
+		defer close(finished)
+		for _, el := range next {
+			select {
+			case <-time.After(time.Duration(float64(el.Timestamp.Sub(now)) / loader.speed)):
+			case <-stop:
+				return
+			}
+			now = el.Timestamp
+			doContinue, err := loader.processRecord(process, typeToProcess, el)
+			if err != nil {
+				done <- err
+				return
+			}
+			if !doContinue {
+				done <- nil
+				return
+			}
+		}
+		log.Debugf("end of archive for %s", loader.devUUID)
+		if timeoutSeconds == 0 {
+			//no new objects will appear, so there is nothing to wait for
+			done <- nil
+		}
+	}()
+	return <-done
+}
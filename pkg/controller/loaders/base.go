@@ -4,6 +4,7 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/cachers"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
 	"time"
 )
 
@@ -11,6 +12,7 @@ import (
 type Loader interface {
 	SetAppUUID(devUUID uuid.UUID)
 	SetUUID(devUUID uuid.UUID)
+	SetTimeRange(timeRange types.TimeRange)
 	ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) error
 	ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error
 	SetRemoteCache(cache cachers.CacheProcessor)
@@ -19,3 +21,26 @@ type Loader interface {
 
 //ProcessFunction is prototype of processing function
 type ProcessFunction func(bytes []byte) (bool, error)
+
+//filterByTimeRange wraps process to skip objects with timestamp outside of timeRange
+//in stream mode processing stops on the first object newer than timeRange.Until
+func filterByTimeRange(process ProcessFunction, typeToProcess types.LoaderObjectType, timeRange types.TimeRange, stream bool) ProcessFunction {
+	if timeRange.IsEmpty() {
+		return process
+	}
+	return func(data []byte) (bool, error) {
+		ts, err := cachers.GetTimestamp(typeToProcess, data)
+		if err != nil {
+			log.Warnf("skip object without timestamp: %s", err)
+			return true, nil
+		}
+		t := ts.AsTime()
+		if timeRange.Contains(t) {
+			return process(data)
+		}
+		if stream && !timeRange.Until.IsZero() && t.After(timeRange.Until) {
+			return false, nil
+		}
+		return true, nil
+	}
+}
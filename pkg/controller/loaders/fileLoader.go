@@ -15,10 +15,11 @@ import (
 
 //FileLoader implements loader from file backend of controller
 type FileLoader struct {
-	appUUID uuid.UUID
-	devUUID uuid.UUID
-	getters types.DirGetters
-	cache   cachers.CacheProcessor
+	appUUID   uuid.UUID
+	devUUID   uuid.UUID
+	getters   types.DirGetters
+	cache     cachers.CacheProcessor
+	timeRange types.TimeRange
 }
 
 //NewFileLoader return loader from files
@@ -35,10 +36,11 @@ func (loader *FileLoader) SetRemoteCache(cache cachers.CacheProcessor) {
 //Clone create copy
 func (loader *FileLoader) Clone() Loader {
 	return &FileLoader{
-		getters: loader.getters,
-		devUUID: loader.devUUID,
-		appUUID: loader.appUUID,
-		cache:   loader.cache,
+		getters:   loader.getters,
+		devUUID:   loader.devUUID,
+		appUUID:   loader.appUUID,
+		cache:     loader.cache,
+		timeRange: loader.timeRange,
 	}
 }
 
@@ -71,8 +73,14 @@ func (loader *FileLoader) SetAppUUID(appUUID uuid.UUID) {
 	loader.appUUID = appUUID
 }
 
+//SetTimeRange set range of timestamps of objects to process
+func (loader *FileLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
 //ProcessExisting for observe existing files
 func (loader *FileLoader) ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	process = filterByTimeRange(process, typeToProcess, loader.timeRange, false)
 	files, err := ioutil.ReadDir(loader.getFilePath(typeToProcess))
 	if err != nil {
 		return err
@@ -85,6 +93,9 @@ func (loader *FileLoader) ProcessExisting(process ProcessFunction, typeToProcess
 		if file.IsDir() {
 			continue
 		}
+		if !loader.timeRange.Since.IsZero() && file.ModTime().Before(loader.timeRange.Since) {
+			break // files are sorted by time of write which cannot be before timestamp of object inside
+		}
 		fileFullPath := path.Join(loader.getFilePath(typeToProcess), file.Name())
 		log.Debugf("local controller parse %s", fileFullPath)
 		data, err := ioutil.ReadFile(fileFullPath)
@@ -93,7 +104,7 @@ func (loader *FileLoader) ProcessExisting(process ProcessFunction, typeToProcess
 			continue
 		}
 		if loader.cache != nil {
-			if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+			if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
 				log.Errorf("error in cache: %s", err)
 			}
 		}
@@ -110,6 +121,7 @@ func (loader *FileLoader) ProcessExisting(process ProcessFunction, typeToProcess
 
 //ProcessStream for observe new files
 func (loader *FileLoader) ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) error {
+	process = filterByTimeRange(process, typeToProcess, loader.timeRange, true)
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
@@ -140,7 +152,7 @@ func (loader *FileLoader) ProcessStream(process ProcessFunction, typeToProcess t
 					}
 					log.Debugf("local controller parse %s", event.Name)
 					if loader.cache != nil {
-						if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+						if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
 							log.Errorf("error in cache: %s", err)
 						}
 					}
@@ -24,6 +24,7 @@ type RedisLoader struct {
 	cache         cachers.CacheProcessor
 	devUUID       uuid.UUID
 	appUUID       uuid.UUID
+	timeRange     types.TimeRange
 }
 
 //NewRedisLoader return loader from redis
@@ -53,6 +54,7 @@ func (loader *RedisLoader) Clone() Loader {
 		cache:         loader.cache,
 		devUUID:       loader.devUUID,
 		appUUID:       loader.appUUID,
+		timeRange:     loader.timeRange,
 	}
 }
 
@@ -85,13 +87,31 @@ func (loader *RedisLoader) SetAppUUID(appUUID uuid.UUID) {
 	loader.appUUID = appUUID
 }
 
+//SetTimeRange set range of timestamps of objects to process
+func (loader *RedisLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
+//streamIDRange returns range of stream IDs to load existing objects inside timeRange
+//IDs of stream are generated from time of insertion, so we use DefaultStreamIDSlack for end of range
+func (loader *RedisLoader) streamIDRange() (start, end string) {
+	start, end = "-", "+"
+	if !loader.timeRange.Since.IsZero() {
+		start = fmt.Sprintf("%d-0", loader.timeRange.Since.UnixNano()/int64(time.Millisecond))
+	}
+	if !loader.timeRange.Until.IsZero() {
+		end = fmt.Sprintf("%d", loader.timeRange.Until.Add(defaults.DefaultStreamIDSlack).UnixNano()/int64(time.Millisecond))
+	}
+	return
+}
+
 func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, found bool, err error) {
 	OrderStream := loader.getStream(typeToProcess)
 	log.Debugf("XRead from %s", OrderStream)
 	if !stream {
-		start := "-"
+		start, end := loader.streamIDRange()
 		for {
-			rr, err := loader.client.XRangeN(OrderStream, start, "+", 10).Result()
+			rr, err := loader.client.XRangeN(OrderStream, start, end, 10).Result()
 			if err != nil {
 				return false, false, fmt.Errorf("XRange error: %s", err)
 			}
@@ -112,7 +132,7 @@ func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.
 					return false, false, fmt.Errorf("process: %s", err)
 				}
 				if loader.cache != nil {
-					if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+					if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
 						log.Errorf("error in cache: %s", err)
 					}
 				}
@@ -147,7 +167,7 @@ func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.
 				return false, false, fmt.Errorf("process first: %s", err)
 			}
 			if loader.cache != nil {
-				if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+				if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
 					log.Errorf("error in cache: %s", err)
 				}
 			}
@@ -176,7 +196,7 @@ func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.
 					return false, false, fmt.Errorf("process: %s", err)
 				}
 				if loader.cache != nil {
-					if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+					if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
 						log.Errorf("error in cache: %s", err)
 					}
 				}
@@ -219,7 +239,7 @@ func (loader *RedisLoader) ProcessExisting(process ProcessFunction, typeToProces
 	if _, err := loader.getOrCreateClient(); err != nil {
 		return err
 	}
-	return loader.repeatableConnection(process, typeToProcess, false)
+	return loader.repeatableConnection(filterByTimeRange(process, typeToProcess, loader.timeRange, false), typeToProcess, false)
 }
 
 //ProcessStream for observe new files
@@ -235,7 +255,7 @@ func (loader *RedisLoader) ProcessStream(process ProcessFunction, typeToProcess
 	}
 
 	go func() {
-		done <- loader.repeatableConnection(process, typeToProcess, true)
+		done <- loader.repeatableConnection(filterByTimeRange(process, typeToProcess, loader.timeRange, true), typeToProcess, true)
 	}()
 	if err = <-done; err != nil {
 		return err
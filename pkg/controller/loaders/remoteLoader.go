@@ -3,7 +3,6 @@ package loaders
 import (
 	"encoding/json"
 	"fmt"
-	"google.golang.org/protobuf/encoding/protojson"
 	"io"
 	"net/http"
 	"time"
@@ -12,10 +11,14 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/cachers"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve/api/go/flowlog"
 	"github.com/lf-edge/eve/api/go/info"
 	"github.com/lf-edge/eve/api/go/logs"
+	"github.com/lf-edge/eve/api/go/metrics"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -39,6 +42,7 @@ type RemoteLoader struct {
 	getClient    getClient
 	client       *http.Client
 	cache        cachers.CacheProcessor
+	timeRange    types.TimeRange
 }
 
 //NewRemoteLoader return loader from files
@@ -69,6 +73,7 @@ func (loader *RemoteLoader) Clone() Loader {
 		appUUID:      loader.appUUID,
 		client:       loader.getClient(),
 		cache:        loader.cache,
+		timeRange:    loader.timeRange,
 	}
 }
 
@@ -103,30 +108,37 @@ func (loader *RemoteLoader) SetAppUUID(appUUID uuid.UUID) {
 
 func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, tocontinue bool, err error) {
 	var buf []byte
+	var emp interface{}
 	switch typeToProcess {
 	case types.LogsType:
-		var emp logs.LogBundle
-		if err := decoder.Decode(&emp); err == io.EOF {
-			return false, false, nil
-		} else if err != nil {
-			return false, false, err
-		}
-		if buf, err = protojson.Marshal(&emp); err != nil {
-			return false, false, err
-		}
+		emp = &logs.LogBundle{}
 	case types.InfoType:
-		var emp info.ZInfoMsg
-		if err := decoder.Decode(&emp); err == io.EOF {
-			return false, false, nil
-		} else if err != nil {
-			return false, false, err
-		}
-		if buf, err = protojson.Marshal(&emp); err != nil {
+		emp = &info.ZInfoMsg{}
+	case types.MetricsType:
+		emp = &metrics.ZMetricMsg{}
+	case types.FlowLogType:
+		emp = &flowlog.FlowMessage{}
+	case types.AppsType:
+		emp = &logs.LogEntry{}
+	case types.RequestType:
+		emp = &types.APIRequest{}
+	default:
+		return false, false, fmt.Errorf("not implemented type %d", typeToProcess)
+	}
+	if err := decoder.Decode(emp); err == io.EOF {
+		return false, false, nil
+	} else if err != nil {
+		return false, false, err
+	}
+	if msg, ok := emp.(proto.Message); ok {
+		if buf, err = protojson.Marshal(msg); err != nil {
 			return false, false, err
 		}
+	} else if buf, err = json.Marshal(emp); err != nil {
+		return false, false, err
 	}
 	if loader.cache != nil {
-		if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, buf); err != nil {
+		if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, buf); err != nil {
 			log.Errorf("error in cache: %s", err)
 		}
 	}
@@ -217,13 +229,19 @@ repeatLoop:
 	return fmt.Errorf("all connection attempts failed")
 }
 
+//SetTimeRange set range of timestamps of objects to process
+func (loader *RemoteLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
 //ProcessExisting for observe existing files
 func (loader *RemoteLoader) ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
-	return loader.repeatableConnection(process, typeToProcess, false)
+	return loader.repeatableConnection(filterByTimeRange(process, typeToProcess, loader.timeRange, false), typeToProcess, false)
 }
 
 //ProcessStream for observe new files
 func (loader *RemoteLoader) ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) (err error) {
+	process = filterByTimeRange(process, typeToProcess, loader.timeRange, true)
 	done := make(chan error)
 	if timeoutSeconds != 0 {
 		time.AfterFunc(timeoutSeconds*time.Second, func() {
@@ -0,0 +1,37 @@
+package protodir
+
+import (
+	"path/filepath"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+//getLogsDir return logs directory for devUUID
+func (ctx *Ctx) getLogsDir(devUUID uuid.UUID) (dir string) {
+	return filepath.Join(ctx.getDeviceDir(devUUID), "logs")
+}
+
+//getAppsLogsDir return app logs directory for devUUID and appUUID
+func (ctx *Ctx) getAppsLogsDir(devUUID uuid.UUID, appUUID uuid.UUID) (dir string) {
+	return filepath.Join(ctx.getDeviceDir(devUUID), "apps", appUUID.String())
+}
+
+//getInfoDir return info directory for devUUID
+func (ctx *Ctx) getInfoDir(devUUID uuid.UUID) (dir string) {
+	return filepath.Join(ctx.getDeviceDir(devUUID), "info")
+}
+
+//getMetricsDir return metrics directory for devUUID
+func (ctx *Ctx) getMetricsDir(devUUID uuid.UUID) (dir string) {
+	return filepath.Join(ctx.getDeviceDir(devUUID), "metrics")
+}
+
+//getFlowLogDir return flow logs directory for devUUID
+func (ctx *Ctx) getFlowLogDir(devUUID uuid.UUID) (dir string) {
+	return filepath.Join(ctx.getDeviceDir(devUUID), "flowlogs")
+}
+
+//getRequestDir return request directory for devUUID
+func (ctx *Ctx) getRequestDir(devUUID uuid.UUID) (dir string) {
+	return filepath.Join(ctx.getDeviceDir(devUUID), "requests")
+}
@@ -0,0 +1,361 @@
+//Package protodir provides implementation of controller.Controller
+//backed by directory with protobuf messages in JSON format.
+//It allows to generate configs of devices without running controller.
+package protodir
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/archive"
+	"github.com/lf-edge/eden/pkg/controller/eapps"
+	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/erequest"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/config"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	devicesDir = "devices"
+	configFile = "config.json"
+	certsFile  = "certs.json"
+	attestFile = "attest.json"
+	deviceFile = "device.json"
+)
+
+//Ctx stores controller settings
+type Ctx struct {
+	dir           string
+	timeRange     types.TimeRange
+	archive       bool      //use archive recorded with eden record instead of directories of objects
+	replaySpeed   float64   //speed of replay of archive relative to original pace, 0 to get all objects at once
+	replayStarted time.Time //time of start of replay
+}
+
+//InitWithVars use variables from viper for init controller
+func (ctx *Ctx) InitWithVars(vars *utils.ConfigVars) error {
+	if vars.ProtoDir == "" {
+		return fmt.Errorf("directory for proto controller is not defined")
+	}
+	ctx.dir = vars.ProtoDir
+	ctx.archive = archive.Exists(ctx.dir)
+	ctx.replaySpeed = vars.ProtoReplaySpeed
+	ctx.replayStarted = time.Now()
+	return os.MkdirAll(filepath.Join(ctx.dir, devicesDir), 0755)
+}
+
+//GetDir return dir
+func (ctx *Ctx) GetDir() (dir string) {
+	return ctx.dir
+}
+
+//getDeviceDir return directory of device with devUUID
+func (ctx *Ctx) getDeviceDir(devUUID uuid.UUID) string {
+	return filepath.Join(ctx.dir, devicesDir, devUUID.String())
+}
+
+//getLoader return loader object for directory
+func (ctx *Ctx) getLoader() loaders.Loader {
+	if ctx.archive {
+		loader := loaders.NewArchiveLoader(ctx.dir, ctx.replaySpeed, ctx.replayStarted)
+		loader.SetTimeRange(ctx.timeRange)
+		return loader
+	}
+	dirGetters := types.DirGetters{
+		LogsGetter:    ctx.getLogsDir,
+		InfoGetter:    ctx.getInfoDir,
+		MetricsGetter: ctx.getMetricsDir,
+		FlowLogGetter: ctx.getFlowLogDir,
+		RequestGetter: ctx.getRequestDir,
+		AppsGetter:    ctx.getAppsLogsDir,
+	}
+	loader := loaders.NewFileLoader(dirGetters)
+	loader.SetTimeRange(ctx.timeRange)
+	return loader
+}
+
+//SetTimeRange set range of timestamps for logs, info, metrics and flow logs to process
+func (ctx *Ctx) SetTimeRange(timeRange types.TimeRange) {
+	ctx.timeRange = timeRange
+}
+
+func (ctx *Ctx) readDeviceCert(devUUID uuid.UUID) (*types.DeviceCert, error) {
+	data, err := ioutil.ReadFile(filepath.Join(ctx.getDeviceDir(devUUID), deviceFile))
+	if err != nil {
+		return nil, err
+	}
+	var devCert types.DeviceCert
+	if err = json.Unmarshal(data, &devCert); err != nil {
+		return nil, err
+	}
+	return &devCert, nil
+}
+
+func (ctx *Ctx) writeDeviceCert(devUUID uuid.UUID, devCert types.DeviceCert) error {
+	data, err := json.MarshalIndent(devCert, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(ctx.getDeviceDir(devUUID), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(ctx.getDeviceDir(devUUID), deviceFile), data, 0644)
+}
+
+//Register device in directory
+//it assigns new uuid to device as controller does after onboarding
+func (ctx *Ctx) Register(device *device.Ctx) error {
+	b, err := ioutil.ReadFile(device.GetOnboardKey())
+	if err != nil {
+		return fmt.Errorf("error reading cert file %s: %v", device.GetOnboardKey(), err)
+	}
+	cert, err := utils.ParseFirstCertFromBlock(b)
+	if err != nil {
+		return err
+	}
+	if devUUID, err := ctx.DeviceGetByOnboardUUID(cert.Subject.CommonName); err == nil {
+		log.Debugf("device with onboard %s already exists: %s", cert.Subject.CommonName, devUUID)
+		return nil
+	}
+	devUUID, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	if err = ctx.writeDeviceCert(devUUID, types.DeviceCert{Onboard: b, Serial: device.GetSerial()}); err != nil {
+		return err
+	}
+	devConfig := &config.EdgeDevConfig{Id: &config.UUIDandVersion{Uuid: devUUID.String(), Version: "0"}}
+	data, err := protojson.Marshal(devConfig)
+	if err != nil {
+		return err
+	}
+	return ctx.ConfigSet(devUUID, data)
+}
+
+//DeviceList return device list
+func (ctx *Ctx) DeviceList(filter types.DeviceStateFilter) (out []string, err error) {
+	out = []string{}
+	if filter == types.NotRegisteredDeviceFilter {
+		return out, nil
+	}
+	files, err := ioutil.ReadDir(filepath.Join(ctx.dir, devicesDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		if _, err := uuid.FromString(file.Name()); err != nil {
+			continue
+		}
+		out = append(out, file.Name())
+	}
+	return out, nil
+}
+
+//ConfigSet set config for devID
+//it stores config in pretty protojson format to simplify comparison
+func (ctx *Ctx) ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error) {
+	var deviceConfig config.EdgeDevConfig
+	if err = protojson.Unmarshal(devConfig, &deviceConfig); err != nil {
+		return fmt.Errorf("cannot unmarshal config: %s", err)
+	}
+	data, err := protojson.MarshalOptions{Multiline: true, Indent: "    "}.Marshal(&deviceConfig)
+	if err != nil {
+		return fmt.Errorf("cannot marshal config: %s", err)
+	}
+	if err = os.MkdirAll(ctx.getDeviceDir(devUUID), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(ctx.getDeviceDir(devUUID), configFile), data, 0644)
+}
+
+//ConfigGet get config for devID
+func (ctx *Ctx) ConfigGet(devUUID uuid.UUID) (out string, err error) {
+	data, err := ioutil.ReadFile(filepath.Join(ctx.getDeviceDir(devUUID), configFile))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+//CertsGet get attest certs for devID
+//it returns empty list if no certs file found
+func (ctx *Ctx) CertsGet(devUUID uuid.UUID) (out string, err error) {
+	data, err := ioutil.ReadFile(filepath.Join(ctx.getDeviceDir(devUUID), certsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "{}", nil
+		}
+		return "", err
+	}
+	return string(data), nil
+}
+
+//AttestGet get last attestation request with quote for devID
+//it returns error if no attest file found
+func (ctx *Ctx) AttestGet(devUUID uuid.UUID) (out string, err error) {
+	data, err := ioutil.ReadFile(filepath.Join(ctx.getDeviceDir(devUUID), attestFile))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+//RequestLastCallback check request by pattern from existence files with callback
+func (ctx *Ctx) RequestLastCallback(devUUID uuid.UUID, q map[string]string, handler erequest.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return erequest.RequestLast(loader, q, handler)
+}
+
+//LogAppsChecker check app logs by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
+func (ctx *Ctx) LogAppsChecker(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc, mode eapps.LogCheckerMode, timeout time.Duration) (err error) {
+	return eapps.LogChecker(ctx.getLoader(), devUUID, appUUID, q, handler, mode, timeout)
+}
+
+//LogAppsLastCallback check app logs by pattern from existence files with callback
+func (ctx *Ctx) LogAppsLastCallback(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	loader.SetAppUUID(appUUID)
+	return eapps.LogLast(loader, q, handler)
+}
+
+//LogChecker check logs by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
+func (ctx *Ctx) LogChecker(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc, mode elog.LogCheckerMode, timeout time.Duration) (err error) {
+	return elog.LogChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+//LogLastCallback check logs by pattern from existence files with callback
+func (ctx *Ctx) LogLastCallback(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return elog.LogLast(loader, q, handler)
+}
+
+//FlowLogChecker check FlowLogs by pattern from existence files with FlowLogLast and use FlowLogWatchWithTimeout with timeout for observe new files
+func (ctx *Ctx) FlowLogChecker(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc, mode eflowlog.FlowLogCheckerMode, timeout time.Duration) (err error) {
+	return eflowlog.FlowLogChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+//FlowLogLastCallback check FlowLogs by pattern from existence files with callback
+func (ctx *Ctx) FlowLogLastCallback(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return eflowlog.FlowLogLast(loader, q, handler)
+}
+
+//InfoChecker checks the information in the regular expression pattern 'query' and processes the info.ZInfoMsg found by the function 'handler' from existing files (mode=einfo.InfoExist), new files (mode=einfo.InfoNew) or any of them (mode=einfo.InfoAny) with timeout.
+func (ctx *Ctx) InfoChecker(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc, mode einfo.InfoCheckerMode, timeout time.Duration) (err error) {
+	return einfo.InfoChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+//InfoLastCallback check info by pattern from existence files with callback
+func (ctx *Ctx) InfoLastCallback(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return einfo.InfoLast(loader, q, einfo.ZInfoFind, handler)
+}
+
+//MetricChecker check metrics by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
+func (ctx *Ctx) MetricChecker(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc, mode emetric.MetricCheckerMode, timeout time.Duration) (err error) {
+	return emetric.MetricChecker(ctx.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+//MetricLastCallback check metrics by pattern from existence files with callback
+func (ctx *Ctx) MetricLastCallback(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error) {
+	var loader = ctx.getLoader()
+	loader.SetUUID(devUUID)
+	return emetric.MetricLast(loader, q, handler)
+}
+
+//OnboardRemove remove devices with onboardUUID
+func (ctx *Ctx) OnboardRemove(onboardUUID string) (err error) {
+	devUUID, err := ctx.DeviceGetByOnboardUUID(onboardUUID)
+	if err != nil {
+		return err
+	}
+	return ctx.DeviceRemove(devUUID)
+}
+
+//DeviceRemove remove device by devUUID
+func (ctx *Ctx) DeviceRemove(devUUID uuid.UUID) (err error) {
+	return os.RemoveAll(ctx.getDeviceDir(devUUID))
+}
+
+//DeviceGetOnboard get device onboardUUID for devUUID
+func (ctx *Ctx) DeviceGetOnboard(devUUID uuid.UUID) (onboardUUID uuid.UUID, err error) {
+	devCert, err := ctx.readDeviceCert(devUUID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	cert, err := utils.ParseFirstCertFromBlock(devCert.Onboard)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.FromString(cert.Subject.CommonName)
+}
+
+//DeviceGetByOnboard try to get device by onboard eveCert
+func (ctx *Ctx) DeviceGetByOnboard(eveCert string) (devUUID uuid.UUID, err error) {
+	b, err := ioutil.ReadFile(eveCert)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error reading cert file %s: %v", eveCert, err)
+	}
+	cert, err := utils.ParseFirstCertFromBlock(b)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return ctx.DeviceGetByOnboardUUID(cert.Subject.CommonName)
+}
+
+//DeviceGetByOnboardUUID try to get device by onboard uuid
+func (ctx *Ctx) DeviceGetByOnboardUUID(onboardUUID string) (devUUID uuid.UUID, err error) {
+	devIDs, err := ctx.DeviceList(types.RegisteredDeviceFilter)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, devID := range devIDs {
+		devUUID, err := uuid.FromString(devID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if id, err := ctx.DeviceGetOnboard(devUUID); err == nil && id.String() == onboardUUID {
+			return devUUID, nil
+		}
+	}
+	return uuid.Nil, fmt.Errorf("no device found")
+}
+
+//GetDeviceCert gets deviceCert contains certificates and serial
+func (ctx *Ctx) GetDeviceCert(device *device.Ctx) (*types.DeviceCert, error) {
+	return ctx.readDeviceCert(device.GetID())
+}
+
+//UploadDeviceCert upload deviceCert into directory
+func (ctx *Ctx) UploadDeviceCert(deviceCert types.DeviceCert) error {
+	cert, err := utils.ParseFirstCertFromBlock(deviceCert.Cert)
+	if err != nil {
+		return fmt.Errorf("cannot parse device cert: %s", err)
+	}
+	devUUID, err := uuid.FromString(cert.Subject.CommonName)
+	if err != nil {
+		return err
+	}
+	return ctx.writeDeviceCert(devUUID, deviceCert)
+}
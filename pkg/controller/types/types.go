@@ -152,3 +152,63 @@ type DeviceCert struct {
 type Zcerts struct {
 	Certs []*certs.ZCert `json:"certs,omitempty"` // EVE device certs
 }
+
+//TimeRange defines interval of timestamps of objects to process
+//zero Since or Until means that interval is not limited from that side
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+//IsEmpty returns true if TimeRange not limited
+func (timeRange TimeRange) IsEmpty() bool {
+	return timeRange.Since.IsZero() && timeRange.Until.IsZero()
+}
+
+//Contains returns true if t is inside TimeRange
+func (timeRange TimeRange) Contains(t time.Time) bool {
+	if !timeRange.Since.IsZero() && t.Before(timeRange.Since) {
+		return false
+	}
+	if !timeRange.Until.IsZero() && t.After(timeRange.Until) {
+		return false
+	}
+	return true
+}
+
+//ParseTime parses absolute time (RFC3339, "2006-01-02 15:04:05" or "2006-01-02")
+//or duration relative to now (for example, 10m or 2h30m means 10 minutes or 2.5 hours ago)
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse time %q: use RFC3339, date or duration (e.g. 10m)", value)
+}
+
+//ParseTimeRange returns TimeRange from since and until values parsed with ParseTime
+func ParseTimeRange(since, until string) (TimeRange, error) {
+	now := time.Now()
+	var timeRange TimeRange
+	var err error
+	if timeRange.Since, err = ParseTime(since, now); err != nil {
+		return timeRange, err
+	}
+	if timeRange.Until, err = ParseTime(until, now); err != nil {
+		return timeRange, err
+	}
+	if !timeRange.Since.IsZero() && !timeRange.Until.IsZero() && timeRange.Until.Before(timeRange.Since) {
+		return timeRange, fmt.Errorf("until (%s) is before since (%s)", timeRange.Until, timeRange.Since)
+	}
+	return timeRange, nil
+}
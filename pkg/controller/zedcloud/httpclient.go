@@ -0,0 +1,117 @@
+package zedcloud
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+//tokenTransport adds bearer token into every request
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+//RoundTrip implements http.RoundTripper
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.token != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.token))
+	}
+	return t.base.RoundTrip(req)
+}
+
+// http client with correct config
+func (zedcloud *Ctx) getHTTPClient() *http.Client {
+	tlsConfig := &tls.Config{}
+	if zedcloud.serverCA != "" {
+		caCert, err := ioutil.ReadFile(zedcloud.serverCA)
+		if err != nil {
+			log.Fatalf("unable to read server CA file at %s: %v", zedcloud.serverCA, err)
+		}
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(caCert)
+		tlsConfig.RootCAs = caCertPool
+	}
+	if zedcloud.insecureTLS {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	var client = &http.Client{
+		Timeout: time.Second * 10,
+		Transport: &tokenTransport{
+			token: zedcloud.token,
+			base: &http.Transport{
+				TLSClientConfig:       tlsConfig,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: 10 * time.Second,
+			},
+		},
+	}
+	return client
+}
+
+func (zedcloud *Ctx) doRequest(method, path string, obj []byte, mimeType string) (out []byte, err error) {
+	u, err := utils.ResolveURL(zedcloud.url, path)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing URL: %v", err)
+	}
+	client := zedcloud.getHTTPClient()
+	var body *bytes.Buffer
+	if obj != nil {
+		body = bytes.NewBuffer(obj)
+	} else {
+		body = &bytes.Buffer{}
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create new http request: %v", err)
+	}
+	if mimeType != "" {
+		req.Header.Set("Content-Type", mimeType)
+	}
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send request: %v", err)
+	}
+	defer response.Body.Close()
+	buf, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read data from URL %s: %v", u, err)
+	}
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent:
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("%s %s: status code %d: %s", method, u, response.StatusCode, string(buf))
+	}
+}
+
+func (zedcloud *Ctx) deleteObj(path string) (err error) {
+	_, err = zedcloud.doRequest(http.MethodDelete, path, nil, "")
+	return err
+}
+
+func (zedcloud *Ctx) getObj(path string) (out string, err error) {
+	buf, err := zedcloud.doRequest(http.MethodGet, path, nil, "")
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func (zedcloud *Ctx) postObj(path string, obj []byte, mimeType string) (err error) {
+	_, err = zedcloud.doRequest(http.MethodPost, path, obj, mimeType)
+	return err
+}
+
+func (zedcloud *Ctx) putObj(path string, obj []byte) (err error) {
+	_, err = zedcloud.doRequest(http.MethodPut, path, obj, "application/json")
+	return err
+}
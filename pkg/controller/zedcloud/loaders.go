@@ -0,0 +1,48 @@
+package zedcloud
+
+import (
+	"path"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+//getDeviceURL return url of object with name for devUUID
+func (zedcloud *Ctx) getDeviceURL(devUUID uuid.UUID, name ...string) string {
+	resURL, err := utils.ResolveURL(zedcloud.url, path.Join(append([]string{devicesPath, "id", devUUID.String()}, name...)...))
+	if err != nil {
+		log.Fatalf("ResolveURL: %s", err)
+	}
+	return resURL
+}
+
+//getLogsURL return logs url for devUUID
+func (zedcloud *Ctx) getLogsURL(devUUID uuid.UUID) string {
+	return zedcloud.getDeviceURL(devUUID, "logs")
+}
+
+//getAppsLogsURL return app logs url for devUUID and appUUID
+func (zedcloud *Ctx) getAppsLogsURL(devUUID uuid.UUID, appUUID uuid.UUID) string {
+	return zedcloud.getDeviceURL(devUUID, "apps", appUUID.String(), "logs")
+}
+
+//getInfoURL return info url for devUUID
+func (zedcloud *Ctx) getInfoURL(devUUID uuid.UUID) string {
+	return zedcloud.getDeviceURL(devUUID, "info")
+}
+
+//getMetricsURL return metrics url for devUUID
+func (zedcloud *Ctx) getMetricsURL(devUUID uuid.UUID) string {
+	return zedcloud.getDeviceURL(devUUID, "metrics")
+}
+
+//getFlowLogURL return flowlog url for devUUID
+func (zedcloud *Ctx) getFlowLogURL(devUUID uuid.UUID) string {
+	return zedcloud.getDeviceURL(devUUID, "flowlogs")
+}
+
+//getRequestURL return request url for devUUID
+func (zedcloud *Ctx) getRequestURL(devUUID uuid.UUID) string {
+	return zedcloud.getDeviceURL(devUUID, "requests")
+}
@@ -0,0 +1,352 @@
+//Package zedcloud provides implementation of controller.Controller
+//for controllers with zedcloud-style REST API.
+package zedcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/eapps"
+	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/erequest"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	devicesPath = "/api/v1/devices"
+	onboardPath = "/api/v1/onboard"
+
+	//RunStateUnprovisioned is a state of device not onboarded yet
+	RunStateUnprovisioned = "RUN_STATE_UNPROVISIONED"
+)
+
+//Ctx stores controller settings
+type Ctx struct {
+	dir         string
+	url         string
+	token       string
+	serverCA    string
+	insecureTLS bool
+	timeRange   types.TimeRange
+}
+
+//DeviceStatus is a device record of zedcloud-style API
+type DeviceStatus struct {
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+	SerialNo string `json:"serialno,omitempty"`
+	RunState string `json:"runState,omitempty"`
+	Onboard  []byte `json:"onboardCert,omitempty"`
+	Cert     []byte `json:"deviceCert,omitempty"`
+}
+
+//DeviceStatusList is a list of devices returned by zedcloud-style API
+type DeviceStatusList struct {
+	List []*DeviceStatus `json:"list"`
+}
+
+//InitWithVars use variables from viper for init controller
+func (zedcloud *Ctx) InitWithVars(vars *utils.ConfigVars) error {
+	zedcloud.dir = vars.AdamDir
+	zedcloud.url = vars.ZedcloudURL
+	if zedcloud.url == "" {
+		return fmt.Errorf("zedcloud url is not defined")
+	}
+	if !strings.Contains(zedcloud.url, "://") {
+		zedcloud.url = fmt.Sprintf("https://%s", zedcloud.url)
+	}
+	zedcloud.token = vars.ZedcloudToken
+	zedcloud.serverCA = vars.ZedcloudCA
+	zedcloud.insecureTLS = vars.ZedcloudInsecure
+	return nil
+}
+
+//GetDir return dir
+func (zedcloud *Ctx) GetDir() (dir string) {
+	return zedcloud.dir
+}
+
+//getLoader return loader object for zedcloud
+func (zedcloud *Ctx) getLoader() loaders.Loader {
+	urlGetters := types.URLGetters{
+		URLLogs:    zedcloud.getLogsURL,
+		URLInfo:    zedcloud.getInfoURL,
+		URLMetrics: zedcloud.getMetricsURL,
+		URLFlowLog: zedcloud.getFlowLogURL,
+		URLRequest: zedcloud.getRequestURL,
+		URLApps:    zedcloud.getAppsLogsURL,
+	}
+	loader := loaders.NewRemoteLoader(zedcloud.getHTTPClient, urlGetters)
+	loader.SetTimeRange(zedcloud.timeRange)
+	return loader
+}
+
+//SetTimeRange set range of timestamps for logs, info, metrics and flow logs to process
+func (zedcloud *Ctx) SetTimeRange(timeRange types.TimeRange) {
+	zedcloud.timeRange = timeRange
+}
+
+func (zedcloud *Ctx) getDevices() ([]*DeviceStatus, error) {
+	out, err := zedcloud.getObj(devicesPath)
+	if err != nil {
+		return nil, err
+	}
+	var devices DeviceStatusList
+	if err := json.Unmarshal([]byte(out), &devices); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal device list: %s", err)
+	}
+	return devices.List, nil
+}
+
+func (zedcloud *Ctx) getDevice(devUUID uuid.UUID) (*DeviceStatus, error) {
+	out, err := zedcloud.getObj(path.Join(devicesPath, "id", devUUID.String()))
+	if err != nil {
+		return nil, err
+	}
+	var dev DeviceStatus
+	if err := json.Unmarshal([]byte(out), &dev); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal device: %s", err)
+	}
+	return &dev, nil
+}
+
+//Register device in zedcloud
+func (zedcloud *Ctx) Register(device *device.Ctx) error {
+	b, err := ioutil.ReadFile(device.GetOnboardKey())
+	switch {
+	case err != nil && os.IsNotExist(err):
+		log.Printf("cert file %s does not exist", device.GetOnboardKey())
+		return err
+	case err != nil:
+		log.Printf("error reading cert file %s: %v", device.GetOnboardKey(), err)
+		return err
+	}
+	objToSend := DeviceStatus{
+		Name:     device.GetSerial(),
+		SerialNo: device.GetSerial(),
+		Onboard:  b,
+	}
+	body, err := json.Marshal(objToSend)
+	if err != nil {
+		return fmt.Errorf("error encoding json: %v", err)
+	}
+	return zedcloud.postObj(devicesPath, body, "application/json")
+}
+
+//DeviceList return device list
+func (zedcloud *Ctx) DeviceList(filter types.DeviceStateFilter) (out []string, err error) {
+	devices, err := zedcloud.getDevices()
+	if err != nil {
+		return nil, err
+	}
+	out = []string{}
+	for _, dev := range devices {
+		registered := dev.RunState != RunStateUnprovisioned
+		switch filter {
+		case types.RegisteredDeviceFilter:
+			if !registered {
+				continue
+			}
+		case types.NotRegisteredDeviceFilter:
+			if registered {
+				continue
+			}
+		}
+		out = append(out, dev.ID)
+	}
+	return out, nil
+}
+
+//ConfigSet set config for devID
+func (zedcloud *Ctx) ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error) {
+	return zedcloud.putObj(path.Join(devicesPath, "id", devUUID.String(), "config"), devConfig)
+}
+
+//ConfigGet get config for devID
+func (zedcloud *Ctx) ConfigGet(devUUID uuid.UUID) (out string, err error) {
+	return zedcloud.getObj(path.Join(devicesPath, "id", devUUID.String(), "config"))
+}
+
+//CertsGet get attest certs for devID
+func (zedcloud *Ctx) CertsGet(devUUID uuid.UUID) (out string, err error) {
+	return zedcloud.getObj(path.Join(devicesPath, "id", devUUID.String(), "certs"))
+}
+
+//AttestGet get last attestation request with quote for devID
+//zedcloud does not provide quotes of device
+func (zedcloud *Ctx) AttestGet(devUUID uuid.UUID) (out string, err error) {
+	return "", fmt.Errorf("quotes of device are not available in zedcloud")
+}
+
+//RequestLastCallback check request by pattern from existence files with callback
+func (zedcloud *Ctx) RequestLastCallback(devUUID uuid.UUID, q map[string]string, handler erequest.HandlerFunc) (err error) {
+	var loader = zedcloud.getLoader()
+	loader.SetUUID(devUUID)
+	return erequest.RequestLast(loader, q, handler)
+}
+
+//LogAppsChecker check app logs by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
+func (zedcloud *Ctx) LogAppsChecker(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc, mode eapps.LogCheckerMode, timeout time.Duration) (err error) {
+	return eapps.LogChecker(zedcloud.getLoader(), devUUID, appUUID, q, handler, mode, timeout)
+}
+
+//LogAppsLastCallback check app logs by pattern from existence files with callback
+func (zedcloud *Ctx) LogAppsLastCallback(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc) (err error) {
+	var loader = zedcloud.getLoader()
+	loader.SetUUID(devUUID)
+	loader.SetAppUUID(appUUID)
+	return eapps.LogLast(loader, q, handler)
+}
+
+//LogChecker check logs by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
+func (zedcloud *Ctx) LogChecker(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc, mode elog.LogCheckerMode, timeout time.Duration) (err error) {
+	return elog.LogChecker(zedcloud.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+//LogLastCallback check logs by pattern from existence files with callback
+func (zedcloud *Ctx) LogLastCallback(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc) (err error) {
+	var loader = zedcloud.getLoader()
+	loader.SetUUID(devUUID)
+	return elog.LogLast(loader, q, handler)
+}
+
+//FlowLogChecker check FlowLogs by pattern from existence files with FlowLogLast and use FlowLogWatchWithTimeout with timeout for observe new files
+func (zedcloud *Ctx) FlowLogChecker(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc, mode eflowlog.FlowLogCheckerMode, timeout time.Duration) (err error) {
+	return eflowlog.FlowLogChecker(zedcloud.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+//FlowLogLastCallback check FlowLogs by pattern from existence files with callback
+func (zedcloud *Ctx) FlowLogLastCallback(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc) (err error) {
+	var loader = zedcloud.getLoader()
+	loader.SetUUID(devUUID)
+	return eflowlog.FlowLogLast(loader, q, handler)
+}
+
+//InfoChecker checks the information in the regular expression pattern 'query' and processes the info.ZInfoMsg found by the function 'handler' from existing files (mode=einfo.InfoExist), new files (mode=einfo.InfoNew) or any of them (mode=einfo.InfoAny) with timeout.
+func (zedcloud *Ctx) InfoChecker(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc, mode einfo.InfoCheckerMode, timeout time.Duration) (err error) {
+	return einfo.InfoChecker(zedcloud.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+//InfoLastCallback check info by pattern from existence files with callback
+func (zedcloud *Ctx) InfoLastCallback(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error) {
+	var loader = zedcloud.getLoader()
+	loader.SetUUID(devUUID)
+	return einfo.InfoLast(loader, q, einfo.ZInfoFind, handler)
+}
+
+//MetricChecker check metrics by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
+func (zedcloud *Ctx) MetricChecker(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc, mode emetric.MetricCheckerMode, timeout time.Duration) (err error) {
+	return emetric.MetricChecker(zedcloud.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+//MetricLastCallback check metrics by pattern from existence files with callback
+func (zedcloud *Ctx) MetricLastCallback(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error) {
+	var loader = zedcloud.getLoader()
+	loader.SetUUID(devUUID)
+	return emetric.MetricLast(loader, q, handler)
+}
+
+//OnboardRemove remove onboard by onboardUUID
+func (zedcloud *Ctx) OnboardRemove(onboardUUID string) (err error) {
+	return zedcloud.deleteObj(path.Join(onboardPath, onboardUUID))
+}
+
+//DeviceRemove remove device by devUUID
+func (zedcloud *Ctx) DeviceRemove(devUUID uuid.UUID) (err error) {
+	return zedcloud.deleteObj(path.Join(devicesPath, "id", devUUID.String()))
+}
+
+//DeviceGetOnboard get device onboardUUID for devUUID
+func (zedcloud *Ctx) DeviceGetOnboard(devUUID uuid.UUID) (onboardUUID uuid.UUID, err error) {
+	dev, err := zedcloud.getDevice(devUUID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	cert, err := utils.ParseFirstCertFromBlock(dev.Onboard)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.FromString(cert.Subject.CommonName)
+}
+
+//DeviceGetByOnboard try to get device by onboard eveCert
+func (zedcloud *Ctx) DeviceGetByOnboard(eveCert string) (devUUID uuid.UUID, err error) {
+	b, err := ioutil.ReadFile(eveCert)
+	switch {
+	case err != nil && os.IsNotExist(err):
+		log.Printf("cert file %s does not exist", eveCert)
+		return uuid.Nil, err
+	case err != nil:
+		log.Printf("error reading cert file %s: %v", eveCert, err)
+		return uuid.Nil, err
+	}
+	cert, err := utils.ParseFirstCertFromBlock(b)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	uuidToFound, err := uuid.FromString(cert.Subject.CommonName)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return zedcloud.DeviceGetByOnboardUUID(uuidToFound.String())
+}
+
+//DeviceGetByOnboardUUID try to get device by onboard uuid
+func (zedcloud *Ctx) DeviceGetByOnboardUUID(onboardUUID string) (devUUID uuid.UUID, err error) {
+	devices, err := zedcloud.getDevices()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, dev := range devices {
+		if dev.RunState == RunStateUnprovisioned {
+			continue
+		}
+		cert, err := utils.ParseFirstCertFromBlock(dev.Onboard)
+		if err != nil {
+			continue
+		}
+		if cert.Subject.CommonName == onboardUUID {
+			return uuid.FromString(dev.ID)
+		}
+	}
+	return uuid.Nil, fmt.Errorf("no device found")
+}
+
+//GetDeviceCert gets deviceCert contains certificates and serial
+func (zedcloud *Ctx) GetDeviceCert(device *device.Ctx) (*types.DeviceCert, error) {
+	dev, err := zedcloud.getDevice(device.GetID())
+	if err != nil {
+		return nil, err
+	}
+	return &types.DeviceCert{
+		Cert:    dev.Cert,
+		Onboard: dev.Onboard,
+		Serial:  dev.SerialNo,
+	}, nil
+}
+
+//UploadDeviceCert upload deviceCert into zedcloud
+func (zedcloud *Ctx) UploadDeviceCert(deviceCert types.DeviceCert) error {
+	body, err := json.Marshal(DeviceStatus{
+		SerialNo: deviceCert.Serial,
+		Onboard:  deviceCert.Onboard,
+		Cert:     deviceCert.Cert,
+	})
+	if err != nil {
+		return err
+	}
+	return zedcloud.postObj(path.Join(devicesPath, "certs"), body, "application/json")
+}
@@ -31,6 +31,7 @@ const (
 
 	DefaultConfigEnv   = "EDEN_CONFIG"    //default env for set config
 	DefaultTestArgsEnv = "EDEN_TEST_ARGS" //default env for test arguments
+	DefaultDeviceEnv   = "EDEN_DEVICE"    //default env for select device
 )
 
 //domains, ips, ports
@@ -47,6 +48,8 @@ const (
 	DefaultRedisPort       = 6379
 	DefaultAdamPort        = 3333
 	DefaultRegistryPort    = 5000
+	DefaultObjectStorePort = 9000
+	DefaultAzuritePort     = 10000
 
 	//tags, versions, repos
 	DefaultEVETag               = "6.8.0" //DefaultEVETag tag for EVE image
@@ -59,6 +62,10 @@ const (
 	DefaultRedisContainerRef    = "redis"
 	DefaultRegistryContainerRef = "library/registry"
 	DefaultProcContainerRef     = "itmoeve/eden-processing"
+	DefaultMinIOTag             = "RELEASE.2021-06-17T00-10-46Z"
+	DefaultMinIOContainerRef    = "minio/minio"
+	DefaultAzuriteTag           = "3.13.1"
+	DefaultAzuriteContainerRef  = "mcr.microsoft.com/azure-storage/azurite"
 	DefaultEveRepo              = "https://github.com/lf-edge/eve.git"
 	DefaultEveRegistry          = "lfedge"
 	DefaultRegistry             = "docker.io"
@@ -67,15 +74,38 @@ const (
 	DefaultSFTPPassword  = "password"
 	DefaultSFTPDirPrefix = "/eserver/run"
 
+	DefaultObjectStoreRegion    = "us-east-1"
+	DefaultObjectStoreAccessKey = "minioadmin"
+	DefaultObjectStoreSecretKey = "minioadmin"
+	//DefaultAzureAccount and DefaultAzureKey are well-known credentials of Azurite
+	DefaultAzureAccount = "devstoreaccount1"
+	DefaultAzureKey     = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+
 	DefaultRedisPasswordFile = "redis.pass"
 
 	DefaultEServerTag          = "1.5"
 	DefaultEServerContainerRef = "lfedge/eden-http-server"
 
+	//DefaultStreamIDSlack is time added to the end of range of redis stream IDs
+	//objects from EVE are stored into stream later than their timestamps
+	DefaultStreamIDSlack = 10 * time.Minute
+
+	//DefaultAppUpdateTimeout is time to wait for app to run after update before rollback
+	DefaultAppUpdateTimeout = 10 * time.Minute
+
+	//DefaultAppSSHKey is private key to access apps with eden pod exec, relative to eden.tests directory
+	DefaultAppSSHKey = "eclient/image/cert/id_rsa"
+	//DefaultAppSSHUser is user to access apps with eden pod exec
+	DefaultAppSSHUser = "root"
+
+	//DefaultCertsExpiryWarning is time before expiration of certificate to warn about it
+	DefaultCertsExpiryWarning = 30 * 24 * time.Hour
+
 	//DefaultRepeatCount is repeat count for requests
 	DefaultRepeatCount = 20
 	//DefaultRepeatTimeout is time wait for next attempt
 	DefaultRepeatTimeout         = 5 * time.Second
+	DefaultSSHConnectTimeout     = 5 * time.Second //timeout of connection of built-in ssh client
 	DefaultUUID                  = "1"
 	DefaultFileToSave            = "./test.tar"
 	DefaultIsLocal               = false
@@ -90,11 +120,13 @@ const (
 	DefaultTestScenario          = ""
 	DefaultRootFSVersionPattern  = `^.*-(xen|kvm|acrn|rpi|rpi-xen|rpi-kvm)-(amd64|arm64)$`
 	DefaultControllerModePattern = `^(?P<Type>(file|proto|adam|zedcloud)):\/\/(?P<URL>.*)$`
-	DefaultPodLinkPattern        = `^(?P<TYPE>(oci|docker|http[s]{0,1}|file|directory)):\/\/(?P<TAG>[^:]+):*(?P<VERSION>.*)$`
+	DefaultPodLinkPattern        = `^(?P<TYPE>(oci|docker|http[s]{0,1}|file|directory|s3|azure)):\/\/(?P<TAG>[^:]+):*(?P<VERSION>.*)$`
 	DefaultRedisContainerName    = "eden_redis"
 	DefaultAdamContainerName     = "eden_adam"
 	DefaultRegistryContainerName = "eden_registry"
 	DefaultEServerContainerName  = "eden_eserver"
+	DefaultMinIOContainerName    = "eden_minio"
+	DefaultAzuriteContainerName  = "eden_azurite"
 	DefaultDockerNetworkName     = "eden_network"
 	DefaultLogLevelToPrint       = log.InfoLevel
 	DefaultX509Country           = "RU"
@@ -137,6 +169,21 @@ const (
 
 	DefaultEVERemote = false
 
+	DefaultEVETPM       = false            //run software TPM for EVE in qemu
+	DefaultSwtpmDir     = "swtpm"          //directory for state of software TPM alongside EVE image
+	DefaultSwtpmTimeout = 10 * time.Second //time to wait for control socket of software TPM
+
+	DefaultEVEInstancesDir       = "instances" //directory for additional instances of EVE alongside EVE image
+	DefaultEVEInstanceConfigDir  = "config"    //directory for config partition inside directory of instance
+	DefaultEVEInstancePortOffset = 100         //offset of ports of instance multiplied by its index
+
+	DefaultEVENetMode    = "user"            //networking of EVE in qemu: user (slirp with hostfwd) or bridge (TAP devices in LAN)
+	DefaultLANBridge     = "eden0"           //bridge of virtual LAN for EVE in qemu
+	DefaultLANSubnet     = "192.168.94.0/24" //subnet of virtual LAN, the first address is assigned to bridge
+	DefaultLANLeaseTime  = time.Hour         //time of DHCP lease in virtual LAN
+	DefaultLANDistPrefix = "lan"             //prefix of directory with files of virtual LAN inside eden directory
+	DefaultLANTAPPrefix  = "eden"            //prefix of TAP devices for EVE in qemu
+
 	DefaultEVEImageSize = 8192
 
 	DefaultAppMem = 1024000
@@ -223,6 +270,12 @@ var (
 		"eve.devmodelfile":      "devmodel-file",
 		"eve.telnet-port":       "eve-telnet-port",
 		"eve.qemu-monitor-port": "qemu-monitor-port",
+		"eve.tpm":               "eve-tpm",
+		"eve.net-mode":          "eve-net-mode",
+
+		"lan.bridge": "lan-bridge",
+		"lan.subnet": "lan-subnet",
+		"lan.dns":    "lan-dns",
 
 		"eden.images.dist":   "image-dist",
 		"eden.images.docker": "docker-yml",
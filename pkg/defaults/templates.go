@@ -71,6 +71,12 @@ eve:
     #EVE acceleration (set to false if you have problems with qemu)
     accel: {{parse "eve.accel"}}
 
+    #run software TPM (swtpm) for EVE in qemu
+    tpm: {{parse "eve.tpm"}}
+
+    #networking of EVE in qemu: user (slirp with hostfwd) or bridge (TAP devices in virtual LAN, see lan section)
+    net-mode: '{{parse "eve.net-mode"}}'
+
     #variant of hypervisor of EVE (kvm/xen)
     hv: '{{parse "eve.hv"}}'
 
@@ -216,6 +222,46 @@ registry:
 
     # dist path to store registry data
     dist: '{{parse "registry.dist"}}'
+
+#virtual LAN for EVE in qemu with net-mode bridge
+lan:
+    #bridge to attach EVE to
+    bridge: '{{parse "lan.bridge"}}'
+
+    #subnet of LAN, the first address is assigned to bridge and used as gateway and DNS server
+    subnet: '{{parse "lan.subnet"}}'
+
+    #upstream DNS server in host:port format (nameserver of host if empty)
+    dns: '{{parse "lan.dns"}}'
+
+#object storage for s3:// (S3 compatible) and azure:// (Azure blob) images
+objectstore:
+    #endpoint of object storage for EDEN access
+    endpoint: '{{parse "objectstore.endpoint"}}'
+
+    #endpoint of object storage for EVE access
+    eve-endpoint: '{{parse "objectstore.eve-endpoint"}}'
+
+    #region of object storage
+    region: '{{parse "objectstore.region"}}'
+
+    #access key
+    access-key: '{{parse "objectstore.access-key"}}'
+
+    #secret key
+    secret-key: '{{parse "objectstore.secret-key"}}'
+
+    #endpoint of Azure blob storage with account path for EDEN access
+    azure-endpoint: '{{parse "objectstore.azure-endpoint"}}'
+
+    #endpoint of Azure blob storage with account path for EVE access
+    azure-eve-endpoint: '{{parse "objectstore.azure-eve-endpoint"}}'
+
+    #name of Azure storage account
+    azure-account: '{{parse "objectstore.azure-account"}}'
+
+    #key of Azure storage account
+    azure-key: '{{parse "objectstore.azure-key"}}'
 `
 
 //DefaultQemuTemplate is configuration template for qemu
@@ -0,0 +1,249 @@
+package eden
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/certs"
+	"github.com/lf-edge/eve/api/go/config"
+	log "github.com/sirupsen/logrus"
+)
+
+//ControllerCerts contains names of certificates of controller, which can be rotated
+//the key is stored in file with name of certificate and '-key' suffix
+var ControllerCerts = []string{"server", "signing", "encrypt"}
+
+//CertInfo contains information about certificate
+type CertInfo struct {
+	File      string
+	Subject   string
+	Issuer    string
+	DNSNames  []string
+	IPs       []string
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+//ExpiresIn returns duration till expiration of certificate
+func (info *CertInfo) ExpiresIn() time.Duration {
+	return time.Until(info.NotAfter)
+}
+
+//ControllerCertPaths returns paths to certificate and key of controller with provided name in global certs directory
+func ControllerCertPaths(name string) (certPath, keyPath string, err error) {
+	edenHome, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", "", err
+	}
+	globalCertsDir := filepath.Join(edenHome, defaults.DefaultCertsDist)
+	return filepath.Join(globalCertsDir, name+".pem"), filepath.Join(globalCertsDir, name+"-key.pem"), nil
+}
+
+//OldCertPath returns path of previous version of file saved during rotation
+func OldCertPath(path string) string {
+	return path + ".old"
+}
+
+//RotateCerts generates new keys and certificates of controller with provided names signed by root CA
+//the previous versions are saved with OldCertPath names
+func RotateCerts(domain, ip, eveIP string, names ...string) error {
+	caCertPath, caKeyPath, err := ControllerCertPaths("root-certificate")
+	if err != nil {
+		return fmt.Errorf("RotateCerts: %s", err)
+	}
+	rootCert, err := utils.ParseCertificate(caCertPath)
+	if err != nil {
+		return fmt.Errorf("RotateCerts: cannot parse certificate from %s: %s", caCertPath, err)
+	}
+	rootKey, err := utils.ParsePrivateKey(caKeyPath)
+	if err != nil {
+		return fmt.Errorf("RotateCerts: cannot parse key from %s: %s", caKeyPath, err)
+	}
+	ips := []net.IP{net.ParseIP(ip), net.ParseIP(eveIP), net.ParseIP("127.0.0.1")}
+	for _, name := range names {
+		certPath, keyPath, err := ControllerCertPaths(name)
+		if err != nil {
+			return fmt.Errorf("RotateCerts: %s", err)
+		}
+		for _, path := range []string{certPath, keyPath} {
+			if _, err := os.Stat(path); err == nil {
+				if err = utils.CopyFile(path, OldCertPath(path)); err != nil {
+					return fmt.Errorf("RotateCerts: cannot save previous version of %s: %s", path, err)
+				}
+			}
+		}
+		//use random serial to distinguish certificates after rotation
+		serial, err := utils.GenSerial()
+		if err != nil {
+			return fmt.Errorf("RotateCerts: %s", err)
+		}
+		log.Debugf("generating Adam %s cert and key", name)
+		cert, key := utils.GenServerCertElliptic(rootCert, rootKey, serial, ips, []string{domain}, domain)
+		if err := utils.WriteToFiles(cert, key, certPath, keyPath); err != nil {
+			return fmt.Errorf("RotateCerts %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+//getDeviceECDHCert returns DEVICE_ECDH_EXCHANGE certificate of dev from controller
+func getDeviceECDHCert(ctrl controller.Cloud, dev *device.Ctx) ([]byte, error) {
+	attestData, err := ctrl.CertsGet(dev.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("cannot get attestation certificates from cloud for %s: %s", dev.GetID(), err)
+	}
+	req := &types.Zcerts{}
+	if err := json.Unmarshal([]byte(attestData), req); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal attest: %v", err)
+	}
+	for _, c := range req.Certs {
+		if c.Type == certs.ZCertType_CERT_TYPE_DEVICE_ECDH_EXCHANGE {
+			return c.Cert, nil
+		}
+	}
+	return nil, fmt.Errorf("no DEVICE_ECDH_EXCHANGE certificate")
+}
+
+//ReencryptCipherContexts re-encrypts cipher blocks of dev, encrypted with controller certificate oldCert,
+//with controller certificate newCert and replaces cipher contexts of dev
+//it returns count of re-encrypted cipher blocks
+func ReencryptCipherContexts(ctrl controller.Cloud, dev *device.Ctx, oldCert, oldKey, newCert, newKey string) (int, error) {
+	if len(dev.GetCipherContexts()) == 0 {
+		return 0, nil
+	}
+	devCert, err := getDeviceECDHCert(ctrl, dev)
+	if err != nil {
+		return 0, err
+	}
+	oldConfig, err := utils.GetCommonCryptoConfig(devCert, oldCert, oldKey)
+	if err != nil {
+		return 0, fmt.Errorf("GetCommonCryptoConfig: %v", err)
+	}
+	newConfig, err := utils.GetCommonCryptoConfig(devCert, newCert, newKey)
+	if err != nil {
+		return 0, fmt.Errorf("GetCommonCryptoConfig: %v", err)
+	}
+	newCtx, err := utils.CreateCipherCtx(newConfig)
+	if err != nil {
+		return 0, fmt.Errorf("CreateCipherCtx: %v", err)
+	}
+	replaced := make(map[string]bool)
+	contexts := []*config.CipherContext{newCtx}
+	for _, c := range dev.GetCipherContexts() {
+		if c.ContextId == newCtx.ContextId {
+			continue
+		}
+		if bytes.Equal(c.DeviceCertHash, oldConfig.DevCertHash[:16]) && bytes.Equal(c.ControllerCertHash, oldConfig.ControllerEncCertHash[:16]) {
+			replaced[c.ContextId] = true
+			continue
+		}
+		contexts = append(contexts, c)
+	}
+	if len(replaced) == 0 {
+		return 0, nil
+	}
+	count := 0
+	reencrypt := func(cipherBlock **config.CipherBlock) error {
+		if *cipherBlock == nil || !replaced[(*cipherBlock).CipherContextId] {
+			return nil
+		}
+		encBlock, err := utils.DecryptCipherBlock(*cipherBlock, oldConfig)
+		if err != nil {
+			return err
+		}
+		if *cipherBlock, err = utils.CryptoConfigWrapper(encBlock, newConfig, newCtx); err != nil {
+			return err
+		}
+		count++
+		return nil
+	}
+	for _, appID := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(appID)
+		if err != nil {
+			return 0, err
+		}
+		if err = reencrypt(&app.CipherData); err != nil {
+			return 0, fmt.Errorf("app %s: %s", app.Displayname, err)
+		}
+	}
+	for _, ds := range ctrl.ListDataStore() {
+		if err = reencrypt(&ds.CipherData); err != nil {
+			return 0, fmt.Errorf("datastore %s: %s", ds.Fqdn, err)
+		}
+	}
+	for _, netID := range dev.GetNetworks() {
+		network, err := ctrl.GetNetworkConfig(netID)
+		if err != nil {
+			return 0, err
+		}
+		if network.Wireless == nil {
+			continue
+		}
+		for _, wifi := range network.Wireless.WifiCfg {
+			if err = reencrypt(&wifi.CipherData); err != nil {
+				return 0, fmt.Errorf("wifi %s: %s", wifi.WifiSSID, err)
+			}
+		}
+	}
+	dev.SetCipherContexts(contexts)
+	return count, nil
+}
+
+//InspectCerts returns information about certificates inside pem files in dirs
+//files without certificates (keys for example) are skipped
+func InspectCerts(dirs ...string) ([]*CertInfo, error) {
+	var result []*CertInfo
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			cert, err := utils.ParseFirstCertFromBlock(data)
+			if err != nil {
+				continue
+			}
+			result = append(result, certInfo(file, cert))
+		}
+	}
+	return result, nil
+}
+
+func certInfo(file string, cert *x509.Certificate) *CertInfo {
+	info := &CertInfo{
+		File:      file,
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		DNSNames:  cert.DNSNames,
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPs = append(info.IPs, ip.String())
+	}
+	return info
+}
+
+//SANs returns subject alternative names of certificate
+func (info *CertInfo) SANs() string {
+	return strings.Join(append(append([]string{}, info.DNSNames...), info.IPs...), ",")
+}
@@ -761,8 +761,14 @@ func CleanContext(eveDist, certsDist, imagesDist, evePID, eveUUID, vmName string
 			} else {
 				log.Infof("EVE stopped")
 			}
+			if err := StopEVEInstances(imagesDist); err != nil {
+				log.Infof("cannot stop EVE instances: %s", err)
+			}
 		}
 	}
+	if err := removeEVEInstances(imagesDist); err != nil {
+		log.Infof("cannot remove EVE instances from controller: %s", err)
+	}
 	if _, err = os.Stat(eveDist); !os.IsNotExist(err) {
 		if err = os.RemoveAll(eveDist); err != nil {
 			return fmt.Errorf("CleanContext: error in %s delete: %s", eveDist, err)
@@ -773,6 +779,7 @@ func CleanContext(eveDist, certsDist, imagesDist, evePID, eveUUID, vmName string
 			return fmt.Errorf("CleanContext: error in %s delete: %s", certsDist, err)
 		}
 	}
+	//state of software TPM is stored inside imagesDist and removed with it
 	if _, err = os.Stat(imagesDist); !os.IsNotExist(err) {
 		if err = os.RemoveAll(imagesDist); err != nil {
 			return fmt.Errorf("CleanContext: error in %s delete: %s", imagesDist, err)
@@ -787,7 +794,8 @@ func CleanContext(eveDist, certsDist, imagesDist, evePID, eveUUID, vmName string
 }
 
 //StopEden teardown Eden
-func StopEden(adamRm, redisRm, registryRm, eserverRm, eveRemote bool, evePidFile string, devModel string, vmName string) {
+//instances of EVE inside eveImagesDist are stopped with EVE
+func StopEden(adamRm, redisRm, registryRm, eserverRm, eveRemote bool, evePidFile, eveImagesDist string, devModel string, vmName string) {
 	if err := StopAdam(adamRm); err != nil {
 		log.Infof("cannot stop adam: %s", err)
 	} else {
@@ -827,13 +835,19 @@ func StopEden(adamRm, redisRm, registryRm, eserverRm, eveRemote bool, evePidFile
 			} else {
 				log.Infof("EVE stopped")
 			}
+			if err := StopEVEInstances(eveImagesDist); err != nil {
+				log.Infof("cannot stop EVE instances: %s", err)
+			}
 		}
 	}
 }
 
 //CleanEden teardown Eden and cleanup
 func CleanEden(eveDist, adamDist, certsDist, imagesDist, eserverDist, redisDist, registryDist, configDir, evePID string, configSaved string, remote bool, devModel string, vmName string) (err error) {
-	StopEden(true, true, true, true, remote, evePID, devModel, vmName)
+	StopEden(true, true, true, true, remote, evePID, imagesDist, devModel, vmName)
+	if err = StopObjectStore(true); err != nil {
+		log.Infof("cannot remove object storage: %s", err)
+	}
 	if _, err = os.Stat(eveDist); !os.IsNotExist(err) {
 		if err = os.RemoveAll(eveDist); err != nil {
 			return fmt.Errorf("CleanEden: error in %s delete: %s", eveDist, err)
@@ -0,0 +1,236 @@
+package eden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+//EVEInstance is additional EVE running in qemu on the same host with EVE defined in config
+//every instance has its own directory with config partition, disk, qemu config, pid and log files
+type EVEInstance struct {
+	Index int
+	Dir   string
+}
+
+//EVEInstancesDir returns directory with additional instances of EVE with images inside imagesDist
+func EVEInstancesDir(imagesDist string) string {
+	return filepath.Join(imagesDist, defaults.DefaultEVEInstancesDir)
+}
+
+//NewEVEInstance returns instance of EVE with index (starting from 1) inside imagesDist
+func NewEVEInstance(imagesDist string, index int) *EVEInstance {
+	return &EVEInstance{
+		Index: index,
+		Dir:   filepath.Join(EVEInstancesDir(imagesDist), strconv.Itoa(index)),
+	}
+}
+
+//ListEVEInstances returns instances of EVE prepared inside imagesDist sorted by index
+func ListEVEInstances(imagesDist string) ([]*EVEInstance, error) {
+	files, err := ioutil.ReadDir(EVEInstancesDir(imagesDist))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var result []*EVEInstance
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		if index, err := strconv.Atoi(file.Name()); err == nil && index > 0 {
+			result = append(result, NewEVEInstance(imagesDist, index))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+	return result, nil
+}
+
+//Name returns name of instance to use as device selector
+func (inst *EVEInstance) Name(eveName string) string {
+	return fmt.Sprintf("%s-%d", eveName, inst.Index)
+}
+
+//ConfigDir returns directory with config partition of instance
+func (inst *EVEInstance) ConfigDir() string {
+	return filepath.Join(inst.Dir, defaults.DefaultEVEInstanceConfigDir)
+}
+
+//OnboardCert returns onboarding certificate of instance
+func (inst *EVEInstance) OnboardCert() string {
+	return filepath.Join(inst.ConfigDir(), "onboard.cert.pem")
+}
+
+//ImageFile returns disk of instance with the same name as eveImageFile
+func (inst *EVEInstance) ImageFile(eveImageFile string) string {
+	return filepath.Join(inst.Dir, filepath.Base(eveImageFile))
+}
+
+//QemuConfigFile returns qemu config of instance
+func (inst *EVEInstance) QemuConfigFile() string {
+	return filepath.Join(inst.Dir, defaults.DefaultQemuFileToSave)
+}
+
+//PidFile returns pid file of qemu of instance
+func (inst *EVEInstance) PidFile() string {
+	return filepath.Join(inst.Dir, "eve.pid")
+}
+
+//LogFile returns console log of instance
+func (inst *EVEInstance) LogFile() string {
+	return filepath.Join(inst.Dir, "eve.log")
+}
+
+//Serial returns serial of instance based on serial of EVE
+func (inst *EVEInstance) Serial(serial string) string {
+	return fmt.Sprintf("%s%d", serial, inst.Index)
+}
+
+//Port returns port of instance shifted from port of EVE
+func (inst *EVEInstance) Port(port int) int {
+	if port == 0 {
+		return 0
+	}
+	return port + inst.Index*defaults.DefaultEVEInstancePortOffset
+}
+
+//HostFwd returns forwarding of ports of instance with host ports shifted from ports of EVE
+func (inst *EVEInstance) HostFwd(hostFwd map[string]string) map[string]string {
+	result := make(map[string]string, len(hostFwd))
+	for k, v := range hostFwd {
+		port, err := strconv.Atoi(k)
+		if err != nil {
+			result[k] = v
+			continue
+		}
+		result[strconv.Itoa(inst.Port(port))] = v
+	}
+	return result
+}
+
+//PrepareEVEInstance generates config partition of instance with its own onboarding certificate and soft serial
+//based on certsDir, downloads disk of instance and generates qemu config based on qemuConfigFile of EVE
+//existing files of instance are not changed
+func PrepareEVEInstance(inst *EVEInstance, certsDir, serial, eveImageFile, qemuConfigFile string, eveDesc utils.EVEDescription, uefiDesc utils.UEFIDescription) error {
+	if _, err := os.Stat(inst.ConfigDir()); os.IsNotExist(err) {
+		if err = os.MkdirAll(inst.ConfigDir(), 0755); err != nil {
+			return fmt.Errorf("PrepareEVEInstance: %s", err)
+		}
+		if err = utils.CopyFolder(certsDir, inst.ConfigDir()); err != nil {
+			return fmt.Errorf("PrepareEVEInstance: %s", err)
+		}
+		//device certificate belongs to EVE, instance will generate its own one
+		_ = os.Remove(filepath.Join(inst.ConfigDir(), "device.cert.pem"))
+		caCertPath, caKeyPath, err := ControllerCertPaths("root-certificate")
+		if err != nil {
+			return fmt.Errorf("PrepareEVEInstance: %s", err)
+		}
+		rootCert, err := utils.ParseCertificate(caCertPath)
+		if err != nil {
+			return fmt.Errorf("PrepareEVEInstance: cannot parse certificate from %s: %s", caCertPath, err)
+		}
+		rootKey, err := utils.ParsePrivateKey(caKeyPath)
+		if err != nil {
+			return fmt.Errorf("PrepareEVEInstance: cannot parse key from %s: %s", caKeyPath, err)
+		}
+		id, err := uuid.NewV4()
+		if err != nil {
+			return fmt.Errorf("PrepareEVEInstance: %s", err)
+		}
+		log.Debugf("generating onboarding cert and key of instance %d", inst.Index)
+		certSerial, err := utils.GenSerial()
+		if err != nil {
+			return fmt.Errorf("PrepareEVEInstance: %s", err)
+		}
+		cert, key := utils.GenServerCertElliptic(rootCert, rootKey, certSerial, nil, nil, id.String())
+		if err = utils.WriteToFiles(cert, key, inst.OnboardCert(), filepath.Join(inst.ConfigDir(), "onboard.key.pem")); err != nil {
+			return fmt.Errorf("PrepareEVEInstance: %s", err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(inst.ConfigDir(), "soft_serial"), []byte(inst.Serial(serial)), 0666); err != nil {
+			return fmt.Errorf("PrepareEVEInstance: %s", err)
+		}
+	}
+	imageFile := inst.ImageFile(eveImageFile)
+	if _, err := os.Stat(imageFile); os.IsNotExist(err) {
+		eveDesc.ConfigPath = inst.ConfigDir()
+		if err = utils.DownloadEveLive(eveDesc, uefiDesc, imageFile); err != nil {
+			return fmt.Errorf("PrepareEVEInstance: cannot download EVE: %s", err)
+		}
+	}
+	if _, err := os.Stat(inst.QemuConfigFile()); os.IsNotExist(err) {
+		data, err := ioutil.ReadFile(qemuConfigFile)
+		if err != nil {
+			return fmt.Errorf("PrepareEVEInstance: %s", err)
+		}
+		//firmware with variables is stored alongside the image, so use files of instance
+		conf := strings.ReplaceAll(string(data), filepath.Dir(eveImageFile), inst.Dir)
+		if err = ioutil.WriteFile(inst.QemuConfigFile(), []byte(conf), 0644); err != nil {
+			return fmt.Errorf("PrepareEVEInstance: %s", err)
+		}
+	}
+	return nil
+}
+
+//removeEVEInstances removes devices and onboarding of instances inside imagesDist from controller
+func removeEVEInstances(imagesDist string) error {
+	instances, err := ListEVEInstances(imagesDist)
+	if err != nil || len(instances) == 0 {
+		return err
+	}
+	ctrl, err := controller.CloudPrepare()
+	if err != nil {
+		return fmt.Errorf("error in CloudPrepare: %s", err)
+	}
+	for _, inst := range instances {
+		data, err := ioutil.ReadFile(inst.OnboardCert())
+		if err != nil {
+			log.Debugf("instance %d: %s", inst.Index, err)
+			continue
+		}
+		cert, err := utils.ParseFirstCertFromBlock(data)
+		if err != nil {
+			return fmt.Errorf("instance %d: %s", inst.Index, err)
+		}
+		onboardUUID := cert.Subject.CommonName
+		if devUUID, err := ctrl.DeviceGetByOnboardUUID(onboardUUID); err == nil {
+			log.Debugf("Deleting devUUID %s of instance %d", devUUID, inst.Index)
+			if err = ctrl.DeviceRemove(devUUID); err != nil {
+				return fmt.Errorf("instance %d: %s", inst.Index, err)
+			}
+		}
+		if err = ctrl.OnboardRemove(onboardUUID); err != nil {
+			log.Debugf("instance %d: %s", inst.Index, err)
+		}
+	}
+	return nil
+}
+
+//StopEVEInstances stops all instances of EVE inside imagesDist
+func StopEVEInstances(imagesDist string) error {
+	instances, err := ListEVEInstances(imagesDist)
+	if err != nil {
+		return err
+	}
+	for _, inst := range instances {
+		if status, _ := StatusEVEQemu(inst.PidFile()); !strings.Contains(status, "running with pid") {
+			continue
+		}
+		if err = StopEVEQemu(inst.PidFile()); err != nil {
+			return fmt.Errorf("cannot stop instance %d: %s", inst.Index, err)
+		}
+		log.Infof("EVE instance %d stopped", inst.Index)
+	}
+	return nil
+}
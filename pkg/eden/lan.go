@@ -0,0 +1,257 @@
+package eden
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/lan"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+//LANDist returns directory with pid, log and leases files of virtual LAN with bridge
+//LAN is shared between contexts, so directory is defined by bridge
+func LANDist(bridge string) (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, fmt.Sprintf("%s-%s", defaults.DefaultLANDistPrefix, bridge)), nil
+}
+
+//LANFiles returns pid, log and leases files of responders of virtual LAN with bridge
+func LANFiles(bridge string) (pidFile, logFile, leasesFile string, err error) {
+	dist, err := LANDist(bridge)
+	if err != nil {
+		return "", "", "", err
+	}
+	return filepath.Join(dist, "lan.pid"), filepath.Join(dist, "lan.log"), filepath.Join(dist, "leases.json"), nil
+}
+
+//runPrivileged runs command with sudo if eden is not running as root
+func runPrivileged(name string, args ...string) error {
+	if os.Geteuid() != 0 {
+		args = append([]string{name}, args...)
+		name = "sudo"
+	}
+	if _, stderr, err := utils.RunCommandAndWait(name, args...); err != nil {
+		return fmt.Errorf("%s %s: %s %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+//natRules returns rules of iptables to forward traffic of subnet of bridge outside of host
+func natRules(bridge, subnet string) [][]string {
+	return [][]string{
+		{"-t", "nat", "POSTROUTING", "-s", subnet, "!", "-o", bridge, "-j", "MASQUERADE"},
+		{"-t", "filter", "FORWARD", "-i", bridge, "-j", "ACCEPT"},
+		{"-t", "filter", "FORWARD", "-o", bridge, "-j", "ACCEPT"},
+	}
+}
+
+//iptablesRule checks rule and adds or deletes it depending on action ("-I" or "-D")
+func iptablesRule(action string, rule []string) error {
+	args := append([]string{rule[0], rule[1], "-C"}, rule[2:]...)
+	exists := runPrivileged("iptables", args...) == nil
+	if (action == "-I" && exists) || (action == "-D" && !exists) {
+		return nil
+	}
+	args[2] = action
+	return runPrivileged("iptables", args...)
+}
+
+//lanProcessRunning checks process with pid from pidFile, which may belong to root
+func lanProcessRunning(pidFile string) (int, bool) {
+	data, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false
+	}
+	if err = syscall.Kill(pid, syscall.Signal(0)); err == nil || err == syscall.EPERM {
+		return pid, true
+	}
+	return pid, false
+}
+
+//StartLAN creates bridge with first address of subnet assigned, enables NAT for subnet
+//and runs DHCP and DNS responders on bridge; hosts are names resolved by DNS responder
+//upstream is DNS server to forward other requests to, nameserver of host is used if empty
+func StartLAN(bridge, subnet, upstream string, hosts map[string]string) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("StartLAN: bridge networking is not supported on %s", runtime.GOOS)
+	}
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return fmt.Errorf("StartLAN: %s", err)
+	}
+	ones, _ := ipNet.Mask.Size()
+	gateway := lan.Gateway(ipNet)
+	if _, err := net.InterfaceByName(bridge); err != nil {
+		log.Infof("Creating bridge %s with address %s/%d", bridge, gateway, ones)
+		if err = runPrivileged("ip", "link", "add", "name", bridge, "type", "bridge"); err != nil {
+			return fmt.Errorf("StartLAN: %s", err)
+		}
+		if err = runPrivileged("ip", "addr", "add", fmt.Sprintf("%s/%d", gateway, ones), "dev", bridge); err != nil {
+			return fmt.Errorf("StartLAN: %s", err)
+		}
+	}
+	if err = runPrivileged("ip", "link", "set", bridge, "up"); err != nil {
+		return fmt.Errorf("StartLAN: %s", err)
+	}
+	if err = runPrivileged("sysctl", "-w", "net.ipv4.ip_forward=1"); err != nil {
+		return fmt.Errorf("StartLAN: %s", err)
+	}
+	for _, rule := range natRules(bridge, ipNet.String()) {
+		if err = iptablesRule("-I", rule); err != nil {
+			return fmt.Errorf("StartLAN: %s", err)
+		}
+	}
+	pidFile, logFile, leasesFile, err := LANFiles(bridge)
+	if err != nil {
+		return fmt.Errorf("StartLAN: %s", err)
+	}
+	if pid, running := lanProcessRunning(pidFile); running {
+		log.Infof("LAN responders already running with pid %d", pid)
+		return nil
+	}
+	if err = os.MkdirAll(filepath.Dir(pidFile), 0755); err != nil {
+		return fmt.Errorf("StartLAN: %s", err)
+	}
+	command, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("StartLAN: cannot obtain executable path: %s", err)
+	}
+	if upstream == "" {
+		upstream = lan.UpstreamDNS("/etc/resolv.conf")
+	}
+	args := []string{"lan", "serve", "--lan-bridge", bridge, "--lan-subnet", ipNet.String(), "--dns", upstream,
+		"--leases", leasesFile, "--pid-file", pidFile}
+	for name, ip := range hosts {
+		args = append(args, "--host", fmt.Sprintf("%s=%s", name, ip))
+	}
+	name := command
+	if os.Geteuid() != 0 {
+		//responders bind privileged ports, use credentials of sudo cached by commands above
+		args = append([]string{"-n", command}, args...)
+		name = "sudo"
+	}
+	log.Infof("Start LAN responders: %s %s", name, strings.Join(args, " "))
+	if err = utils.RunCommandNohup(name, logFile, "", args...); err != nil {
+		return fmt.Errorf("StartLAN: %s", err)
+	}
+	return nil
+}
+
+//StopLAN stops DHCP and DNS responders, removes NAT rules and bridge
+func StopLAN(bridge, subnet string) error {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	pidFile, _, _, err := LANFiles(bridge)
+	if err != nil {
+		return fmt.Errorf("StopLAN: %s", err)
+	}
+	if pid, running := lanProcessRunning(pidFile); running {
+		if err = runPrivileged("kill", strconv.Itoa(pid)); err != nil {
+			return fmt.Errorf("StopLAN: %s", err)
+		}
+	}
+	_ = os.Remove(pidFile)
+	if _, ipNet, err := net.ParseCIDR(subnet); err == nil {
+		for _, rule := range natRules(bridge, ipNet.String()) {
+			if err = iptablesRule("-D", rule); err != nil {
+				log.Debugf("StopLAN: %s", err)
+			}
+		}
+	}
+	if _, err = net.InterfaceByName(bridge); err == nil {
+		if err = runPrivileged("ip", "link", "del", bridge); err != nil {
+			return fmt.Errorf("StopLAN: %s", err)
+		}
+	}
+	return nil
+}
+
+//StatusLAN returns status of bridge and DHCP and DNS responders
+func StatusLAN(bridge string) (string, error) {
+	if _, err := net.InterfaceByName(bridge); err != nil {
+		return fmt.Sprintf("bridge %s not exists", bridge), nil
+	}
+	pidFile, _, _, err := LANFiles(bridge)
+	if err != nil {
+		return "", err
+	}
+	if pid, running := lanProcessRunning(pidFile); running {
+		return fmt.Sprintf("running with pid %d", pid), nil
+	}
+	return "process doesn't exist", nil
+}
+
+//qemuTAP returns name and MAC of TAP device for interface index of qemu with pidFile
+//names are unique for every qemu running on the host and no longer than IFNAMSIZ
+func qemuTAP(pidFile string, index int) (string, string) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(pidFile))
+	sum := h.Sum32()
+	name := fmt.Sprintf("%s%06x%d", defaults.DefaultLANTAPPrefix, sum&0xffffff, index)
+	mac := fmt.Sprintf("52:54:%02x:%02x:%02x:%02x", byte(sum>>16), byte(sum>>8), byte(sum), index)
+	return name, mac
+}
+
+//createTAP creates TAP device owned by current user and attaches it to bridge
+func createTAP(name, bridge string) error {
+	if _, err := net.InterfaceByName(name); err != nil {
+		if err = runPrivileged("ip", "tuntap", "add", "dev", name, "mode", "tap", "user", strconv.Itoa(os.Getuid())); err != nil {
+			return err
+		}
+	}
+	if err := runPrivileged("ip", "link", "set", name, "master", bridge); err != nil {
+		return err
+	}
+	return runPrivileged("ip", "link", "set", name, "up")
+}
+
+//qemuBridgeOptions creates TAP devices attached to bridge and returns options of qemu to use them for eth0 and eth1
+func qemuBridgeOptions(bridge, pidFile string) (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", fmt.Errorf("bridge networking is not supported on %s", runtime.GOOS)
+	}
+	if _, err := net.InterfaceByName(bridge); err != nil {
+		return "", fmt.Errorf("bridge %s not found, please run 'eden lan start': %s", bridge, err)
+	}
+	options := ""
+	for i, device := range []string{"virtio-net-pci", "e1000"} {
+		name, mac := qemuTAP(pidFile, i)
+		if err := createTAP(name, bridge); err != nil {
+			return "", err
+		}
+		options += fmt.Sprintf("-netdev tap,id=eth%d,ifname=%s,script=no,downscript=no -device %s,netdev=eth%d,mac=%s ", i, name, device, i, mac)
+	}
+	return options, nil
+}
+
+//deleteQemuTAPs removes TAP devices of qemu with pidFile if they exist
+func deleteQemuTAPs(pidFile string) error {
+	for i := 0; i < 2; i++ {
+		name, _ := qemuTAP(pidFile, i)
+		if _, err := net.InterfaceByName(name); err != nil {
+			continue
+		}
+		if err := runPrivileged("ip", "link", "del", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
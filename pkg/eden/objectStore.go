@@ -0,0 +1,95 @@
+package eden
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+)
+
+//objectStoreContainer describes container with stand-in of object storage
+type objectStoreContainer struct {
+	serviceName   string
+	containerName string
+	image         string
+	portMap       map[string]string
+	cmd           []string
+	envs          []string
+}
+
+//objectStoreContainers returns containers with MinIO for s3:// and Azurite for azure:// images
+func objectStoreContainers(minioPort, azuritePort int, accessKey, secretKey, azureAccount, azureKey string) []objectStoreContainer {
+	return []objectStoreContainer{{
+		serviceName:   "minio",
+		containerName: defaults.DefaultMinIOContainerName,
+		image:         defaults.DefaultMinIOContainerRef + ":" + defaults.DefaultMinIOTag,
+		portMap:       map[string]string{"9000": strconv.Itoa(minioPort)},
+		cmd:           []string{"server", "/data"},
+		envs:          []string{"MINIO_ROOT_USER=" + accessKey, "MINIO_ROOT_PASSWORD=" + secretKey},
+	}, {
+		serviceName:   "azurite",
+		containerName: defaults.DefaultAzuriteContainerName,
+		image:         defaults.DefaultAzuriteContainerRef + ":" + defaults.DefaultAzuriteTag,
+		portMap:       map[string]string{"10000": strconv.Itoa(azuritePort)},
+		cmd:           []string{"azurite-blob", "--blobHost", "0.0.0.0", "--blobPort", "10000", "--loose"},
+		envs:          []string{fmt.Sprintf("AZURITE_ACCOUNTS=%s:%s", azureAccount, azureKey)},
+	}}
+}
+
+//StartObjectStore function run MinIO and Azurite in docker to serve s3:// and azure:// images
+func StartObjectStore(minioPort, azuritePort int, accessKey, secretKey, azureAccount, azureKey string) (err error) {
+	for _, c := range objectStoreContainers(minioPort, azuritePort, accessKey, secretKey, azureAccount, azureKey) {
+		state, err := utils.StateContainer(c.containerName)
+		if err != nil {
+			return fmt.Errorf("StartObjectStore: error in get state of %s container: %s", c.serviceName, err)
+		}
+		if state == "" {
+			if err := utils.CreateAndRunContainer(c.containerName, c.image, c.portMap, nil, c.cmd, c.envs); err != nil {
+				return fmt.Errorf("StartObjectStore: error in create %s container: %s", c.serviceName, err)
+			}
+		} else if !strings.Contains(state, "running") {
+			if err := utils.StartContainer(c.containerName); err != nil {
+				return fmt.Errorf("StartObjectStore: error in restart %s container: %s", c.serviceName, err)
+			}
+		}
+	}
+	return nil
+}
+
+//StopObjectStore function stop MinIO and Azurite containers and removes them if rm set
+func StopObjectStore(rm bool) (err error) {
+	for _, c := range objectStoreContainers(0, 0, "", "", "", "") {
+		state, err := utils.StateContainer(c.containerName)
+		if err != nil {
+			return fmt.Errorf("StopObjectStore: error in get state of %s container: %s", c.serviceName, err)
+		}
+		if state == "" {
+			continue
+		}
+		if !strings.Contains(state, "running") && !rm {
+			continue
+		}
+		if err := utils.StopContainer(c.containerName, rm); err != nil {
+			return fmt.Errorf("StopObjectStore: error in stop %s container: %s", c.serviceName, err)
+		}
+	}
+	return nil
+}
+
+//StatusObjectStore function return statuses of MinIO and Azurite containers
+func StatusObjectStore() (status map[string]string, err error) {
+	status = map[string]string{}
+	for _, c := range objectStoreContainers(0, 0, "", "", "", "") {
+		state, err := utils.StateContainer(c.containerName)
+		if err != nil {
+			return nil, fmt.Errorf("StatusObjectStore: error in get state of %s container: %s", c.serviceName, err)
+		}
+		if state == "" {
+			state = "container doesn't exist"
+		}
+		status[c.serviceName] = state
+	}
+	return status, nil
+}
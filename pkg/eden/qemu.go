@@ -16,8 +16,10 @@ import (
 )
 
 //StartEVEQemu function run EVE in qemu
+//if tpm is true, software TPM is started with state alongside eveImageFile and attached to qemu as TPM 2.0 device
+//if bridge is not empty, interfaces of EVE are attached to it with TAP devices instead of user networking and qemuHostFwd is not used
 func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, qemuSMBIOSSerial string, eveTelnetPort, qemuMonitorPort int, qemuHostFwd map[string]string,
-	qemuAccel bool, qemuConfigFile, logFile, pidFile string, foregroud bool) (err error) {
+	qemuAccel, tpm bool, bridge, qemuConfigFile, logFile, pidFile string, foregroud bool) (err error) {
 	qemuCommand := ""
 	qemuOptions := "-display none -nodefaults -no-user-config "
 	qemuOptions += fmt.Sprintf("-serial chardev:char0 -chardev socket,id=char0,port=%d,host=localhost,server,nodelay,nowait,telnet,logfile=%s ", eveTelnetPort, logFile)
@@ -27,44 +29,52 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, qemuSMBIOSSerial string, eveTe
 	if qemuMonitorPort != 0 {
 		qemuOptions += fmt.Sprintf("-monitor tcp:localhost:%d,server,nowait  ", qemuMonitorPort)
 	}
-	nets, err := utils.GetSubnetsNotUsed(1)
-	if err != nil {
-		return fmt.Errorf("StartEVEQemu: %s", err)
-	}
-	offset := 0
-	network := nets[0].Subnet
-	qemuOptions += fmt.Sprintf("-netdev user,id=eth%d,net=%s,dhcpstart=%s,ipv6=off", 0, network, nets[0].FirstAddress)
-	for k, v := range qemuHostFwd {
-		origPort, err := strconv.Atoi(k)
+	if bridge != "" {
+		bridgeOptions, err := qemuBridgeOptions(bridge, pidFile)
 		if err != nil {
-			log.Errorf("Failed converting %s to Integer", k)
-			break
+			return fmt.Errorf("StartEVEQemu: %s", err)
 		}
-		newPort, err := strconv.Atoi(v)
+		qemuOptions += bridgeOptions
+	} else {
+		nets, err := utils.GetSubnetsNotUsed(1)
 		if err != nil {
-			log.Errorf("Failed converting %s to Integer", v)
-			break
+			return fmt.Errorf("StartEVEQemu: %s", err)
 		}
-		qemuOptions += fmt.Sprintf(",hostfwd=tcp::%d-:%d", origPort+offset, newPort+offset)
-	}
-	qemuOptions += fmt.Sprintf(" -device virtio-net-pci,netdev=eth%d ", 0)
-	offset += 10
-
-	qemuOptions += fmt.Sprintf("-netdev user,id=eth%d,net=%s,dhcpstart=%s,ipv6=off", 1, network, nets[0].SecondAddress)
-	for k, v := range qemuHostFwd {
-		origPort, err := strconv.Atoi(k)
-		if err != nil {
-			log.Errorf("Failed converting %s to Integer", k)
-			break
+		offset := 0
+		network := nets[0].Subnet
+		qemuOptions += fmt.Sprintf("-netdev user,id=eth%d,net=%s,dhcpstart=%s,ipv6=off", 0, network, nets[0].FirstAddress)
+		for k, v := range qemuHostFwd {
+			origPort, err := strconv.Atoi(k)
+			if err != nil {
+				log.Errorf("Failed converting %s to Integer", k)
+				break
+			}
+			newPort, err := strconv.Atoi(v)
+			if err != nil {
+				log.Errorf("Failed converting %s to Integer", v)
+				break
+			}
+			qemuOptions += fmt.Sprintf(",hostfwd=tcp::%d-:%d", origPort+offset, newPort+offset)
 		}
-		newPort, err := strconv.Atoi(v)
-		if err != nil {
-			log.Errorf("Failed converting %s to Integer", v)
-			break
+		qemuOptions += fmt.Sprintf(" -device virtio-net-pci,netdev=eth%d ", 0)
+		offset += 10
+
+		qemuOptions += fmt.Sprintf("-netdev user,id=eth%d,net=%s,dhcpstart=%s,ipv6=off", 1, network, nets[0].SecondAddress)
+		for k, v := range qemuHostFwd {
+			origPort, err := strconv.Atoi(k)
+			if err != nil {
+				log.Errorf("Failed converting %s to Integer", k)
+				break
+			}
+			newPort, err := strconv.Atoi(v)
+			if err != nil {
+				log.Errorf("Failed converting %s to Integer", v)
+				break
+			}
+			qemuOptions += fmt.Sprintf(",hostfwd=tcp::%d-:%d", origPort + offset, newPort + offset)
 		}
-		qemuOptions += fmt.Sprintf(",hostfwd=tcp::%d-:%d", origPort + offset, newPort + offset)
+		qemuOptions += fmt.Sprintf(" -device e1000,netdev=eth%d ", 1)
 	}
-	qemuOptions += fmt.Sprintf(" -device e1000,netdev=eth%d ", 1)
 
 	if qemuOS == "" {
 		qemuOS = runtime.GOOS
@@ -101,12 +111,33 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, qemuSMBIOSSerial string, eveTe
 	default:
 		return fmt.Errorf("StartEVEQemu: Arch not supported: %s", qemuARCH)
 	}
+	if tpm {
+		socket, err := StartSwtpm(SwtpmStateDir(eveImageFile), SwtpmPidFile(pidFile))
+		if err != nil {
+			return fmt.Errorf("StartEVEQemu: %s", err)
+		}
+		qemuOptions += qemuTPMOptions(qemuARCH, socket)
+	}
 	qemuOptions += fmt.Sprintf("-drive file=%s,format=qcow2 ", eveImageFile)
 	if qemuConfigFile != "" {
 		qemuOptions += fmt.Sprintf("-readconfig %s ", qemuConfigFile)
 	}
 	log.Infof("Start EVE: %s %s", qemuCommand, qemuOptions)
 	if foregroud {
+		if tpm {
+			defer func() {
+				if err := StopSwtpm(SwtpmPidFile(pidFile)); err != nil {
+					log.Debugf("cannot stop swtpm: %s", err)
+				}
+			}()
+		}
+		if bridge != "" {
+			defer func() {
+				if err := deleteQemuTAPs(pidFile); err != nil {
+					log.Debugf("cannot delete TAP devices: %s", err)
+				}
+			}()
+		}
 		if err := utils.RunCommandForeground(qemuCommand, strings.Fields(qemuOptions)...); err != nil {
 			return fmt.Errorf("StartEVEQemu: %s", err)
 		}
@@ -119,9 +150,17 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, qemuSMBIOSSerial string, eveTe
 	return nil
 }
 
-//StopEVEQemu function stop EVE
+//StopEVEQemu function stop EVE, its software TPM and TAP devices
 func StopEVEQemu(pidFile string) (err error) {
-	return utils.StopCommandWithPid(pidFile)
+	err = utils.StopCommandWithPid(pidFile)
+	if err := deleteQemuTAPs(pidFile); err != nil {
+		log.Debugf("cannot delete TAP devices: %s", err)
+	}
+	//swtpm may exit by itself after disconnection of qemu
+	if err := StopSwtpm(SwtpmPidFile(pidFile)); err != nil {
+		log.Debugf("cannot stop swtpm: %s", err)
+	}
+	return err
 }
 
 //StatusEVEQemu function get status of EVE
@@ -0,0 +1,80 @@
+package eden
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+//SwtpmStateDir returns directory to keep state of software TPM of EVE with image eveImageFile
+//state is stored alongside the image, so onboarding of EVE survives restarts
+func SwtpmStateDir(eveImageFile string) string {
+	return filepath.Join(filepath.Dir(eveImageFile), defaults.DefaultSwtpmDir)
+}
+
+//SwtpmPidFile returns pid file of software TPM of EVE with pid file evePidFile
+func SwtpmPidFile(evePidFile string) string {
+	return fmt.Sprintf("%s-swtpm.pid", strings.TrimSuffix(evePidFile, filepath.Ext(evePidFile)))
+}
+
+func swtpmSocket(stateDir string) string {
+	return filepath.Join(stateDir, "swtpm-sock")
+}
+
+//StartSwtpm runs swtpm with state inside stateDir and returns path to its control socket
+//running instance of swtpm with pid from pidFile is reused
+func StartSwtpm(stateDir, pidFile string) (string, error) {
+	socket := swtpmSocket(stateDir)
+	if status, _ := utils.StatusCommandWithPid(pidFile); strings.Contains(status, "running with pid") {
+		log.Debugf("swtpm already %s", status)
+		return socket, nil
+	}
+	if _, err := exec.LookPath("swtpm"); err != nil {
+		return "", fmt.Errorf("StartSwtpm: swtpm not found, please install it: %s", err)
+	}
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return "", fmt.Errorf("StartSwtpm: %s", err)
+	}
+	_ = os.Remove(socket)
+	args := []string{"socket", "--tpm2",
+		"--tpmstate", fmt.Sprintf("dir=%s", stateDir),
+		"--ctrl", fmt.Sprintf("type=unixio,path=%s", socket),
+		"--log", fmt.Sprintf("file=%s,level=20", filepath.Join(stateDir, "swtpm.log")),
+		"--terminate"}
+	log.Infof("Start swtpm: swtpm %s", strings.Join(args, " "))
+	if err := utils.RunCommandNohup("swtpm", "", pidFile, args...); err != nil {
+		return "", fmt.Errorf("StartSwtpm: %s", err)
+	}
+	//wait for swtpm to create control socket before connection of qemu
+	for start := time.Now(); time.Since(start) < defaults.DefaultSwtpmTimeout; time.Sleep(100 * time.Millisecond) {
+		if _, err := os.Stat(socket); err == nil {
+			return socket, nil
+		}
+	}
+	_ = StopSwtpm(pidFile)
+	return "", fmt.Errorf("StartSwtpm: no socket %s after %s", socket, defaults.DefaultSwtpmTimeout)
+}
+
+//StopSwtpm stops swtpm with pid from pidFile if it is running
+func StopSwtpm(pidFile string) error {
+	if _, err := os.Stat(pidFile); os.IsNotExist(err) {
+		return nil
+	}
+	return utils.StopCommandWithPid(pidFile)
+}
+
+//qemuTPMOptions returns options of qemu to use TPM 2.0 device emulated by swtpm with control socket
+func qemuTPMOptions(qemuARCH, socket string) string {
+	device := "tpm-tis"
+	if qemuARCH == "arm64" {
+		device = "tpm-tis-device"
+	}
+	return fmt.Sprintf("-chardev socket,id=chrtpm,path=%s -tpmdev emulator,id=tpm0,chardev=chrtpm -device %s,tpmdev=tpm0 ", socket, device)
+}
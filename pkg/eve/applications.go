@@ -2,6 +2,7 @@ package eve
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"sort"
 	"strconv"
@@ -18,26 +19,29 @@ import (
 
 //AppInstState stores state of app
 type AppInstState struct {
-	Name         string
-	UUID         string
-	Image        string
-	AdamState    string
-	EVEState     string
-	InternalIP   []string
-	ExternalIP   string
-	InternalPort string
-	ExternalPort string
-	Memory       string
-	macs         []string
+	Name         string   `json:"name"`
+	UUID         string   `json:"uuid"`
+	Image        string   `json:"image"`
+	AdamState    string   `json:"adamState"`
+	EVEState     string   `json:"eveState"`
+	InternalIP   []string `json:"internalIP"`
+	ExternalIP   string   `json:"externalIP"`
+	InternalPort string   `json:"internalPort"`
+	ExternalPort string   `json:"externalPort"`
+	Memory       string   `json:"memory"`
+	MACs         []string `json:"macs"`
 	volumes      map[string]uint32
 	deleted      bool
 }
 
-func appStateHeader() string {
+func appStateHeader(wide bool) string {
+	if wide {
+		return "NAME\tIMAGE\tUUID\tINTERNAL\tEXTERNAL\tMEMORY\tMACS\tSTATE(ADAM)\tLAST_STATE(EVE)"
+	}
 	return "NAME\tIMAGE\tUUID\tINTERNAL\tEXTERNAL\tMEMORY\tSTATE(ADAM)\tLAST_STATE(EVE)"
 }
 
-func (appStateObj *AppInstState) toString() string {
+func (appStateObj *AppInstState) toString(wide bool) string {
 	internal := "-"
 	if len(appStateObj.InternalIP) == 1 {
 		if appStateObj.InternalPort == "" {
@@ -64,12 +68,36 @@ func (appStateObj *AppInstState) toString() string {
 	if appStateObj.ExternalPort == "" {
 		external = "-"
 	}
+	if wide {
+		macs := "-"
+		if len(appStateObj.MACs) > 0 {
+			macs = strings.Join(appStateObj.MACs, "; ")
+		}
+		return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
+			appStateObj.Name, appStateObj.Image, appStateObj.UUID,
+			internal, external, appStateObj.Memory, macs,
+			appStateObj.AdamState, appStateObj.EVEState)
+	}
 	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
 		appStateObj.Name, appStateObj.Image, appStateObj.UUID,
 		internal, external, appStateObj.Memory,
 		appStateObj.AdamState, appStateObj.EVEState)
 }
 
+//ExternalAddress returns address in host:port format to access internalPort of app from outside of EVE
+func (appStateObj *AppInstState) ExternalAddress(internalPort int) (string, error) {
+	if appStateObj.ExternalIP == "" || appStateObj.ExternalIP == "-" {
+		return "", fmt.Errorf("no external IP of app %s", appStateObj.Name)
+	}
+	extPorts := strings.Split(appStateObj.ExternalPort, ",")
+	for i, port := range strings.Split(appStateObj.InternalPort, ",") {
+		if port == strconv.Itoa(internalPort) && i < len(extPorts) {
+			return net.JoinHostPort(appStateObj.ExternalIP, extPorts[i]), nil
+		}
+	}
+	return "", fmt.Errorf("port %d of app %s is not published", internalPort, appStateObj.Name)
+}
+
 func getPortMapping(appConfig *config.AppInstanceConfig, qemuPorts map[string]string) (intports, extports string) {
 	iports := []string{}
 	eports := []string{}
@@ -190,22 +218,22 @@ func (ctx *State) processApplicationsByInfo(im *info.ZInfoMsg) {
 		if len(im.GetAinfo().Network) != 0 && len(im.GetAinfo().Network[0].IPAddrs) != 0 {
 			if len(im.GetAinfo().Network) > 1 {
 				appStateObj.InternalIP = []string{}
-				appStateObj.macs = []string{}
+				appStateObj.MACs = []string{}
 				for _, el := range im.GetAinfo().Network {
 					if len(im.GetAinfo().Network[0].IPAddrs) != 0 {
 						appStateObj.InternalIP = append(appStateObj.InternalIP, el.IPAddrs[0])
-						appStateObj.macs = append(appStateObj.macs, el.MacAddr)
+						appStateObj.MACs = append(appStateObj.MACs, el.MacAddr)
 					}
 				}
 			} else {
 				if len(im.GetAinfo().Network[0].IPAddrs) != 0 {
 					appStateObj.InternalIP = []string{im.GetAinfo().Network[0].IPAddrs[0]}
-					appStateObj.macs = []string{im.GetAinfo().Network[0].MacAddr}
+					appStateObj.MACs = []string{im.GetAinfo().Network[0].MacAddr}
 				}
 			}
 		} else {
 			appStateObj.InternalIP = []string{"-"}
-			appStateObj.macs = []string{}
+			appStateObj.MACs = []string{}
 		}
 		//check appStateObj not defined in adam
 		if appStateObj.AdamState != "IN_CONFIG" {
@@ -216,7 +244,7 @@ func (ctx *State) processApplicationsByInfo(im *info.ZInfoMsg) {
 	case info.ZInfoTypes_ZiNetworkInstance: //try to find ips from NetworkInstances
 		for _, el := range im.GetNiinfo().IpAssignments {
 			for _, appStateObj := range ctx.applications {
-				for ind, mac := range appStateObj.macs {
+				for ind, mac := range appStateObj.MACs {
 					if mac == el.MacAddress {
 						appStateObj.InternalIP[ind] = el.IpAddress[0]
 					}
@@ -276,20 +304,23 @@ func (ctx *State) processApplicationsByInfo(im *info.ZInfoMsg) {
 	}
 }
 
-//PodsList prints applications
-func (ctx *State) PodsList() error {
-	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
-	if _, err := fmt.Fprintln(w, appStateHeader()); err != nil {
-		return err
-	}
+//PodsList prints applications in defined output format
+func (ctx *State) PodsList(output *Output) error {
 	appStatesSlice := make([]*AppInstState, 0, len(ctx.Applications()))
 	appStatesSlice = append(appStatesSlice, ctx.Applications()...)
 	sort.SliceStable(appStatesSlice, func(i, j int) bool {
 		return appStatesSlice[i].Name < appStatesSlice[j].Name
 	})
+	if !output.IsTable() {
+		return output.Print(os.Stdout, appStatesSlice)
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err := fmt.Fprintln(w, appStateHeader(output.IsWide())); err != nil {
+		return err
+	}
 	for _, el := range appStatesSlice {
-		if _, err := fmt.Fprintln(w, el.toString()); err != nil {
+		if _, err := fmt.Fprintln(w, el.toString(output.IsWide())); err != nil {
 			return err
 		}
 	}
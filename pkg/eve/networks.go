@@ -1,6 +1,7 @@
 package eve
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"sort"
@@ -15,22 +16,43 @@ import (
 
 //NetInstState stores state of network instance
 type NetInstState struct {
-	Name        string
-	UUID        string
-	NetworkType config.ZNetworkInstType
-	CIDR        string
-	Stats       string
-	AdamState   string
-	EveState    string
-	Activated   bool
+	Name        string                  `json:"name"`
+	UUID        string                  `json:"uuid"`
+	NetworkType config.ZNetworkInstType `json:"networkType"`
+	CIDR        string                  `json:"cidr"`
+	Stats       string                  `json:"stats"`
+	AdamState   string                  `json:"adamState"`
+	EveState    string                  `json:"eveState"`
+	Activated   bool                    `json:"activated"`
 	deleted     bool
 }
 
-func netInstStateHeader() string {
+//MarshalJSON returns json representation of NetInstState with NetworkType as string
+func (netInstStateObj *NetInstState) MarshalJSON() ([]byte, error) {
+	type netInstState NetInstState
+	return json.Marshal(&struct {
+		*netInstState
+		NetworkType string `json:"networkType"`
+	}{
+		netInstState: (*netInstState)(netInstStateObj),
+		NetworkType:  netInstStateObj.NetworkType.String(),
+	})
+}
+
+func netInstStateHeader(wide bool) string {
+	if wide {
+		return "NAME\tUUID\tTYPE\tCIDR\tSTATS\tACTIVATED\tSTATE(ADAM)\tLAST_STATE(EVE)"
+	}
 	return "NAME\tUUID\tTYPE\tCIDR\tSTATS\tSTATE(ADAM)\tLAST_STATE(EVE)"
 }
 
-func (netInstStateObj *NetInstState) toString() string {
+func (netInstStateObj *NetInstState) toString(wide bool) string {
+	if wide {
+		return fmt.Sprintf("%s\t%s\t%v\t%s\t%s\t%t\t%s\t%s",
+			netInstStateObj.Name, netInstStateObj.UUID,
+			netInstStateObj.NetworkType, netInstStateObj.CIDR, netInstStateObj.Stats,
+			netInstStateObj.Activated, netInstStateObj.AdamState, netInstStateObj.EveState)
+	}
 	return fmt.Sprintf("%s\t%s\t%v\t%s\t%s\t%s\t%s",
 		netInstStateObj.Name, netInstStateObj.UUID,
 		netInstStateObj.NetworkType, netInstStateObj.CIDR, netInstStateObj.Stats,
@@ -108,20 +130,23 @@ func (ctx *State) processNetworksByMetric(msg *metrics.ZMetricMsg) {
 	}
 }
 
-//NetList prints networks
-func (ctx *State) NetList() error {
-	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
-	if _, err := fmt.Fprintln(w, netInstStateHeader()); err != nil {
-		return err
-	}
+//NetList prints networks in defined output format
+func (ctx *State) NetList(output *Output) error {
 	netInstStatesSlice := make([]*NetInstState, 0, len(ctx.Networks()))
 	netInstStatesSlice = append(netInstStatesSlice, ctx.Networks()...)
 	sort.SliceStable(netInstStatesSlice, func(i, j int) bool {
 		return netInstStatesSlice[i].Name < netInstStatesSlice[j].Name
 	})
+	if !output.IsTable() {
+		return output.Print(os.Stdout, netInstStatesSlice)
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err := fmt.Fprintln(w, netInstStateHeader(output.IsWide())); err != nil {
+		return err
+	}
 	for _, el := range netInstStatesSlice {
-		if _, err := fmt.Fprintln(w, el.toString()); err != nil {
+		if _, err := fmt.Fprintln(w, el.toString(output.IsWide())); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,129 @@
+package eve
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+//OutputFormat is format to print state of objects
+type OutputFormat int
+
+//formats of output
+const (
+	OutputFormatTable OutputFormat = iota
+	OutputFormatWide
+	OutputFormatJSON
+	OutputFormatYAML
+	OutputFormatTemplate
+)
+
+//OutputFormatHelp describes possible values for output flag
+const OutputFormatHelp = "Output format: table, wide, json, yaml or template=<go template> (for example template='{{.Name}}')"
+
+//Output defines how to print state of objects
+type Output struct {
+	Format   OutputFormat
+	Template *template.Template
+}
+
+//ParseOutput returns Output for provided value of output flag
+func ParseOutput(output string) (*Output, error) {
+	switch output {
+	case "", "table":
+		return &Output{Format: OutputFormatTable}, nil
+	case "wide":
+		return &Output{Format: OutputFormatWide}, nil
+	case "json":
+		return &Output{Format: OutputFormatJSON}, nil
+	case "yaml":
+		return &Output{Format: OutputFormatYAML}, nil
+	}
+	for _, prefix := range []string{"template=", "go-template="} {
+		if strings.HasPrefix(output, prefix) {
+			tmpl, err := template.New("output").Parse(strings.TrimPrefix(output, prefix))
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse template: %s", err)
+			}
+			return &Output{Format: OutputFormatTemplate, Template: tmpl}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown output format: %s", output)
+}
+
+//IsTable returns true if output must be printed as table
+func (output *Output) IsTable() bool {
+	return output == nil || output.Format == OutputFormatTable || output.Format == OutputFormatWide
+}
+
+//IsWide returns true if output must be printed as table with additional columns
+func (output *Output) IsWide() bool {
+	return output != nil && output.Format == OutputFormatWide
+}
+
+//Print prints object or slice of objects in json, yaml or template format
+//template is executed for every element of slice
+func (output *Output) Print(w io.Writer, obj interface{}) error {
+	switch output.Format {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(obj, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case OutputFormatYAML:
+		data, err := toYAML(obj)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(w, string(data))
+		return err
+	case OutputFormatTemplate:
+		val := reflect.ValueOf(obj)
+		if val.Kind() != reflect.Slice {
+			return output.execute(w, obj)
+		}
+		for i := 0; i < val.Len(); i++ {
+			if err := output.execute(w, val.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("format %d cannot be used for print without table", output.Format)
+	}
+}
+
+func (output *Output) execute(w io.Writer, obj interface{}) error {
+	if err := output.Template.Execute(w, obj); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+//toYAML converts object to yaml through json to use the same field names and keep their order
+func toYAML(obj interface{}) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var ms yaml.MapSlice
+	var msList []yaml.MapSlice
+	if reflect.ValueOf(obj).Kind() == reflect.Slice {
+		if err = yaml.Unmarshal(data, &msList); err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(msList)
+	}
+	if err = yaml.Unmarshal(data, &ms); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(ms)
+}
@@ -1,6 +1,7 @@
 package eve
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"sort"
@@ -17,25 +18,46 @@ import (
 
 //VolInstState stores state of volumes
 type VolInstState struct {
-	Name          string
-	UUID          string
-	Image         string
-	VolumeType    config.Format
-	Size          string
-	MaxSize       string
-	AdamState     string
-	EveState      string
-	Ref           string
-	contentTreeID string
-	MountPoint    string
+	Name          string        `json:"name"`
+	UUID          string        `json:"uuid"`
+	Image         string        `json:"image"`
+	VolumeType    config.Format `json:"volumeType"`
+	Size          string        `json:"size"`
+	MaxSize       string        `json:"maxSize"`
+	AdamState     string        `json:"adamState"`
+	EveState      string        `json:"eveState"`
+	Ref           string        `json:"ref"`
+	ContentTreeID string        `json:"contentTreeID"`
+	MountPoint    string        `json:"mountPoint"`
 	deleted       bool
 }
 
-func volInstStateHeader() string {
+//MarshalJSON returns json representation of VolInstState with VolumeType as string
+func (volInstStateObj *VolInstState) MarshalJSON() ([]byte, error) {
+	type volInstState VolInstState
+	return json.Marshal(&struct {
+		*volInstState
+		VolumeType string `json:"volumeType"`
+	}{
+		volInstState: (*volInstState)(volInstStateObj),
+		VolumeType:   volInstStateObj.VolumeType.String(),
+	})
+}
+
+func volInstStateHeader(wide bool) string {
+	if wide {
+		return "NAME\tUUID\tREF\tIMAGE\tTYPE\tSIZE\tMAX_SIZE\tMOUNT\tCONTENT_TREE\tSTATE(ADAM)\tLAST_STATE(EVE)"
+	}
 	return "NAME\tUUID\tREF\tIMAGE\tTYPE\tSIZE\tMAX_SIZE\tMOUNT\tSTATE(ADAM)\tLAST_STATE(EVE)"
 }
 
-func (volInstStateObj *VolInstState) toString() string {
+func (volInstStateObj *VolInstState) toString(wide bool) string {
+	if wide {
+		return fmt.Sprintf("%s\t%s\t%s\t%s\t%v\t%s\t%s\t%s\t%s\t%s\t%s",
+			volInstStateObj.Name, volInstStateObj.UUID, volInstStateObj.Ref, volInstStateObj.Image,
+			volInstStateObj.VolumeType, volInstStateObj.Size, volInstStateObj.MaxSize, volInstStateObj.MountPoint,
+			volInstStateObj.ContentTreeID, volInstStateObj.AdamState, volInstStateObj.EveState)
+	}
 	return fmt.Sprintf("%s\t%s\t%s\t%s\t%v\t%s\t%s\t%s\t%s\t%s",
 		volInstStateObj.Name, volInstStateObj.UUID, volInstStateObj.Ref, volInstStateObj.Image,
 		volInstStateObj.VolumeType, volInstStateObj.Size, volInstStateObj.MaxSize, volInstStateObj.MountPoint,
@@ -81,7 +103,7 @@ func (ctx *State) initVolumes(ctrl controller.Cloud, dev *device.Ctx) error {
 			MaxSize:       "-",
 			MountPoint:    strings.Join(mountPoint, ";"),
 			Ref:           strings.Join(ref, ";"),
-			contentTreeID: contentTreeID,
+			ContentTreeID: contentTreeID,
 		}
 		ctx.volumes[volInstStateObj.Name] = volInstStateObj
 	}
@@ -131,7 +153,7 @@ func (ctx *State) processVolumesByInfo(im *info.ZInfoMsg) {
 	case info.ZInfoTypes_ZiContentTree:
 		infoObject := im.GetCinfo()
 		for _, el := range ctx.volumes {
-			if infoObject.Uuid == el.contentTreeID {
+			if infoObject.Uuid == el.ContentTreeID {
 				if infoObject.GetErr() != nil {
 					el.EveState = fmt.Sprintf("ERRORS: %s", infoObject.GetErr().String())
 				} else {
@@ -156,21 +178,24 @@ func (ctx *State) processVolumesByMetric(msg *metrics.ZMetricMsg) {
 	}
 }
 
-//VolumeList prints volumes
-func (ctx *State) VolumeList() error {
-	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
-	if _, err := fmt.Fprintln(w, volInstStateHeader()); err != nil {
-		return err
-	}
+//VolumeList prints volumes in defined output format
+func (ctx *State) VolumeList(output *Output) error {
 	volInstStatesSlice := make([]*VolInstState, 0, len(ctx.Volumes()))
 	volInstStatesSlice = append(volInstStatesSlice, ctx.Volumes()...)
 	sort.SliceStable(volInstStatesSlice, func(i, j int) bool {
 		return volInstStatesSlice[i].Name < volInstStatesSlice[j].Name
 	})
+	if !output.IsTable() {
+		return output.Print(os.Stdout, volInstStatesSlice)
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err := fmt.Fprintln(w, volInstStateHeader(output.IsWide())); err != nil {
+		return err
+	}
 	for _, el := range volInstStatesSlice {
 		if !el.deleted {
-			if _, err := fmt.Fprintln(w, el.toString()); err != nil {
+			if _, err := fmt.Fprintln(w, el.toString(output.IsWide())); err != nil {
 				return err
 			}
 		}
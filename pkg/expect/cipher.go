@@ -0,0 +1,142 @@
+package expect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/certs"
+	"github.com/lf-edge/eve/api/go/config"
+	log "github.com/sirupsen/logrus"
+)
+
+//cryptoConfig returns config to encrypt data with DEVICE_ECDH_EXCHANGE certificate of device
+//it returns error if there are no attestation certificates of device in controller
+func (exp *AppExpectation) cryptoConfig() (*utils.CommonCryptoConfig, error) {
+	attestData, err := exp.ctrl.CertsGet(exp.device.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("cannot get attestation certificates from cloud for %s: %s", exp.device.GetID(), err)
+	}
+	req := &types.Zcerts{}
+	if err := json.Unmarshal([]byte(attestData), req); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal attest: %v", err)
+	}
+	var cert []byte
+	for _, c := range req.Certs {
+		if c.Type == certs.ZCertType_CERT_TYPE_DEVICE_ECDH_EXCHANGE {
+			cert = c.Cert
+		}
+	}
+	if len(cert) == 0 {
+		return nil, fmt.Errorf("no DEVICE_ECDH_EXCHANGE certificate of %s in cloud", exp.device.GetID())
+	}
+	globalCertsDir, err := controllerCertsDir(exp.ctrl)
+	if err != nil {
+		return nil, err
+	}
+	cryptoConfig, err := utils.GetCommonCryptoConfig(cert, filepath.Join(globalCertsDir, "signing.pem"), filepath.Join(globalCertsDir, "signing-key.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("GetCommonCryptoConfig: %v", err)
+	}
+	return cryptoConfig, nil
+}
+
+//controllerCertsDir returns directory with signing certificate of controller
+//it is located near root certificate of controller if defined in variables
+func controllerCertsDir(ctrl controller.Cloud) (string, error) {
+	if vars := ctrl.GetVars(); vars != nil && vars.AdamCA != "" {
+		return filepath.Dir(vars.AdamCA), nil
+	}
+	edenHome, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", fmt.Errorf("DefaultEdenDir: %s", err)
+	}
+	return filepath.Join(edenHome, defaults.DefaultCertsDist), nil
+}
+
+//encryptBlock encrypts encBlock with DEVICE_ECDH_EXCHANGE certificate of device
+//it returns error if there are no attestation certificates of device in controller
+func (exp *AppExpectation) encryptBlock(encBlock *config.EncryptionBlock) (*config.CipherBlock, error) {
+	cryptoConfig, err := exp.cryptoConfig()
+	if err != nil {
+		return nil, err
+	}
+	cipherCtx, err := utils.CreateCipherCtx(cryptoConfig)
+	if err != nil {
+		return nil, fmt.Errorf("CreateCipherCtx: %v", err)
+	}
+	appendCipherCtx := true
+	for _, c := range exp.device.GetCipherContexts() {
+		// we do not change controller certificates
+		if bytes.Equal(c.DeviceCertHash, cipherCtx.DeviceCertHash) {
+			cipherCtx = c
+			appendCipherCtx = false
+		}
+	}
+	cipherBlock, err := utils.CryptoConfigWrapper(encBlock, cryptoConfig, cipherCtx)
+	if err != nil {
+		return nil, fmt.Errorf("CryptoConfigWrapper: %v", err)
+	}
+	if appendCipherCtx {
+		exp.device.SetCipherContexts(append(exp.device.GetCipherContexts(), cipherCtx))
+	}
+	return cipherBlock, nil
+}
+
+//DecryptCipherBlock decrypts block encrypted for dev with signing certificate of controller
+func DecryptCipherBlock(ctrl controller.Cloud, dev *device.Ctx, block *config.CipherBlock) (*config.EncryptionBlock, error) {
+	exp := &AppExpectation{ctrl: ctrl, device: dev}
+	cryptoConfig, err := exp.cryptoConfig()
+	if err != nil {
+		return nil, err
+	}
+	return utils.DecryptCipherBlock(block, cryptoConfig)
+}
+
+//applyDataStoreCredentials sets user and password of datastore encrypted into CipherData
+//it returns error if credentials cannot be encrypted and plaintext credentials are not allowed
+func (exp *AppExpectation) applyDataStoreCredentials(ds *config.DatastoreConfig, user, password string) error {
+	if user == "" && password == "" {
+		return nil
+	}
+	cipherBlock, err := exp.encryptBlock(&config.EncryptionBlock{DsAPIKey: user, DsPassword: password})
+	if err != nil {
+		if !exp.plaintextCredentials {
+			return fmt.Errorf("cannot encrypt credentials for %s: %s (allow plaintext credentials to send them without encryption)", ds.Fqdn, err)
+		}
+		log.Warnf("%s, will use plaintext credentials for %s", err, ds.Fqdn)
+		ds.ApiKey = user
+		ds.Password = password
+		return nil
+	}
+	ds.CipherData = cipherBlock
+	return nil
+}
+
+//checkDataStoreCredentials checks if user and password of datastore are the same as provided
+//encrypted credentials are decrypted to compare
+func (exp *AppExpectation) checkDataStoreCredentials(ds *config.DatastoreConfig, user, password string) bool {
+	if ds.CipherData == nil {
+		return ds.ApiKey == user && ds.Password == password
+	}
+	if ds.ApiKey != "" || ds.Password != "" {
+		return false
+	}
+	cryptoConfig, err := exp.cryptoConfig()
+	if err != nil {
+		log.Debugf("cannot check credentials of datastore %s: %s", ds.Id, err)
+		return false
+	}
+	encBlock, err := utils.DecryptCipherBlock(ds.CipherData, cryptoConfig)
+	if err != nil {
+		log.Debugf("cannot decrypt credentials of datastore %s: %s", ds.Id, err)
+		return false
+	}
+	return encBlock.DsAPIKey == user && encBlock.DsPassword == password
+}
@@ -19,6 +19,8 @@ func (exp *AppExpectation) checkDataStore(ds *config.DatastoreConfig) bool {
 		return exp.checkDataStoreHTTP(ds)
 	case directoryApp:
 		return exp.checkDataStoreDirectory(ds)
+	case s3App, azureApp:
+		return exp.checkDataStoreObjectStore(ds)
 	}
 	return false
 }
@@ -31,7 +33,7 @@ func (exp *AppExpectation) createDataStore() (*config.DatastoreConfig, error) {
 	}
 	switch exp.appType {
 	case dockerApp:
-		return exp.createDataStoreDocker(id), nil
+		return exp.createDataStoreDocker(id)
 	case httpApp, httpsApp, fileApp:
 		if exp.sftpLoad {
 			return exp.createDataStoreSFTP(id), nil
@@ -39,6 +41,8 @@ func (exp *AppExpectation) createDataStore() (*config.DatastoreConfig, error) {
 		return exp.createDataStoreHTTP(id), nil
 	case directoryApp:
 		return exp.createDataStoreDirectory(id), nil
+	case s3App, azureApp:
+		return exp.createDataStoreObjectStore(id)
 	default:
 		return nil, fmt.Errorf("not supported appType")
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
@@ -59,17 +60,55 @@ func (exp *AppExpectation) getDataStoreFQDN(withProto bool) string {
 	return fqdn
 }
 
+//getRegistryCredentials returns user and password to access registry of datastore
+//it uses credentials from AppExpectation or from docker config (~/.docker/config.json) if not provided and enabled
+func (exp *AppExpectation) getRegistryCredentials() (user, password string) {
+	if exp.registryUser != "" || exp.registryPassword != "" {
+		return exp.registryUser, exp.registryPassword
+	}
+	if !exp.dockerConfigCredentials {
+		return "", ""
+	}
+	reg, err := name.NewRegistry(exp.getDataStoreFQDN(false))
+	if err != nil {
+		return "", ""
+	}
+	auth, err := authn.DefaultKeychain.Resolve(reg)
+	if err != nil {
+		log.Debugf("cannot resolve credentials for %s: %s", reg, err)
+		return "", ""
+	}
+	authConfig, err := auth.Authorization()
+	if err != nil {
+		log.Debugf("cannot get credentials for %s: %s", reg, err)
+		return "", ""
+	}
+	return authConfig.Username, authConfig.Password
+}
+
+//craneOptions returns options to access registry with resolved credentials
+func (exp *AppExpectation) craneOptions() []crane.Option {
+	user, password := exp.getRegistryCredentials()
+	if user == "" && password == "" {
+		return nil
+	}
+	return []crane.Option{crane.WithAuth(&authn.Basic{Username: user, Password: password})}
+}
+
 //checkDataStoreDocker checks if provided ds match expectation
+//datastore must have the same credentials as resolved for registry
 func (exp *AppExpectation) checkDataStoreDocker(ds *config.DatastoreConfig) bool {
-	if ds.DType == config.DsType_DsContainerRegistry && ds.Fqdn == exp.getDataStoreFQDN(true) {
-		return true
+	if ds.DType != config.DsType_DsContainerRegistry || ds.Fqdn != exp.getDataStoreFQDN(true) {
+		return false
 	}
-	return false
+	user, password := exp.getRegistryCredentials()
+	return exp.checkDataStoreCredentials(ds, user, password)
 }
 
 //createDataStoreDocker creates DatastoreConfig for docker.io with provided id
-func (exp *AppExpectation) createDataStoreDocker(id uuid.UUID) *config.DatastoreConfig {
-	return &config.DatastoreConfig{
+//credentials of registry are encrypted into CipherData with certificate of device
+func (exp *AppExpectation) createDataStoreDocker(id uuid.UUID) (*config.DatastoreConfig, error) {
+	ds := &config.DatastoreConfig{
 		Id:         id.String(),
 		DType:      config.DsType_DsContainerRegistry,
 		Fqdn:       exp.getDataStoreFQDN(true),
@@ -79,6 +118,11 @@ func (exp *AppExpectation) createDataStoreDocker(id uuid.UUID) *config.Datastore
 		Region:     "",
 		CipherData: nil,
 	}
+	user, password := exp.getRegistryCredentials()
+	if err := exp.applyDataStoreCredentials(ds, user, password); err != nil {
+		return nil, err
+	}
+	return ds, nil
 }
 
 //applyRootFSType try to parse manifest to get Annotations provided in https://github.com/lf-edge/edge-containers/blob/master/docs/annotations.md
@@ -88,7 +132,7 @@ func (exp *AppExpectation) applyRootFSType(image *config.Image) error {
 		return nil
 	}
 	ref := fmt.Sprintf("%s/%s", exp.getDataStoreFQDN(false), image.Name)
-	manifest, err := crane.Manifest(ref)
+	manifest, err := crane.Manifest(ref, exp.craneOptions()...)
 	if err != nil {
 		return err
 	}
@@ -129,7 +173,7 @@ func (exp *AppExpectation) obtainVolumeInfo(image *config.Image) ([]string, erro
 		return nil, nil
 	}
 	ref := fmt.Sprintf("%s/%s", exp.getDataStoreFQDN(false), image.Name)
-	cfg, err := crane.Config(ref)
+	cfg, err := crane.Config(ref, exp.craneOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("error getting config %s: %v", image.Name, err)
 	}
@@ -0,0 +1,136 @@
+package expect
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/config"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+//getObjectLocation returns bucket (container for azure) and key (blob for azure) of image
+func (exp *AppExpectation) getObjectLocation() (bucket, key string) {
+	splitted := strings.SplitN(exp.appURL, "/", 2)
+	if len(splitted) < 2 || splitted[1] == "" {
+		log.Fatalf("cannot parse <bucket>/<key> from %s", exp.appLink)
+	}
+	return splitted[0], splitted[1]
+}
+
+//getObjectStoreDsType returns type of datastore for object storage
+func (exp *AppExpectation) getObjectStoreDsType() config.DsType {
+	if exp.appType == azureApp {
+		return config.DsType_DsAzureBlob
+	}
+	return config.DsType_DsS3
+}
+
+//objectStorage is client of object storage, bucket is container and key is blob for Azure blob storage
+type objectStorage interface {
+	MakeBucket(bucket string) error
+	PutObject(bucket, key, filePath string) error
+	GetObject(bucket, key string) (io.ReadCloser, error)
+}
+
+//objectStore returns client of Azure blob storage for azure:// images
+//or client of S3 compatible object storage for s3:// images defined in config
+func (exp *AppExpectation) objectStore() objectStorage {
+	vars := exp.ctrl.GetVars()
+	if exp.appType == azureApp {
+		store, err := utils.NewBlobStore(vars.AzureEndpoint, vars.AzureAccount, vars.AzureKey)
+		if err != nil {
+			log.Fatalf("cannot create client of Azure blob storage: %s", err)
+		}
+		return store
+	}
+	return utils.NewObjectStore(vars.ObjectStoreEndpoint, vars.ObjectStoreRegion, vars.ObjectStoreAccessKey, vars.ObjectStoreSecretKey)
+}
+
+//objectStoreDataStore returns endpoint for EVE, region and credentials of object storage for datastore
+func (exp *AppExpectation) objectStoreDataStore() (fqdn, region, user, password string) {
+	vars := exp.ctrl.GetVars()
+	if exp.appType == azureApp {
+		return vars.AzureEVEEndpoint, "", vars.AzureAccount, vars.AzureKey
+	}
+	return vars.ObjectStoreEVEEndpoint, vars.ObjectStoreRegion, vars.ObjectStoreAccessKey, vars.ObjectStoreSecretKey
+}
+
+//createImageObjectStore uploads image into object storage if source file defined and calculates size and sha256 of image
+func (exp *AppExpectation) createImageObjectStore(id uuid.UUID, dsID string) *config.Image {
+	store := exp.objectStore()
+	bucket, key := exp.getObjectLocation()
+	if exp.objectSource != "" {
+		log.Infof("Start uploading of %s into %s", exp.objectSource, exp.appLink)
+		if err := store.MakeBucket(bucket); err != nil {
+			log.Fatalf("cannot create bucket %s: %s", bucket, err)
+		}
+		if err := store.PutObject(bucket, key, exp.objectSource); err != nil {
+			log.Fatalf("cannot upload %s: %s", exp.objectSource, err)
+		}
+	}
+	object, err := store.GetObject(bucket, key)
+	if err != nil {
+		log.Fatalf("cannot get %s: %s", exp.appLink, err)
+	}
+	defer object.Close()
+	h := sha256.New()
+	fileSize, err := io.Copy(h, object)
+	if err != nil {
+		log.Fatalf("cannot read %s: %s", exp.appLink, err)
+	}
+	sha256 := hex.EncodeToString(h.Sum(nil))
+	log.Infof("Image in object storage has size %s and sha256 %s", humanize.Bytes(uint64(fileSize)), sha256)
+	return &config.Image{
+		Uuidandversion: &config.UUIDandVersion{
+			Uuid:    id.String(),
+			Version: "1",
+		},
+		Name:      key,
+		Iformat:   exp.imageFormatEnum(),
+		DsId:      dsID,
+		SizeBytes: fileSize,
+		Sha256:    sha256,
+	}
+}
+
+//checkImageObjectStore checks if provided img match expectation
+func (exp *AppExpectation) checkImageObjectStore(img *config.Image, dsID string) bool {
+	_, key := exp.getObjectLocation()
+	return img.DsId == dsID && img.Name == key
+}
+
+//checkDataStoreObjectStore checks if provided ds match expectation
+func (exp *AppExpectation) checkDataStoreObjectStore(ds *config.DatastoreConfig) bool {
+	bucket, _ := exp.getObjectLocation()
+	fqdn, region, user, password := exp.objectStoreDataStore()
+	return ds.DType == exp.getObjectStoreDsType() &&
+		ds.Fqdn == fqdn &&
+		ds.Dpath == bucket &&
+		ds.Region == region &&
+		exp.checkDataStoreCredentials(ds, user, password)
+}
+
+//createDataStoreObjectStore creates datastore, pointed onto bucket (container for azure) of object storage
+func (exp *AppExpectation) createDataStoreObjectStore(id uuid.UUID) (*config.DatastoreConfig, error) {
+	bucket, _ := exp.getObjectLocation()
+	fqdn, region, user, password := exp.objectStoreDataStore()
+	ds := &config.DatastoreConfig{
+		Id:         id.String(),
+		DType:      exp.getObjectStoreDsType(),
+		Fqdn:       fqdn,
+		ApiKey:     "",
+		Password:   "",
+		Dpath:      bucket,
+		Region:     region,
+		CipherData: nil,
+	}
+	if err := exp.applyDataStoreCredentials(ds, user, password); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
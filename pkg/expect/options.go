@@ -208,6 +208,37 @@ func WithRegistry(registry string) ExpectationOption {
 	}
 }
 
+//WithRegistryCredentials sets user and password to access registry with images
+func WithRegistryCredentials(user, password string) ExpectationOption {
+	return func(expectation *AppExpectation) {
+		expectation.registryUser = user
+		expectation.registryPassword = password
+	}
+}
+
+//WithDockerConfigCredentials enables use of credentials for registry from docker config
+//if they are not set with WithRegistryCredentials
+func WithDockerConfigCredentials(enabled bool) ExpectationOption {
+	return func(expectation *AppExpectation) {
+		expectation.dockerConfigCredentials = enabled
+	}
+}
+
+//WithPlaintextCredentials allows to send credentials of datastore without encryption
+//if device has no certificate to encrypt them
+func WithPlaintextCredentials(allowed bool) ExpectationOption {
+	return func(expectation *AppExpectation) {
+		expectation.plaintextCredentials = allowed
+	}
+}
+
+//WithObjectSource sets file to upload into object storage for s3:// and azure:// links
+func WithObjectSource(path string) ExpectationOption {
+	return func(expectation *AppExpectation) {
+		expectation.objectSource = path
+	}
+}
+
 //WithOldApp sets old app name to get info from
 func WithOldApp(appName string) ExpectationOption {
 	return func(expectation *AppExpectation) {
@@ -0,0 +1,271 @@
+package lan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/ipv4"
+)
+
+//DHCP message types
+const (
+	DHCPDiscover byte = 1
+	DHCPOffer    byte = 2
+	DHCPRequest  byte = 3
+	DHCPDecline  byte = 4
+	DHCPAck      byte = 5
+	DHCPNak      byte = 6
+	DHCPRelease  byte = 7
+	DHCPInform   byte = 8
+)
+
+//DHCP options
+const (
+	optSubnetMask     byte = 1
+	optRouter         byte = 3
+	optDNS            byte = 6
+	optHostname       byte = 12
+	optRequestedIP    byte = 50
+	optLeaseTime      byte = 51
+	optMessageType    byte = 53
+	optServerID       byte = 54
+	optRenewalTime    byte = 58
+	optRebindTime     byte = 59
+	optPad            byte = 0
+	optEnd            byte = 255
+	dhcpHeaderLen          = 236
+	dhcpMagicCookie        = 0x63825363
+	dhcpBroadcastFlag      = 0x8000
+	dhcpServerPort         = 67
+	dhcpClientPort         = 68
+)
+
+//DHCPPacket is BOOTP message with DHCP options
+type DHCPPacket struct {
+	Op      byte
+	XID     uint32
+	Flags   uint16
+	CIAddr  net.IP
+	YIAddr  net.IP
+	SIAddr  net.IP
+	GIAddr  net.IP
+	CHAddr  net.HardwareAddr
+	Options map[byte][]byte
+}
+
+//MessageType returns type of DHCP message
+func (p *DHCPPacket) MessageType() byte {
+	if v := p.Options[optMessageType]; len(v) == 1 {
+		return v[0]
+	}
+	return 0
+}
+
+//ParseDHCP parses DHCP message from data
+func ParseDHCP(data []byte) (*DHCPPacket, error) {
+	if len(data) < dhcpHeaderLen+4 {
+		return nil, fmt.Errorf("too short DHCP packet: %d bytes", len(data))
+	}
+	if binary.BigEndian.Uint32(data[dhcpHeaderLen:]) != dhcpMagicCookie {
+		return nil, fmt.Errorf("no DHCP magic cookie")
+	}
+	hLen := int(data[2])
+	if hLen > 16 {
+		return nil, fmt.Errorf("wrong hardware address length: %d", hLen)
+	}
+	p := &DHCPPacket{
+		Op:      data[0],
+		XID:     binary.BigEndian.Uint32(data[4:8]),
+		Flags:   binary.BigEndian.Uint16(data[10:12]),
+		CIAddr:  net.IP(append([]byte{}, data[12:16]...)),
+		YIAddr:  net.IP(append([]byte{}, data[16:20]...)),
+		SIAddr:  net.IP(append([]byte{}, data[20:24]...)),
+		GIAddr:  net.IP(append([]byte{}, data[24:28]...)),
+		CHAddr:  net.HardwareAddr(append([]byte{}, data[28:28+hLen]...)),
+		Options: make(map[byte][]byte),
+	}
+	options := data[dhcpHeaderLen+4:]
+	for i := 0; i < len(options); {
+		code := options[i]
+		if code == optEnd {
+			break
+		}
+		if code == optPad {
+			i++
+			continue
+		}
+		if i+1 >= len(options) || i+2+int(options[i+1]) > len(options) {
+			return nil, fmt.Errorf("truncated DHCP option %d", code)
+		}
+		length := int(options[i+1])
+		p.Options[code] = append(p.Options[code], options[i+2:i+2+length]...)
+		i += 2 + length
+	}
+	return p, nil
+}
+
+//Marshal returns DHCP message as bytes
+func (p *DHCPPacket) Marshal() []byte {
+	data := make([]byte, dhcpHeaderLen+4)
+	data[0] = p.Op
+	data[1] = 1 //ethernet
+	data[2] = byte(len(p.CHAddr))
+	binary.BigEndian.PutUint32(data[4:8], p.XID)
+	binary.BigEndian.PutUint16(data[10:12], p.Flags)
+	for i, ip := range []net.IP{p.CIAddr, p.YIAddr, p.SIAddr, p.GIAddr} {
+		if ip4 := ip.To4(); ip4 != nil {
+			copy(data[12+i*4:], ip4)
+		}
+	}
+	copy(data[28:44], p.CHAddr)
+	binary.BigEndian.PutUint32(data[dhcpHeaderLen:], dhcpMagicCookie)
+	var codes []int
+	for code := range p.Options {
+		codes = append(codes, int(code))
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		value := p.Options[byte(code)]
+		for len(value) > 255 {
+			data = append(data, byte(code), 255)
+			data = append(data, value[:255]...)
+			value = value[255:]
+		}
+		data = append(data, byte(code), byte(len(value)))
+		data = append(data, value...)
+	}
+	return append(data, optEnd)
+}
+
+func uint32Option(v uint32) []byte {
+	result := make([]byte, 4)
+	binary.BigEndian.PutUint32(result, v)
+	return result
+}
+
+//HandleDHCP returns reply to DHCP request or nil if no reply needed
+func (s *Server) HandleDHCP(req *DHCPPacket) (*DHCPPacket, error) {
+	if req.Op != 1 {
+		return nil, nil
+	}
+	if serverID := req.Options[optServerID]; len(serverID) == 4 && !net.IP(serverID).Equal(s.cfg.Gateway) {
+		//request to another server
+		return nil, nil
+	}
+	hostname := string(req.Options[optHostname])
+	var requested net.IP
+	if v := req.Options[optRequestedIP]; len(v) == 4 {
+		requested = net.IP(v)
+	} else if !req.CIAddr.IsUnspecified() {
+		requested = req.CIAddr
+	}
+	reply := &DHCPPacket{
+		Op:     2,
+		XID:    req.XID,
+		Flags:  req.Flags,
+		SIAddr: s.cfg.Gateway,
+		GIAddr: req.GIAddr,
+		CHAddr: req.CHAddr,
+		Options: map[byte][]byte{
+			optServerID:   s.cfg.Gateway.To4(),
+			optSubnetMask: s.cfg.Subnet.Mask,
+			optRouter:     s.cfg.Gateway.To4(),
+			optDNS:        s.cfg.Gateway.To4(),
+		},
+	}
+	switch req.MessageType() {
+	case DHCPDiscover:
+		lease, err := s.Lease(req.CHAddr, requested, hostname)
+		if err != nil {
+			return nil, err
+		}
+		reply.YIAddr = net.ParseIP(lease.IP)
+		reply.Options[optMessageType] = []byte{DHCPOffer}
+	case DHCPRequest:
+		lease, err := s.Lease(req.CHAddr, requested, hostname)
+		if err != nil {
+			return nil, err
+		}
+		if requested != nil && !requested.Equal(net.ParseIP(lease.IP)) {
+			log.Infof("DHCP: NAK %s for %s, leased %s", requested, req.CHAddr, lease.IP)
+			return &DHCPPacket{
+				Op:      2,
+				XID:     req.XID,
+				Flags:   req.Flags | dhcpBroadcastFlag,
+				GIAddr:  req.GIAddr,
+				CHAddr:  req.CHAddr,
+				Options: map[byte][]byte{optMessageType: {DHCPNak}, optServerID: s.cfg.Gateway.To4()},
+			}, nil
+		}
+		log.Infof("DHCP: leased %s to %s (%s)", lease.IP, req.CHAddr, lease.Hostname)
+		reply.YIAddr = net.ParseIP(lease.IP)
+		reply.CIAddr = req.CIAddr
+		reply.Options[optMessageType] = []byte{DHCPAck}
+	case DHCPInform:
+		reply.CIAddr = req.CIAddr
+		reply.Options[optMessageType] = []byte{DHCPAck}
+		return reply, nil
+	case DHCPRelease, DHCPDecline:
+		log.Infof("DHCP: released address of %s", req.CHAddr)
+		s.Release(req.CHAddr)
+		return nil, nil
+	default:
+		return nil, nil
+	}
+	leaseTime := uint32(s.cfg.LeaseTime.Seconds())
+	reply.Options[optLeaseTime] = uint32Option(leaseTime)
+	reply.Options[optRenewalTime] = uint32Option(leaseTime / 2)
+	reply.Options[optRebindTime] = uint32Option(leaseTime / 8 * 7)
+	return reply, nil
+}
+
+//serveDHCP answers DHCP requests received from bridge
+func (s *Server) serveDHCP() error {
+	iface, err := net.InterfaceByName(s.cfg.Bridge)
+	if err != nil {
+		return fmt.Errorf("serveDHCP: %s", err)
+	}
+	conn, err := net.ListenPacket("udp4", fmt.Sprintf(":%d", dhcpServerPort))
+	if err != nil {
+		return fmt.Errorf("serveDHCP: %s", err)
+	}
+	defer conn.Close()
+	pc := ipv4.NewPacketConn(conn)
+	if err = pc.SetControlMessage(ipv4.FlagInterface, true); err != nil {
+		return fmt.Errorf("serveDHCP: %s", err)
+	}
+	log.Infof("DHCP responder listening on %s", iface.Name)
+	buf := make([]byte, 1500)
+	for {
+		n, cm, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			return fmt.Errorf("serveDHCP: %s", err)
+		}
+		if cm != nil && cm.IfIndex != iface.Index {
+			continue
+		}
+		req, err := ParseDHCP(buf[:n])
+		if err != nil {
+			log.Debugf("DHCP: %s", err)
+			continue
+		}
+		reply, err := s.HandleDHCP(req)
+		if err != nil {
+			log.Errorf("DHCP: %s", err)
+			continue
+		}
+		if reply == nil {
+			continue
+		}
+		dst := &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpClientPort}
+		if !req.CIAddr.IsUnspecified() && reply.Flags&dhcpBroadcastFlag == 0 {
+			dst.IP = req.CIAddr
+		}
+		if _, err = pc.WriteTo(reply.Marshal(), &ipv4.ControlMessage{IfIndex: iface.Index}, dst); err != nil {
+			log.Errorf("DHCP: cannot send reply to %s: %s", req.CHAddr, err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package lan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	dnsHeaderLen      = 12
+	dnsTypeA          = 1
+	dnsClassIN        = 1
+	dnsPort           = 53
+	dnsTTL            = 60
+	dnsForwardTimeout = 5 * time.Second
+)
+
+//UpstreamDNS returns first nameserver from resolvConf in host:port format or 8.8.8.8:53 if not found
+func UpstreamDNS(resolvConf string) string {
+	if data, err := ioutil.ReadFile(resolvConf); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) > 1 && fields[0] == "nameserver" && net.ParseIP(fields[1]) != nil {
+				return net.JoinHostPort(fields[1], fmt.Sprint(dnsPort))
+			}
+		}
+	}
+	return net.JoinHostPort("8.8.8.8", fmt.Sprint(dnsPort))
+}
+
+//parseQuestion returns name, type and class of the first question of DNS query and offset of its end
+func parseQuestion(query []byte) (string, uint16, uint16, int, error) {
+	if len(query) < dnsHeaderLen {
+		return "", 0, 0, 0, fmt.Errorf("too short DNS query: %d bytes", len(query))
+	}
+	var labels []string
+	i := dnsHeaderLen
+	for {
+		if i >= len(query) {
+			return "", 0, 0, 0, fmt.Errorf("truncated DNS query")
+		}
+		length := int(query[i])
+		if length == 0 {
+			i++
+			break
+		}
+		if length > 63 || i+1+length > len(query) {
+			return "", 0, 0, 0, fmt.Errorf("wrong label in DNS query")
+		}
+		labels = append(labels, string(query[i+1:i+1+length]))
+		i += 1 + length
+	}
+	if i+4 > len(query) {
+		return "", 0, 0, 0, fmt.Errorf("truncated DNS question")
+	}
+	return strings.Join(labels, "."), binary.BigEndian.Uint16(query[i:]), binary.BigEndian.Uint16(query[i+2:]), i + 4, nil
+}
+
+//HandleDNS returns answer to DNS query for known names or nil if query must be forwarded upstream
+func (s *Server) HandleDNS(query []byte) ([]byte, error) {
+	if len(query) < dnsHeaderLen {
+		return nil, fmt.Errorf("too short DNS query: %d bytes", len(query))
+	}
+	//only standard queries with one question
+	if query[2]&0xf8 != 0 || binary.BigEndian.Uint16(query[4:6]) != 1 {
+		return nil, nil
+	}
+	name, qType, qClass, end, err := parseQuestion(query)
+	if err != nil {
+		return nil, err
+	}
+	if qClass != dnsClassIN {
+		return nil, nil
+	}
+	ip := s.Lookup(name)
+	if ip == nil || ip.To4() == nil {
+		return nil, nil
+	}
+	answer := make([]byte, end, end+16)
+	copy(answer, query[:end])
+	answer[2] = 0x84 | query[2]&0x01 //response, authoritative, keep recursion desired
+	answer[3] = 0x80                 //recursion available, no error
+	binary.BigEndian.PutUint16(answer[8:10], 0)
+	binary.BigEndian.PutUint16(answer[10:12], 0)
+	if qType != dnsTypeA {
+		//name exists, but has no records of requested type
+		binary.BigEndian.PutUint16(answer[6:8], 0)
+		return answer, nil
+	}
+	binary.BigEndian.PutUint16(answer[6:8], 1)
+	record := make([]byte, 16)
+	binary.BigEndian.PutUint16(record[0:2], 0xc000|dnsHeaderLen) //pointer to name in question
+	binary.BigEndian.PutUint16(record[2:4], dnsTypeA)
+	binary.BigEndian.PutUint16(record[4:6], dnsClassIN)
+	binary.BigEndian.PutUint32(record[6:10], dnsTTL)
+	binary.BigEndian.PutUint16(record[10:12], net.IPv4len)
+	copy(record[12:], ip.To4())
+	return append(answer, record...), nil
+}
+
+//forwardDNS sends query to upstream server and returns its answer
+func (s *Server) forwardDNS(query []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", s.cfg.Upstream, dnsForwardTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(dnsForwardTimeout)); err != nil {
+		return nil, err
+	}
+	if _, err = conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+//serveDNS answers DNS queries received on address of gateway
+func (s *Server) serveDNS() error {
+	conn, err := net.ListenPacket("udp4", net.JoinHostPort(s.cfg.Gateway.String(), fmt.Sprint(dnsPort)))
+	if err != nil {
+		return fmt.Errorf("serveDNS: %s", err)
+	}
+	defer conn.Close()
+	log.Infof("DNS responder listening on %s", conn.LocalAddr())
+	for {
+		buf := make([]byte, 1500)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return fmt.Errorf("serveDNS: %s", err)
+		}
+		go func(query []byte, addr net.Addr) {
+			answer, err := s.HandleDNS(query)
+			if err != nil {
+				log.Debugf("DNS: %s", err)
+				return
+			}
+			if answer == nil {
+				if s.cfg.Upstream == "" {
+					return
+				}
+				if answer, err = s.forwardDNS(query); err != nil {
+					log.Debugf("DNS: cannot forward query of %s: %s", addr, err)
+					return
+				}
+			}
+			if _, err = conn.WriteTo(answer, addr); err != nil {
+				log.Debugf("DNS: cannot send answer to %s: %s", addr, err)
+			}
+		}(buf[:n], addr)
+	}
+}
@@ -0,0 +1,207 @@
+//Package lan provides DHCP and DNS responders for virtual LAN of EVEs running in qemu
+package lan
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//Lease is address assigned to device with MAC
+type Lease struct {
+	MAC      string
+	IP       string
+	Hostname string `json:",omitempty"`
+	Expire   time.Time
+}
+
+//Config contains settings of responders
+type Config struct {
+	Bridge     string            //name of bridge to serve DHCP on
+	Subnet     *net.IPNet        //subnet of LAN
+	Gateway    net.IP            //address of bridge, used as router and DNS server
+	Upstream   string            //DNS server to forward requests to in host:port format
+	Hosts      map[string]net.IP //static names resolved by DNS responder
+	LeasesFile string            //file to store leases
+	LeaseTime  time.Duration
+}
+
+//Server serves DHCP and DNS requests inside LAN
+type Server struct {
+	cfg    *Config
+	mu     sync.Mutex
+	leases map[string]*Lease
+}
+
+//Gateway returns first address of subnet to use as address of bridge
+func Gateway(subnet *net.IPNet) net.IP {
+	return addToIP(subnet.IP.Mask(subnet.Mask), 1)
+}
+
+func addToIP(ip net.IP, n uint32) net.IP {
+	result := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(result, binary.BigEndian.Uint32(ip.To4())+n)
+	return result
+}
+
+//NewServer returns Server with leases loaded from cfg.LeasesFile
+func NewServer(cfg *Config) (*Server, error) {
+	if cfg.Subnet == nil || cfg.Subnet.IP.To4() == nil {
+		return nil, fmt.Errorf("IPv4 subnet required")
+	}
+	if cfg.Gateway == nil {
+		cfg.Gateway = Gateway(cfg.Subnet)
+	}
+	s := &Server{cfg: cfg, leases: make(map[string]*Lease)}
+	if cfg.LeasesFile != "" {
+		leases, err := LoadLeases(cfg.LeasesFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, el := range leases {
+			s.leases[el.MAC] = el
+		}
+	}
+	return s, nil
+}
+
+//LoadLeases reads leases from file
+func LoadLeases(fileName string) ([]*Lease, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var leases []*Lease
+	if err = json.Unmarshal(data, &leases); err != nil {
+		return nil, fmt.Errorf("cannot parse leases from %s: %s", fileName, err)
+	}
+	return leases, nil
+}
+
+//saveLeases writes leases into file, must be called with locked mutex
+func (s *Server) saveLeases() {
+	if s.cfg.LeasesFile == "" {
+		return
+	}
+	var leases []*Lease
+	for _, el := range s.leases {
+		leases = append(leases, el)
+	}
+	sort.Slice(leases, func(i, j int) bool { return leases[i].IP < leases[j].IP })
+	data, err := json.MarshalIndent(leases, "", "    ")
+	if err != nil {
+		log.Errorf("cannot marshal leases: %s", err)
+		return
+	}
+	if err = ioutil.WriteFile(s.cfg.LeasesFile, data, 0644); err != nil {
+		log.Errorf("cannot save leases: %s", err)
+	}
+}
+
+//usable returns true if ip may be assigned to device
+func (s *Server) usable(ip net.IP) bool {
+	if ip == nil || ip.To4() == nil || !s.cfg.Subnet.Contains(ip) || ip.Equal(s.cfg.Gateway) {
+		return false
+	}
+	network := s.cfg.Subnet.IP.Mask(s.cfg.Subnet.Mask)
+	ones, bits := s.cfg.Subnet.Mask.Size()
+	broadcast := addToIP(network, 1<<uint(bits-ones)-1)
+	return !ip.Equal(network) && !ip.Equal(broadcast)
+}
+
+//owner returns MAC of device with active lease of ip, must be called with locked mutex
+func (s *Server) owner(ip net.IP) string {
+	for mac, el := range s.leases {
+		if el.IP == ip.String() && time.Now().Before(el.Expire) {
+			return mac
+		}
+	}
+	return ""
+}
+
+//Lease returns lease for device with mac, existing lease is extended
+//requested address is used for new lease if it is free
+func (s *Server) Lease(mac net.HardwareAddr, requested net.IP, hostname string) (*Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := mac.String()
+	lease, ok := s.leases[key]
+	if !ok {
+		var ip net.IP
+		if s.usable(requested) && s.owner(requested) == "" {
+			ip = requested
+		} else {
+			ones, bits := s.cfg.Subnet.Mask.Size()
+			network := s.cfg.Subnet.IP.Mask(s.cfg.Subnet.Mask)
+			for i := uint32(1); i < 1<<uint(bits-ones); i++ {
+				candidate := addToIP(network, i)
+				if s.usable(candidate) && s.owner(candidate) == "" {
+					ip = candidate
+					break
+				}
+			}
+		}
+		if ip == nil {
+			return nil, fmt.Errorf("no free addresses in %s", s.cfg.Subnet)
+		}
+		//drop expired lease of address
+		for k, el := range s.leases {
+			if el.IP == ip.String() {
+				delete(s.leases, k)
+			}
+		}
+		lease = &Lease{MAC: key, IP: ip.String()}
+		s.leases[key] = lease
+	}
+	if hostname != "" {
+		lease.Hostname = hostname
+	}
+	lease.Expire = time.Now().Add(s.cfg.LeaseTime)
+	s.saveLeases()
+	return lease, nil
+}
+
+//Release removes lease of device with mac
+func (s *Server) Release(mac net.HardwareAddr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.leases, mac.String())
+	s.saveLeases()
+}
+
+//Lookup returns address of host with name from static hosts or hostnames of leases
+func (s *Server) Lookup(name string) net.IP {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	if ip, ok := s.cfg.Hosts[name]; ok {
+		return ip
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, el := range s.leases {
+		if strings.ToLower(el.Hostname) == name && time.Now().Before(el.Expire) {
+			return net.ParseIP(el.IP)
+		}
+	}
+	return nil
+}
+
+//Serve runs DHCP and DNS responders and returns on first error
+func (s *Server) Serve() error {
+	errCh := make(chan error, 2)
+	go func() {
+		errCh <- s.serveDHCP()
+	}()
+	go func() {
+		errCh <- s.serveDNS()
+	}()
+	return <-errCh
+}
@@ -0,0 +1,311 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/expect"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/config"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+//Applier applies changes from Diff to device
+type Applier struct {
+	Ctrl controller.Cloud
+	Dev  *device.Ctx
+	//LocalRegistry is address of registry to use for objects with 'registry: local'
+	LocalRegistry string
+}
+
+//Apply moves device from current state to desired one with provided changes
+//it only modifies config of device inside controller, caller must save it
+func (a *Applier) Apply(desired, current *Manifest, changes []Change) error {
+	byAction := func(kind Kind, check func(c Change) bool) (names []string) {
+		for _, c := range changes {
+			if c.Kind == kind && check(c) {
+				names = append(names, c.Name)
+			}
+		}
+		return
+	}
+	toRemove := func(c Change) bool { return c.Action == ActionRemove || c.recreate }
+	toCreate := func(c Change) bool { return c.Action == ActionAdd || c.recreate }
+
+	for _, c := range changes {
+		switch c.Kind {
+		case KindDatastore:
+			if err := a.applyDatastore(desired, c); err != nil {
+				return err
+			}
+		case KindConfigItem:
+			a.Dev.SetConfigItem(c.Name, desired.ConfigItems[c.Name])
+		}
+	}
+	for _, name := range byAction(KindApp, toRemove) {
+		if err := a.removeApp(desired, name); err != nil {
+			return err
+		}
+	}
+	for _, name := range byAction(KindNetwork, toRemove) {
+		if err := a.removeNetwork(name); err != nil {
+			return err
+		}
+	}
+	for _, name := range byAction(KindVolume, toRemove) {
+		if err := a.removeVolume(name); err != nil {
+			return err
+		}
+	}
+	for _, name := range byAction(KindNetwork, toCreate) {
+		a.createNetwork(desired.network(name))
+	}
+	for _, name := range byAction(KindVolume, toCreate) {
+		if err := a.createVolume(desired.volume(name)); err != nil {
+			return err
+		}
+	}
+	for _, name := range byAction(KindApp, toCreate) {
+		app := desired.app(name)
+		networks := app.Networks
+		if cur := current.app(name); len(networks) == 0 && cur != nil {
+			//keep current networks of app if not defined in manifest
+			networks = cur.Networks
+		}
+		if err := a.createApp(app, networks); err != nil {
+			return err
+		}
+	}
+	for _, c := range changes {
+		if c.Kind != KindApp || c.Action != ActionUpdate || c.recreate {
+			continue
+		}
+		_, app, err := a.findApp(c.Name)
+		if err != nil {
+			return err
+		}
+		if app != nil {
+			app.Activate = !desired.app(c.Name).Stopped
+		}
+	}
+	return nil
+}
+
+func (a *Applier) applyDatastore(desired *Manifest, c Change) error {
+	var dsManifest *DatastoreManifest
+	for i := range desired.Datastores {
+		if dsKey(desired.Datastores[i]) == c.Name {
+			dsManifest = &desired.Datastores[i]
+			break
+		}
+	}
+	if dsManifest == nil {
+		return fmt.Errorf("no datastore %s in manifest", c.Name)
+	}
+	dsType, err := dsTypeByName(dsManifest.Type)
+	if err != nil {
+		return err
+	}
+	for _, ds := range a.Ctrl.ListDataStore() {
+		if ds.DType == dsType && ds.Fqdn == dsManifest.FQDN && ds.Dpath == dsManifest.Dir {
+			ds.ApiKey = dsManifest.APIKey
+			ds.Password = dsManifest.Password
+			ds.Region = dsManifest.Region
+			return nil
+		}
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	return a.Ctrl.AddDataStore(&config.DatastoreConfig{
+		Id:       id.String(),
+		DType:    dsType,
+		Fqdn:     dsManifest.FQDN,
+		Dpath:    dsManifest.Dir,
+		ApiKey:   dsManifest.APIKey,
+		Password: dsManifest.Password,
+		Region:   dsManifest.Region,
+	})
+}
+
+//findApp returns index and config of app with defined name or nil if not found
+func (a *Applier) findApp(name string) (int, *config.AppInstanceConfig, error) {
+	for id, el := range a.Dev.GetApplicationInstances() {
+		app, err := a.Ctrl.GetApplicationInstanceConfig(el)
+		if err != nil {
+			return 0, nil, fmt.Errorf("no app in cloud %s: %s", el, err)
+		}
+		if app.Displayname == name {
+			return id, app, nil
+		}
+	}
+	return 0, nil, nil
+}
+
+//removeApp removes app and its volumes, volumes defined in manifest as standalone remain
+func (a *Applier) removeApp(desired *Manifest, name string) error {
+	id, app, err := a.findApp(name)
+	if err != nil || app == nil {
+		return err
+	}
+	volumeIDs := a.Dev.GetVolumes()
+	utils.DelEleInSliceByFunction(&volumeIDs, func(i interface{}) bool {
+		vol, err := a.Ctrl.GetVolume(i.(string))
+		if err != nil {
+			return false
+		}
+		for _, volRef := range app.VolumeRefList {
+			if vol.Uuid == volRef.Uuid {
+				return desired.volume(vol.DisplayName) == nil
+			}
+		}
+		return false
+	})
+	a.Dev.SetVolumeConfigs(volumeIDs)
+	configs := a.Dev.GetApplicationInstances()
+	utils.DelEleInSlice(&configs, id)
+	a.Dev.SetApplicationInstanceConfig(configs)
+	return nil
+}
+
+func (a *Applier) removeNetwork(name string) error {
+	for id, el := range a.Dev.GetNetworkInstances() {
+		ni, err := a.Ctrl.GetNetworkInstanceConfig(el)
+		if err != nil {
+			return fmt.Errorf("no network in cloud %s: %s", el, err)
+		}
+		if ni.Displayname == name {
+			configs := a.Dev.GetNetworkInstances()
+			utils.DelEleInSlice(&configs, id)
+			a.Dev.SetNetworkInstanceConfig(configs)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (a *Applier) removeVolume(name string) error {
+	for id, el := range a.Dev.GetVolumes() {
+		vol, err := a.Ctrl.GetVolume(el)
+		if err != nil {
+			return fmt.Errorf("no volume in cloud %s: %s", el, err)
+		}
+		if vol.DisplayName == name {
+			configs := a.Dev.GetVolumes()
+			utils.DelEleInSlice(&configs, id)
+			a.Dev.SetVolumeConfigs(configs)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (a *Applier) createNetwork(n *NetworkManifest) {
+	var opts []expect.ExpectationOption
+	uplink := n.Uplink
+	if uplink == "" {
+		uplink = "eth0"
+	}
+	subnet := ""
+	if networkType(n.Type) == "local" {
+		subnet = n.Subnet
+	}
+	opts = append(opts, expect.AddNetInstanceAndPortPublish(subnet, networkType(n.Type), n.Name, nil, uplink))
+	opts = append(opts, expect.WithStaticDNSEntries(n.Name, n.StaticDNS))
+	expectation := expect.AppExpectationFromURL(a.Ctrl, a.Dev, defaults.DefaultDummyExpect, "", opts...)
+	for _, el := range expectation.NetworkInstances() {
+		a.Dev.SetNetworkInstanceConfig(append(a.Dev.GetNetworkInstances(), el.Uuidandversion.Uuid))
+		log.Debugf("network %s with name %s created", el.Uuidandversion.Uuid, el.Displayname)
+	}
+}
+
+func (a *Applier) registry(registry string) string {
+	switch registry {
+	case "local":
+		return a.LocalRegistry
+	case "remote":
+		return ""
+	}
+	return registry
+}
+
+func (a *Applier) createVolume(v *VolumeManifest) error {
+	var opts []expect.ExpectationOption
+	if v.Size != "" {
+		size, err := humanize.ParseBytes(v.Size)
+		if err != nil {
+			return fmt.Errorf("volume %s: %s", v.Name, err)
+		}
+		opts = append(opts, expect.WithDiskSize(int64(size)))
+	}
+	opts = append(opts, expect.WithImageFormat(v.Format))
+	opts = append(opts, expect.WithRegistry(a.registry(v.Registry)))
+	expectation := expect.AppExpectationFromURL(a.Ctrl, a.Dev, v.Image, v.Name, opts...)
+	volume := expectation.Volume()
+	log.Debugf("volume %s with name %s created", volume.Uuid, volume.DisplayName)
+	return nil
+}
+
+func (a *Applier) createApp(app *AppManifest, networks []string) error {
+	var opts []expect.ExpectationOption
+	opts = append(opts, expect.WithMetadata(app.Metadata))
+	if len(networks) > 0 {
+		for i, el := range networks {
+			if i == 0 {
+				//allocate ports on first network
+				opts = append(opts, expect.AddNetInstanceNameAndPortPublish(el, app.Ports))
+			} else {
+				opts = append(opts, expect.AddNetInstanceNameAndPortPublish(el, nil))
+			}
+		}
+	} else {
+		opts = append(opts, expect.WithPortsPublish(app.Ports))
+	}
+	if app.DiskSize != "" {
+		size, err := humanize.ParseBytes(app.DiskSize)
+		if err != nil {
+			return fmt.Errorf("app %s: %s", app.Name, err)
+		}
+		opts = append(opts, expect.WithDiskSize(int64(size)))
+	}
+	cpus := app.CPUs
+	if cpus == 0 {
+		cpus = defaults.DefaultAppCPU
+	}
+	memory := uint64(defaults.DefaultAppMem * 1024)
+	if app.Memory != "" {
+		var err error
+		if memory, err = humanize.ParseBytes(app.Memory); err != nil {
+			return fmt.Errorf("app %s: %s", app.Name, err)
+		}
+	}
+	opts = append(opts, expect.WithVolumeSize(defaults.DefaultVolumeSize))
+	opts = append(opts, expect.WithVolumeType(expect.VolumeTypeByName("qcow2")))
+	opts = append(opts, expect.WithResources(cpus, uint32(memory/1000)))
+	opts = append(opts, expect.WithImageFormat(app.Format))
+	acls := make(map[string][]string)
+	for _, el := range app.ACL {
+		//acl may be defined for network in notation <network>:<acl>
+		parsed := strings.SplitN(el, ":", 2)
+		if len(parsed) > 1 {
+			acls[parsed[0]] = append(acls[parsed[0]], parsed[1])
+		} else {
+			acls[""] = append(acls[""], parsed[0])
+		}
+	}
+	opts = append(opts, expect.WithACL(acls))
+	opts = append(opts, expect.WithHTTPDirectLoad(true))
+	opts = append(opts, expect.WithAdditionalDisks(app.Mounts))
+	opts = append(opts, expect.WithRegistry(a.registry(app.Registry)))
+	expectation := expect.AppExpectationFromURL(a.Ctrl, a.Dev, app.Image, app.Name, opts...)
+	appInstanceConfig := expectation.Application()
+	appInstanceConfig.Activate = !app.Stopped
+	a.Dev.SetApplicationInstanceConfig(append(a.Dev.GetApplicationInstances(), appInstanceConfig.Uuidandversion.Uuid))
+	return nil
+}
@@ -0,0 +1,394 @@
+package manifest
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/lf-edge/eden/pkg/defaults"
+)
+
+//Action is type of change
+type Action string
+
+//Kind is type of object to change
+type Kind string
+
+//actions with objects
+const (
+	ActionAdd    Action = "+"
+	ActionUpdate Action = "~"
+	ActionRemove Action = "-"
+)
+
+//kinds of objects
+const (
+	KindConfigItem Kind = "config-item"
+	KindDatastore  Kind = "datastore"
+	KindNetwork    Kind = "network"
+	KindVolume     Kind = "volume"
+	KindApp        Kind = "app"
+)
+
+//Change describes one difference between desired and current state of device
+type Change struct {
+	Action  Action
+	Kind    Kind
+	Name    string
+	Details string
+
+	recreate bool //object must be removed and created again
+}
+
+//String returns human readable representation of Change
+func (c Change) String() string {
+	if c.Details == "" {
+		return fmt.Sprintf("%s %s %s", c.Action, c.Kind, c.Name)
+	}
+	return fmt.Sprintf("%s %s %s: %s", c.Action, c.Kind, c.Name, c.Details)
+}
+
+//Diff returns list of changes to move device from current state to desired one
+//Config items and datastores absent in desired state are not removed
+//Networks and volumes absent in desired state are removed only if no app uses them
+func Diff(desired, current *Manifest) (changes []Change) {
+	changes = append(changes, diffConfigItems(desired, current)...)
+	changes = append(changes, diffDatastores(desired, current)...)
+	networkChanges := diffNetworks(desired, current)
+	changes = append(changes, networkChanges...)
+	changes = append(changes, diffVolumes(desired, current)...)
+	changes = append(changes, diffApps(desired, current, networkChanges)...)
+	return
+}
+
+func diffConfigItems(desired, current *Manifest) (changes []Change) {
+	keys := make([]string, 0, len(desired.ConfigItems))
+	for k := range desired.ConfigItems {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		val := desired.ConfigItems[k]
+		if cur, ok := current.ConfigItems[k]; !ok {
+			changes = append(changes, Change{Action: ActionAdd, Kind: KindConfigItem, Name: k, Details: val})
+		} else if cur != val {
+			changes = append(changes, Change{Action: ActionUpdate, Kind: KindConfigItem, Name: k, Details: fmt.Sprintf("%s -> %s", cur, val)})
+		}
+	}
+	return
+}
+
+//dsKey returns identity of datastore
+func dsKey(ds DatastoreManifest) string {
+	return fmt.Sprintf("%s://%s/%s", ds.Type, strings.TrimSuffix(ds.FQDN, "/"), strings.Trim(ds.Dir, "/"))
+}
+
+func diffDatastores(desired, current *Manifest) (changes []Change) {
+	for _, ds := range desired.Datastores {
+		found := false
+		for _, cur := range current.Datastores {
+			if dsKey(ds) != dsKey(cur) {
+				continue
+			}
+			found = true
+			if ds.APIKey != cur.APIKey || ds.Password != cur.Password || ds.Region != cur.Region {
+				changes = append(changes, Change{Action: ActionUpdate, Kind: KindDatastore, Name: dsKey(ds), Details: "credentials or region changed"})
+			}
+			break
+		}
+		if !found {
+			changes = append(changes, Change{Action: ActionAdd, Kind: KindDatastore, Name: dsKey(ds)})
+		}
+	}
+	return
+}
+
+func diffNetworks(desired, current *Manifest) (changes []Change) {
+	for _, n := range desired.Networks {
+		cur := current.network(n.Name)
+		if cur == nil {
+			changes = append(changes, Change{Action: ActionAdd, Kind: KindNetwork, Name: n.Name})
+			continue
+		}
+		var diffs []string
+		if networkType(n.Type) != networkType(cur.Type) {
+			diffs = append(diffs, fmt.Sprintf("type %s -> %s", networkType(cur.Type), networkType(n.Type)))
+		}
+		if networkType(n.Type) == "local" && n.Subnet != cur.Subnet {
+			diffs = append(diffs, fmt.Sprintf("subnet %s -> %s", cur.Subnet, n.Subnet))
+		}
+		if n.Uplink != "" && n.Uplink != cur.Uplink {
+			diffs = append(diffs, fmt.Sprintf("uplink %s -> %s", cur.Uplink, n.Uplink))
+		}
+		if !equalSets(n.StaticDNS, cur.StaticDNS) {
+			diffs = append(diffs, "static dns entries changed")
+		}
+		if len(diffs) > 0 {
+			changes = append(changes, Change{Action: ActionUpdate, Kind: KindNetwork, Name: n.Name, Details: strings.Join(diffs, ", "), recreate: true})
+		}
+	}
+	for _, n := range current.Networks {
+		if desired.network(n.Name) == nil && !appsUseNetworkAfterApply(desired, current, n.Name) {
+			changes = append(changes, Change{Action: ActionRemove, Kind: KindNetwork, Name: n.Name})
+		}
+	}
+	return
+}
+
+//appsUseNetworkAfterApply checks if some app which will remain on device without recreation uses network
+//apps without networks defined in manifest remain connected to their current networks
+func appsUseNetworkAfterApply(desired, current *Manifest, network string) bool {
+	for _, app := range current.Apps {
+		desiredApp := desired.app(app.Name)
+		if desiredApp == nil || len(desiredApp.Networks) > 0 {
+			continue
+		}
+		for _, n := range app.Networks {
+			if n == network {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func diffVolumes(desired, current *Manifest) (changes []Change) {
+	for _, v := range desired.Volumes {
+		cur := current.volume(v.Name)
+		if cur == nil {
+			changes = append(changes, Change{Action: ActionAdd, Kind: KindVolume, Name: v.Name})
+			continue
+		}
+		var diffs []string
+		if registryImageRef(v.Image, v.Registry) != imageRef(cur.Image) {
+			diffs = append(diffs, fmt.Sprintf("image %s -> %s", cur.Image, v.Image))
+		}
+		if v.Size != "" && parseSize(v.Size) != parseSize(cur.Size) {
+			diffs = append(diffs, fmt.Sprintf("size %s -> %s", cur.Size, v.Size))
+		}
+		if len(diffs) > 0 {
+			changes = append(changes, Change{Action: ActionUpdate, Kind: KindVolume, Name: v.Name, Details: strings.Join(diffs, ", "), recreate: true})
+		}
+	}
+	for _, v := range current.Volumes {
+		if desired.volume(v.Name) == nil {
+			changes = append(changes, Change{Action: ActionRemove, Kind: KindVolume, Name: v.Name})
+		}
+	}
+	return
+}
+
+func diffApps(desired, current *Manifest, networkChanges []Change) (changes []Change) {
+	replacedNetworks := make(map[string]bool)
+	for _, c := range networkChanges {
+		if c.Action == ActionUpdate {
+			replacedNetworks[c.Name] = true
+		}
+	}
+	for _, app := range desired.Apps {
+		cur := current.app(app.Name)
+		if cur == nil {
+			changes = append(changes, Change{Action: ActionAdd, Kind: KindApp, Name: app.Name, Details: app.Image})
+			continue
+		}
+		//apps without networks defined in manifest remain connected to their current networks
+		networks := app.Networks
+		if len(networks) == 0 {
+			networks = cur.Networks
+		}
+		var diffs []string
+		if registryImageRef(app.Image, app.Registry) != imageRef(cur.Image) {
+			diffs = append(diffs, fmt.Sprintf("image %s -> %s", cur.Image, app.Image))
+		}
+		if app.Format != "" && !strings.EqualFold(app.Format, cur.Format) {
+			diffs = append(diffs, fmt.Sprintf("format %s -> %s", cur.Format, app.Format))
+		}
+		if app.CPUs != 0 && app.CPUs != cur.CPUs {
+			diffs = append(diffs, fmt.Sprintf("cpus %d -> %d", cur.CPUs, app.CPUs))
+		}
+		if app.Memory != "" && parseSize(app.Memory)/1000 != parseSize(cur.Memory)/1000 {
+			diffs = append(diffs, fmt.Sprintf("memory %s -> %s", cur.Memory, app.Memory))
+		}
+		if app.DiskSize != "" && parseSize(app.DiskSize) != parseSize(cur.DiskSize) {
+			diffs = append(diffs, fmt.Sprintf("disk size %s -> %s", cur.DiskSize, app.DiskSize))
+		}
+		if len(app.Networks) > 0 && !reflect.DeepEqual(app.Networks, cur.Networks) {
+			diffs = append(diffs, fmt.Sprintf("networks %v -> %v", cur.Networks, app.Networks))
+		}
+		if !equalSets(app.Ports, cur.Ports) {
+			diffs = append(diffs, fmt.Sprintf("ports %v -> %v", cur.Ports, app.Ports))
+		}
+		if !equalSets(aclKeys(app.ACL, networks), aclKeys(cur.ACL, cur.Networks)) {
+			diffs = append(diffs, fmt.Sprintf("acl %v -> %v", cur.ACL, app.ACL))
+		}
+		if !equalSets(mountKeys(app.Mounts, false), mountKeys(cur.Mounts, true)) {
+			diffs = append(diffs, fmt.Sprintf("mounts %v -> %v", cur.Mounts, app.Mounts))
+		}
+		if strings.Replace(app.Metadata, `\n`, "\n", -1) != cur.Metadata {
+			diffs = append(diffs, "metadata changed")
+		}
+		for _, n := range networks {
+			if replacedNetworks[n] {
+				diffs = append(diffs, fmt.Sprintf("network %s replaced", n))
+			}
+		}
+		if len(diffs) > 0 {
+			changes = append(changes, Change{Action: ActionUpdate, Kind: KindApp, Name: app.Name, Details: strings.Join(diffs, ", "), recreate: true})
+		} else if app.Stopped != cur.Stopped {
+			//only state changed, no need to recreate app
+			details := "start"
+			if app.Stopped {
+				details = "stop"
+			}
+			changes = append(changes, Change{Action: ActionUpdate, Kind: KindApp, Name: app.Name, Details: details})
+		}
+	}
+	for _, app := range current.Apps {
+		if desired.app(app.Name) == nil {
+			changes = append(changes, Change{Action: ActionRemove, Kind: KindApp, Name: app.Name})
+		}
+	}
+	return
+}
+
+//aclKeys returns acl entries in notation <network>:<acl> for every network of app
+//entries without network are applied to all networks of app
+func aclKeys(acl []string, networks []string) (result []string) {
+	for _, el := range acl {
+		parsed := strings.SplitN(el, ":", 2)
+		if len(parsed) > 1 {
+			result = append(result, el)
+			continue
+		}
+		for _, n := range networks {
+			result = append(result, fmt.Sprintf("%s:%s", n, el))
+		}
+	}
+	return
+}
+
+//mountKeys returns normalized images of mounts with their mount points
+//empty volumes created for volumes of docker image are skipped if skipEmpty set
+func mountKeys(mounts []string, skipEmpty bool) (result []string) {
+	emptyVolumes := make(map[string]bool)
+	for _, el := range []string{
+		defaults.DefaultEmptyVolumeLinkQcow2,
+		defaults.DefaultEmptyVolumeLinkDocker,
+		defaults.DefaultEmptyVolumeLinkRaw,
+		defaults.DefaultEmptyVolumeLinkQcow,
+		defaults.DefaultEmptyVolumeLinkVHDX,
+		defaults.DefaultEmptyVolumeLinkVMDK,
+	} {
+		emptyVolumes[imageRef(el)] = true
+	}
+	for _, el := range mounts {
+		src, dst := el, ""
+		if strings.Contains(el, ",") {
+			for _, arg := range strings.Split(el, ",") {
+				parsed := strings.SplitN(arg, "=", 2)
+				if len(parsed) < 2 {
+					continue
+				}
+				switch parsed[0] {
+				case "source", "src":
+					src = parsed[1]
+				case "destination", "dst", "target":
+					dst = parsed[1]
+				}
+			}
+		}
+		if skipEmpty && emptyVolumes[imageRef(src)] {
+			continue
+		}
+		result = append(result, fmt.Sprintf("%s:%s", imageRef(src), dst))
+	}
+	return
+}
+
+func (m *Manifest) network(name string) *NetworkManifest {
+	for i := range m.Networks {
+		if m.Networks[i].Name == name {
+			return &m.Networks[i]
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) volume(name string) *VolumeManifest {
+	for i := range m.Volumes {
+		if m.Volumes[i].Name == name {
+			return &m.Volumes[i]
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) app(name string) *AppManifest {
+	for i := range m.Apps {
+		if m.Apps[i].Name == name {
+			return &m.Apps[i]
+		}
+	}
+	return nil
+}
+
+func networkType(t string) string {
+	if t == "" {
+		return "local"
+	}
+	return t
+}
+
+//registryImageRef returns normalized reference to docker image pulled through registry
+//registry must be address of registry, 'remote' or empty to use registry of image
+//'local' registry must be resolved with ResolveLocalRegistry before
+func registryImageRef(link, registry string) string {
+	if registry == "" || registry == "remote" {
+		return imageRef(link)
+	}
+	splitted := strings.SplitN(link, "://", 2)
+	if len(splitted) == 1 || splitted[0] == "docker" || splitted[0] == "oci" {
+		return imageRef(fmt.Sprintf("%s/%s", registry, splitted[len(splitted)-1]))
+	}
+	return imageRef(link)
+}
+
+//imageRef returns normalized reference to image to compare
+//for docker images it is full reference with registry and tag, for others it is name of file
+func imageRef(link string) string {
+	splitted := strings.SplitN(link, "://", 2)
+	if len(splitted) == 1 || splitted[0] == "docker" || splitted[0] == "oci" {
+		ref, err := name.ParseReference(splitted[len(splitted)-1])
+		if err != nil {
+			return link
+		}
+		return ref.Name()
+	}
+	return path.Base(splitted[1])
+}
+
+func parseSize(size string) uint64 {
+	if size == "" {
+		return 0
+	}
+	parsed, err := humanize.ParseBytes(size)
+	if err != nil {
+		return 0
+	}
+	return parsed
+}
+
+func equalSets(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sa := append([]string{}, a...)
+	sb := append([]string{}, b...)
+	sort.Strings(sa)
+	sort.Strings(sb)
+	return reflect.DeepEqual(sa, sb)
+}
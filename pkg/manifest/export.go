@@ -0,0 +1,272 @@
+package manifest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/expect"
+	"github.com/lf-edge/eve/api/go/config"
+	log "github.com/sirupsen/logrus"
+)
+
+var dsTypes = map[string]config.DsType{
+	"http":      config.DsType_DsHttp,
+	"https":     config.DsType_DsHttps,
+	"s3":        config.DsType_DsS3,
+	"sftp":      config.DsType_DsSFTP,
+	"container": config.DsType_DsContainerRegistry,
+	"azure":     config.DsType_DsAzureBlob,
+}
+
+//dsTypeByName returns DsType for its name in manifest
+func dsTypeByName(name string) (config.DsType, error) {
+	if dsType, ok := dsTypes[name]; ok {
+		return dsType, nil
+	}
+	return config.DsType_DsUnknown, fmt.Errorf("datastore type %s not supported", name)
+}
+
+//dsNameByType returns name of datastore type to use in manifest
+func dsNameByType(dsType config.DsType) string {
+	for k, v := range dsTypes {
+		if v == dsType {
+			return k
+		}
+	}
+	return dsType.String()
+}
+
+//exporter collects state of device from controller
+type exporter struct {
+	ctrl       controller.Cloud
+	dev        *device.Ctx
+	datastores map[string]DatastoreManifest
+}
+
+//Export returns Manifest which describes current state of device
+//apps and volumes refer images by links in format used by 'eden pod deploy'
+func Export(ctrl controller.Cloud, dev *device.Ctx) (*Manifest, error) {
+	exp := &exporter{ctrl: ctrl, dev: dev, datastores: make(map[string]DatastoreManifest)}
+	m := &Manifest{ConfigItems: make(map[string]string)}
+	for k, v := range dev.GetConfigItems() {
+		m.ConfigItems[k] = v
+	}
+	networkNames := make(map[string]string)
+	for _, niID := range dev.GetNetworkInstances() {
+		ni, err := ctrl.GetNetworkInstanceConfig(niID)
+		if err != nil {
+			return nil, fmt.Errorf("no network instance %s in controller: %s", niID, err)
+		}
+		networkNames[ni.Uuidandversion.Uuid] = ni.Displayname
+		m.Networks = append(m.Networks, exportNetwork(ni))
+	}
+	appVolumes := make(map[string]bool)
+	for _, appID := range dev.GetApplicationInstances() {
+		app, err := ctrl.GetApplicationInstanceConfig(appID)
+		if err != nil {
+			return nil, fmt.Errorf("no app %s in controller: %s", appID, err)
+		}
+		appManifest, err := exp.exportApp(app, networkNames)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range app.VolumeRefList {
+			appVolumes[ref.Uuid] = true
+		}
+		m.Apps = append(m.Apps, *appManifest)
+	}
+	for _, volID := range dev.GetVolumes() {
+		if appVolumes[volID] {
+			continue
+		}
+		vol, err := ctrl.GetVolume(volID)
+		if err != nil {
+			return nil, fmt.Errorf("no volume %s in controller: %s", volID, err)
+		}
+		link, format, err := exp.volumeImage(vol)
+		if err != nil {
+			return nil, err
+		}
+		m.Volumes = append(m.Volumes, VolumeManifest{
+			Name:   vol.DisplayName,
+			Image:  link,
+			Size:   strconv.FormatInt(vol.Maxsizebytes, 10),
+			Format: format,
+		})
+	}
+	for _, ds := range exp.datastores {
+		m.Datastores = append(m.Datastores, ds)
+	}
+	return m, nil
+}
+
+func exportNetwork(ni *config.NetworkInstanceConfig) NetworkManifest {
+	n := NetworkManifest{Name: ni.Displayname, Type: "local"}
+	if ni.InstType == config.ZNetworkInstType_ZnetInstSwitch {
+		n.Type = "switch"
+	} else if ni.Ip != nil {
+		n.Subnet = ni.Ip.Subnet
+	}
+	if ni.Port == nil {
+		n.Uplink = "none"
+	} else {
+		n.Uplink = ni.Port.Name
+	}
+	for _, el := range ni.Dns {
+		n.StaticDNS = append(n.StaticDNS, fmt.Sprintf("%s:%s", el.HostName, strings.Join(el.Address, ",")))
+	}
+	return n
+}
+
+func (exp *exporter) exportApp(app *config.AppInstanceConfig, networkNames map[string]string) (*AppManifest, error) {
+	a := &AppManifest{
+		Name:    app.Displayname,
+		Stopped: !app.Activate,
+	}
+	if app.Fixedresources != nil {
+		a.CPUs = app.Fixedresources.Vcpus
+		a.Memory = fmt.Sprintf("%dkB", app.Fixedresources.Memory)
+	}
+	if len(app.VolumeRefList) == 0 {
+		return nil, fmt.Errorf("app %s has no volumes", app.Displayname)
+	}
+	for i, ref := range app.VolumeRefList {
+		vol, err := exp.ctrl.GetVolume(ref.Uuid)
+		if err != nil {
+			return nil, fmt.Errorf("no volume %s in controller: %s", ref.Uuid, err)
+		}
+		link, format, err := exp.volumeImage(vol)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			a.Image = link
+			a.Format = format
+			if vol.Maxsizebytes > 0 {
+				a.DiskSize = strconv.FormatInt(vol.Maxsizebytes, 10)
+			}
+			continue
+		}
+		mount := link
+		if ref.MountDir != "" {
+			mount = fmt.Sprintf("src=%s,dst=%s", link, ref.MountDir)
+		}
+		a.Mounts = append(a.Mounts, mount)
+	}
+	a.Metadata = exp.appMetadata(app)
+	for _, iface := range app.Interfaces {
+		name, ok := networkNames[iface.NetworkId]
+		if ok {
+			a.Networks = append(a.Networks, name)
+		}
+		for _, acl := range iface.Acls {
+			lport := ""
+			var appPort uint32
+			for _, match := range acl.Matches {
+				if match.Type == "lport" {
+					lport = match.Value
+					break
+				}
+			}
+			if lport == "" && ok {
+				if el := exportACE(acl); el != "" {
+					a.ACL = append(a.ACL, fmt.Sprintf("%s:%s", name, el))
+				}
+			}
+			for _, action := range acl.Actions {
+				if action.Portmap {
+					appPort = action.AppPort
+					break
+				}
+			}
+			if lport != "" && appPort != 0 {
+				a.Ports = append(a.Ports, fmt.Sprintf("%s:%d", lport, appPort))
+			}
+		}
+	}
+	return a, nil
+}
+
+//exportACE returns notation of access rule of app or empty string for rule allowing all addresses
+func exportACE(acl *config.ACE) string {
+	for _, match := range acl.Matches {
+		switch match.Type {
+		case "host":
+			if match.Value == "" {
+				return defaults.DefaultHostOnlyNotation
+			}
+			return match.Value
+		case "ip":
+			if match.Value == "0.0.0.0/0" {
+				return ""
+			}
+			return match.Value
+		}
+	}
+	return ""
+}
+
+//appMetadata returns metadata of app, encrypted one is decrypted with certificates of controller
+func (exp *exporter) appMetadata(app *config.AppInstanceConfig) string {
+	userData := app.UserData
+	if app.CipherData != nil {
+		encBlock, err := expect.DecryptCipherBlock(exp.ctrl, exp.dev, app.CipherData)
+		if err != nil {
+			log.Warnf("cannot decrypt metadata of app %s: %s", app.Displayname, err)
+			return ""
+		}
+		userData = encBlock.ProtectedUserData
+	}
+	metadata, err := base64.StdEncoding.DecodeString(userData)
+	if err != nil {
+		log.Warnf("cannot decode metadata of app %s: %s", app.Displayname, err)
+		return ""
+	}
+	return string(metadata)
+}
+
+//volumeImage returns link to image of volume and its format
+func (exp *exporter) volumeImage(vol *config.Volume) (link string, format string, err error) {
+	if vol.Origin == nil || vol.Origin.DownloadContentTreeID == "" {
+		return "", "", fmt.Errorf("volume %s has no content tree", vol.DisplayName)
+	}
+	ct, err := exp.ctrl.GetContentTree(vol.Origin.DownloadContentTreeID)
+	if err != nil {
+		return "", "", fmt.Errorf("no content tree %s in controller: %s", vol.Origin.DownloadContentTreeID, err)
+	}
+	ds, err := exp.ctrl.GetDataStore(ct.DsId)
+	if err != nil {
+		return "", "", fmt.Errorf("no datastore %s in controller: %s", ct.DsId, err)
+	}
+	dsManifest := DatastoreManifest{
+		Type:     dsNameByType(ds.DType),
+		FQDN:     ds.Fqdn,
+		Dir:      ds.Dpath,
+		APIKey:   ds.ApiKey,
+		Password: ds.Password,
+		Region:   ds.Region,
+	}
+	exp.datastores[dsKey(dsManifest)] = dsManifest
+	format = strings.ToLower(ct.Iformat.String())
+	switch ds.DType {
+	case config.DsType_DsContainerRegistry:
+		return fmt.Sprintf("docker://%s/%s", strings.TrimPrefix(ds.Fqdn, "docker://"), ct.URL), format, nil
+	case config.DsType_DsHttp, config.DsType_DsHttps:
+		fqdn := ds.Fqdn
+		if !strings.Contains(fqdn, "://") {
+			fqdn = fmt.Sprintf("%s://%s", dsNameByType(ds.DType), fqdn)
+		}
+		parts := []string{strings.TrimSuffix(fqdn, "/")}
+		if dir := strings.Trim(ds.Dpath, "/"); dir != "" {
+			parts = append(parts, dir)
+		}
+		return strings.Join(append(parts, strings.TrimPrefix(ct.URL, "/")), "/"), format, nil
+	default:
+		return fmt.Sprintf("file://%s", ct.URL), format, nil
+	}
+}
@@ -0,0 +1,158 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+//Manifest describes desired state of device
+type Manifest struct {
+	ConfigItems map[string]string   `yaml:"config-items,omitempty"`
+	Datastores  []DatastoreManifest `yaml:"datastores,omitempty"`
+	Networks    []NetworkManifest   `yaml:"networks,omitempty"`
+	Volumes     []VolumeManifest    `yaml:"volumes,omitempty"`
+	Apps        []AppManifest       `yaml:"apps,omitempty"`
+}
+
+//DatastoreManifest describes datastore
+//datastores are identified by type, fqdn and dir
+type DatastoreManifest struct {
+	Type     string `yaml:"type"`
+	FQDN     string `yaml:"fqdn"`
+	Dir      string `yaml:"dir,omitempty"`
+	APIKey   string `yaml:"api-key,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	Region   string `yaml:"region,omitempty"`
+}
+
+//NetworkManifest describes network instance
+type NetworkManifest struct {
+	Name      string   `yaml:"name"`
+	Type      string   `yaml:"type,omitempty"`
+	Subnet    string   `yaml:"subnet,omitempty"`
+	Uplink    string   `yaml:"uplink,omitempty"`
+	StaticDNS []string `yaml:"static-dns,omitempty"`
+}
+
+//VolumeManifest describes standalone volume not owned by app
+type VolumeManifest struct {
+	Name     string `yaml:"name"`
+	Image    string `yaml:"image"`
+	Size     string `yaml:"size,omitempty"`
+	Format   string `yaml:"format,omitempty"`
+	Registry string `yaml:"registry,omitempty"`
+}
+
+//AppManifest describes app instance
+type AppManifest struct {
+	Name     string   `yaml:"name"`
+	Image    string   `yaml:"image"`
+	Networks []string `yaml:"networks,omitempty"`
+	Ports    []string `yaml:"ports,omitempty"`
+	ACL      []string `yaml:"acl,omitempty"`
+	CPUs     uint32   `yaml:"cpus,omitempty"`
+	Memory   string   `yaml:"memory,omitempty"`
+	DiskSize string   `yaml:"disk-size,omitempty"`
+	Format   string   `yaml:"format,omitempty"`
+	Mounts   []string `yaml:"mounts,omitempty"`
+	Metadata string   `yaml:"metadata,omitempty"`
+	Registry string   `yaml:"registry,omitempty"`
+	Stopped  bool     `yaml:"stopped,omitempty"`
+}
+
+//Parse returns Manifest from yaml data
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("cannot parse manifest: %s", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+//Load returns Manifest from yaml file
+func Load(file string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+//Bytes returns yaml representation of Manifest
+func (m *Manifest) Bytes() ([]byte, error) {
+	return yaml.Marshal(m)
+}
+
+//validate checks required fields and uniqueness of names
+//ResolveLocalRegistry replaces 'registry: local' of apps and volumes with address of local registry
+//to compare their images with images exported from controller
+func (m *Manifest) ResolveLocalRegistry(address string) {
+	for i := range m.Volumes {
+		if m.Volumes[i].Registry == "local" {
+			m.Volumes[i].Registry = address
+		}
+	}
+	for i := range m.Apps {
+		if m.Apps[i].Registry == "local" {
+			m.Apps[i].Registry = address
+		}
+	}
+}
+
+func (m *Manifest) validate() error {
+	networks := make(map[string]bool)
+	for _, el := range m.Networks {
+		if el.Name == "" {
+			return fmt.Errorf("network without name in manifest")
+		}
+		if networks[el.Name] {
+			return fmt.Errorf("duplicate network %s in manifest", el.Name)
+		}
+		switch el.Type {
+		case "", "local":
+			if el.Subnet == "" {
+				return fmt.Errorf("subnet required for local network %s", el.Name)
+			}
+		case "switch":
+		default:
+			return fmt.Errorf("network %s: type %s not supported", el.Name, el.Type)
+		}
+		networks[el.Name] = true
+	}
+	volumes := make(map[string]bool)
+	for _, el := range m.Volumes {
+		if el.Name == "" || el.Image == "" {
+			return fmt.Errorf("volume must have name and image in manifest")
+		}
+		if volumes[el.Name] {
+			return fmt.Errorf("duplicate volume %s in manifest", el.Name)
+		}
+		volumes[el.Name] = true
+	}
+	apps := make(map[string]bool)
+	for _, el := range m.Apps {
+		if el.Name == "" || el.Image == "" {
+			return fmt.Errorf("app must have name and image in manifest")
+		}
+		if apps[el.Name] {
+			return fmt.Errorf("duplicate app %s in manifest", el.Name)
+		}
+		for _, n := range el.Networks {
+			if !networks[n] {
+				return fmt.Errorf("app %s uses network %s not defined in manifest", el.Name, n)
+			}
+		}
+		apps[el.Name] = true
+	}
+	for _, el := range m.Datastores {
+		if _, err := dsTypeByName(el.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package projects
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//DeadlineContext is context.Context with deadline which can be extended
+type DeadlineContext struct {
+	context.Context
+	cancel   context.CancelFunc
+	mu       sync.Mutex
+	deadline time.Time
+	timer    *time.Timer
+	expired  bool
+}
+
+//WithExtendableDeadline returns context which is done after timeout or with parent
+//timeout can be extended with Extend until context is done
+func WithExtendableDeadline(parent context.Context, timeout time.Duration) (*DeadlineContext, context.CancelFunc) {
+	cancelCtx, cancel := context.WithCancel(parent)
+	ctx := &DeadlineContext{Context: cancelCtx, cancel: cancel, deadline: time.Now().Add(timeout)}
+	ctx.timer = time.AfterFunc(timeout, ctx.expire)
+	return ctx, func() {
+		ctx.timer.Stop()
+		cancel()
+	}
+}
+
+func (ctx *DeadlineContext) expire() {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if wait := time.Until(ctx.deadline); wait > 0 {
+		//deadline was extended after timer fired
+		ctx.timer.Reset(wait)
+		return
+	}
+	ctx.expired = true
+	ctx.cancel()
+}
+
+//Extend moves deadline forward by duration, it does nothing if context is already done
+func (ctx *DeadlineContext) Extend(duration time.Duration) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.expired || ctx.Context.Err() != nil {
+		return
+	}
+	ctx.deadline = ctx.deadline.Add(duration)
+	if ctx.timer.Stop() {
+		ctx.timer.Reset(time.Until(ctx.deadline))
+	}
+}
+
+//Deadline returns current deadline
+func (ctx *DeadlineContext) Deadline() (time.Time, bool) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	return ctx.deadline, true
+}
+
+//Err returns context.DeadlineExceeded if deadline expired
+func (ctx *DeadlineContext) Err() error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.expired {
+		return context.DeadlineExceeded
+	}
+	return ctx.Context.Err()
+}
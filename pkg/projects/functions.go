@@ -2,15 +2,16 @@ package projects
 
 import (
 	"fmt"
+	"io/ioutil"
 	"strings"
 
 	"github.com/lf-edge/eden/pkg/controller/eapps"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/sshclient"
 	"github.com/lf-edge/eve/api/go/logs"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/crypto/ssh"
 )
 
 //CheckMessageInAppLog try to find message in logs of app
@@ -41,29 +42,31 @@ func (tc *TestContext) CheckMessageInAppLog(edgeNode *device.Ctx, appID uuid.UUI
 func SendCommandSSH(ip *string, port *int, user, password, command string, foreground bool, callbacks ...Callback) ProcTimerFunc {
 	return func() error {
 		if *ip != "" {
-			configSSH := &ssh.ClientConfig{
-				User: user,
-				Auth: []ssh.AuthMethod{
-					ssh.Password(password),
-				},
-				HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-				Timeout:         defaults.DefaultRepeatTimeout,
-			}
-			conn, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", *ip, *port), configSSH)
+			client, err := sshclient.Dial(&sshclient.Config{
+				Host:     *ip,
+				Port:     *port,
+				User:     user,
+				Password: password,
+				Timeout:  defaults.DefaultRepeatTimeout,
+			})
 			if err != nil {
 				return nil
 			}
-			session, _ := conn.NewSession()
 			if foreground {
-				defer session.Close()
-				if err := session.Run(command); err != nil {
+				defer client.Close()
+				code, err := client.Run(command, nil, ioutil.Discard, ioutil.Discard)
+				if err != nil {
 					fmt.Println(err)
 					return nil
 				}
+				if code != 0 {
+					fmt.Printf("command \"%s\" exited with code %d\n", command, code)
+					return nil
+				}
 			} else {
 				go func() {
-					_ = session.Run(command) //we cannot get answer for this command
-					session.Close()
+					_, _ = client.Run(command, nil, ioutil.Discard, ioutil.Discard) //we cannot get answer for this command
+					client.Close()
 				}()
 			}
 			for _, clb := range callbacks {
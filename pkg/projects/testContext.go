@@ -1,34 +1,25 @@
 package projects
 
 import (
+	"context"
 	"fmt"
 	"github.com/lf-edge/eden/pkg/controller"
-	"github.com/lf-edge/eden/pkg/controller/adam"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
 //GetControllerMode parse url with controller
 func GetControllerMode(controllerMode string) (modeType, modeURL string, err error) {
-	params := utils.GetParams(controllerMode, defaults.DefaultControllerModePattern)
-	if len(params) == 0 {
-		return "", "", fmt.Errorf("cannot parse mode (not [file|proto|adam|zedcloud]://<URL>): %s", controllerMode)
-	}
-	ok := false
-	if modeType, ok = params["Type"]; !ok {
-		return "", "", fmt.Errorf("cannot parse modeType (not [file|proto|adam|zedcloud]://<URL>): %s", controllerMode)
-	}
-	if modeURL, ok = params["URL"]; !ok {
-		return "", "", fmt.Errorf("cannot parse modeURL (not [file|proto|adam|zedcloud]://<URL>): %s", controllerMode)
-	}
-	return
+	return controller.GetControllerMode(controllerMode)
 }
 
 //TestContext is main structure for running tests
@@ -39,7 +30,8 @@ type TestContext struct {
 	procBus  *processingBus
 	tests    map[*device.Ctx]*testing.T
 	states   map[*device.Ctx]*State
-	stopTime time.Time
+	mu       sync.Mutex
+	deadline *DeadlineContext //deadline of current wait
 	addTime  time.Duration
 }
 
@@ -60,35 +52,32 @@ func NewTestContext() *TestContext {
 		if err != nil {
 			log.Debug(err)
 		}
-		if modeType != "" {
-			if modeType != "adam" {
-				log.Fatalf("Not implemented controller type %s", modeType)
+		switch modeType {
+		case "", "adam":
+			if modeURL != "" { //overwrite config only if url defined
+				setAdamURL(modeURL)
 			}
-		}
-		if modeURL != "" { //overwrite config only if url defined
-			ipPort := strings.Split(modeURL, ":")
-			ip := ipPort[0]
-			if ip == "" {
-				log.Fatalf("cannot get ip/hostname from %s", modeURL)
+		case "zedcloud":
+			if modeURL != "" { //overwrite config only if url defined
+				viper.Set("zedcloud.url", modeURL)
 			}
-			port := "80"
-			if len(ipPort) > 1 {
-				port = ipPort[1]
+		case "proto":
+			if modeURL != "" { //overwrite config only if url defined
+				viper.Set("proto.dir", modeURL)
 			}
-			viper.Set("adam.ip", ip)
-			viper.Set("adam.port", port)
+		default:
+			log.Fatalf("Not implemented controller type %s", modeType)
 		}
 	}
-	vars, err := utils.InitVars()
+	ctx, err := controller.CloudPrepare()
 	if err != nil {
-		log.Fatalf("utils.InitVars: %s", err)
-	}
-	ctx := &controller.CloudCtx{Controller: &adam.Ctx{}}
-	ctx.SetVars(vars)
-	if err := ctx.InitWithVars(vars); err != nil {
-		log.Fatalf("cloud.InitWithVars: %s", err)
+		log.Fatalf("CloudPrepare: %s", err)
 	}
-	ctx.GetAllNodes()
+	return NewTestContextWithController(ctx)
+}
+
+//NewTestContextWithController creates new TestContext with provided controller without loading of config
+func NewTestContextWithController(ctx controller.Cloud) *TestContext {
 	tstCtx := &TestContext{
 		cloud: ctx,
 		tests: map[*device.Ctx]*testing.T{},
@@ -97,6 +86,21 @@ func NewTestContext() *TestContext {
 	return tstCtx
 }
 
+//setAdamURL overwrites adam ip and port in config with ones from url
+func setAdamURL(modeURL string) {
+	ipPort := strings.Split(modeURL, ":")
+	ip := ipPort[0]
+	if ip == "" {
+		log.Fatalf("cannot get ip/hostname from %s", modeURL)
+	}
+	port := "80"
+	if len(ipPort) > 1 {
+		port = ipPort[1]
+	}
+	viper.Set("adam.ip", ip)
+	viper.Set("adam.port", port)
+}
+
 //GetNodeDescriptions returns list of nodes from config
 func (tc *TestContext) GetNodeDescriptions() (nodes []*EdgeNodeDescription) {
 	if eveList := viper.GetStringMap("test.eve"); len(eveList) > 0 {
@@ -216,36 +220,92 @@ func (tc *TestContext) ConfigSync(edgeNode *device.Ctx) {
 }
 
 //ExpandOnSuccess adds additional time to global timeout on every success check
+//it is applied until the next wait is done
 func (tc *TestContext) ExpandOnSuccess(secs int) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
 	tc.addTime = time.Duration(secs) * time.Second
 }
 
-//WaitForProcWithErrorCallback blocking execution until the time elapses or all Procs gone
-//and fires callback in case of timeout
-func (tc *TestContext) WaitForProcWithErrorCallback(secs int, callback Callback) {
-	defer func() { tc.addTime = 0 }() //reset addTime on exit
-	timeout := time.Duration(secs) * time.Second
-	tc.stopTime = time.Now().Add(timeout)
-	ticker := time.NewTicker(defaults.DefaultRepeatTimeout)
-	defer ticker.Stop()
+//extendDeadline extends deadline of current wait by time set with ExpandOnSuccess
+func (tc *TestContext) extendDeadline() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.addTime == 0 || tc.deadline == nil {
+		return
+	}
+	log.Infof("Expand timewait by %s", tc.addTime)
+	tc.deadline.Extend(tc.addTime)
+}
+
+//withDeadline returns context for wait, which deadline is extended on success checks
+func (tc *TestContext) withDeadline(timeout time.Duration) (*DeadlineContext, context.CancelFunc) {
+	ctx, cancel := WithExtendableDeadline(context.Background(), timeout)
+	tc.mu.Lock()
+	deadline := tc.deadline
+	tc.deadline = ctx
+	tc.mu.Unlock()
+	return ctx, func() {
+		tc.mu.Lock()
+		//restore deadline of outer wait on exit
+		tc.deadline = deadline
+		if deadline == nil {
+			//ExpandOnSuccess is applied only to waits started before the outermost one is done
+			tc.addTime = 0
+		}
+		tc.mu.Unlock()
+		cancel()
+	}
+}
+
+//PendingProcs returns descriptions of processors which are not done yet
+func (tc *TestContext) PendingProcs() []string {
+	return tc.procBus.pending()
+}
+
+//logPending reports processors which are not done yet into tests
+func (tc *TestContext) logPending() {
+	pending := tc.PendingProcs()
+	if len(pending) == 0 {
+		return
+	}
+	toRet := fmt.Sprintf("pending processors:\n\t%s", strings.Join(pending, "\n\t"))
+	if len(tc.tests) == 0 {
+		log.Info(toRet)
+	}
+	for _, el := range tc.tests {
+		el.Log(toRet)
+	}
+}
+
+//WaitForProcContext blocking execution until ctx is done or all Procs gone
+//returns error of ctx if it is done first
+func (tc *TestContext) WaitForProcContext(ctx context.Context) error {
 	waitChan := make(chan struct{})
 	go func() {
-		tc.procBus.wg.Wait()
+		tc.procBus.waitGroup().Wait()
 		close(waitChan)
 	}()
-	for {
-		select {
-		case <-waitChan:
-			for node, el := range tc.tests {
-				el.Logf("done for device %s", node.GetID())
-			}
-			return
-		case <-ticker.C:
-			if time.Now().After(tc.stopTime) {
-				callback()
-				return
-			}
+	select {
+	case <-waitChan:
+		for node, el := range tc.tests {
+			el.Logf("done for device %s", node.GetID())
 		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//WaitForProcWithErrorCallback blocking execution until the time elapses or all Procs gone
+//and fires callback in case of timeout
+//time is expanded on every success check if ExpandOnSuccess was called before
+func (tc *TestContext) WaitForProcWithErrorCallback(secs int, callback Callback) {
+	ctx, cancel := tc.withDeadline(time.Duration(secs) * time.Second)
+	defer cancel()
+	if err := tc.WaitForProcContext(ctx); err != nil {
+		tc.logPending()
+		callback()
 	}
 }
 
@@ -265,29 +325,65 @@ func (tc *TestContext) WaitForProc(secs int) {
 	tc.WaitForProcWithErrorCallback(secs, callback)
 }
 
+//RunStep runs named step of test: adds processors with addProcs and waits for them during timeout
+//processors added before are not waited, timeout is expanded if ExpandOnSuccess was called inside addProcs
+//returns error with processors not done on timeout and fails tests
+func (tc *TestContext) RunStep(name string, timeout time.Duration, addProcs func()) error {
+	end := tc.procBus.beginStep(name)
+	defer end()
+	ctx, cancel := tc.withDeadline(timeout)
+	defer cancel()
+	addProcs()
+	if err := tc.WaitForProcContext(ctx); err != nil {
+		pending := tc.PendingProcs()
+		tc.procBus.clean()
+		err = fmt.Errorf("step %s terminated by timeout %s, pending processors: %s",
+			name, timeout, strings.Join(pending, "; "))
+		if len(tc.tests) == 0 {
+			log.Error(err)
+		}
+		for _, el := range tc.tests {
+			el.Error(err)
+		}
+		return err
+	}
+	log.Infof("step %s done", name)
+	return nil
+}
+
 //AddProcLog add processFunction, that will get all logs for edgeNode
-func (tc *TestContext) AddProcLog(edgeNode *device.Ctx, processFunction ProcLogFunc) {
-	tc.procBus.addProc(edgeNode, processFunction)
+func (tc *TestContext) AddProcLog(edgeNode *device.Ctx, processFunction ProcLogFunc, opts ...ProcOption) {
+	tc.procBus.addProc(edgeNode, processFunction, opts...)
 }
 
 //AddProcFlowLog add processFunction, that will get all FlowLogs for edgeNode
-func (tc *TestContext) AddProcFlowLog(edgeNode *device.Ctx, processFunction ProcLogFlowFunc) {
-	tc.procBus.addProc(edgeNode, processFunction)
+func (tc *TestContext) AddProcFlowLog(edgeNode *device.Ctx, processFunction ProcLogFlowFunc, opts ...ProcOption) {
+	tc.procBus.addProc(edgeNode, processFunction, opts...)
+}
+
+//AddProcAppLog add processFunction, that will get all logs of app with appID for edgeNode
+func (tc *TestContext) AddProcAppLog(edgeNode *device.Ctx, appID uuid.UUID, processFunction ProcAppLogFunc, opts ...ProcOption) {
+	tc.procBus.addAppLogProc(edgeNode, appID, processFunction, opts...)
 }
 
 //AddProcInfo add processFunction, that will get all info for edgeNode
-func (tc *TestContext) AddProcInfo(edgeNode *device.Ctx, processFunction ProcInfoFunc) {
-	tc.procBus.addProc(edgeNode, processFunction)
+func (tc *TestContext) AddProcInfo(edgeNode *device.Ctx, processFunction ProcInfoFunc, opts ...ProcOption) {
+	tc.procBus.addProc(edgeNode, processFunction, opts...)
 }
 
 //AddProcMetric add processFunction, that will get all metrics for edgeNode
-func (tc *TestContext) AddProcMetric(edgeNode *device.Ctx, processFunction ProcMetricFunc) {
-	tc.procBus.addProc(edgeNode, processFunction)
+func (tc *TestContext) AddProcMetric(edgeNode *device.Ctx, processFunction ProcMetricFunc, opts ...ProcOption) {
+	tc.procBus.addProc(edgeNode, processFunction, opts...)
+}
+
+//AddProcRequest add processFunction, that will get all new API requests of edgeNode to controller
+func (tc *TestContext) AddProcRequest(edgeNode *device.Ctx, processFunction ProcRequestFunc, opts ...ProcOption) {
+	tc.procBus.addProc(edgeNode, processFunction, opts...)
 }
 
 //AddProcTimer add processFunction, that will fire with time intervals for edgeNode
-func (tc *TestContext) AddProcTimer(edgeNode *device.Ctx, processFunction ProcTimerFunc) {
-	tc.procBus.addProc(edgeNode, processFunction)
+func (tc *TestContext) AddProcTimer(edgeNode *device.Ctx, processFunction ProcTimerFunc, opts ...ProcOption) {
+	tc.procBus.addProc(edgeNode, processFunction, opts...)
 }
 
 //StartTrackingState init function for State monitoring
@@ -302,11 +398,8 @@ func (tc *TestContext) StartTrackingState(onlyNewElements bool) {
 			_ = tc.GetController().InfoLastCallback(dev.GetID(), map[string]string{}, curState.getProcessorInfo())
 			_ = tc.GetController().MetricLastCallback(dev.GetID(), map[string]string{}, curState.getProcessorMetric())
 		}
-		if _, exists := tc.procBus.proc[dev]; !exists {
-			tc.procBus.initCheckers(dev)
-		}
-		tc.procBus.proc[dev] = append(tc.procBus.proc[dev], &absFunc{proc: curState.GetInfoProcessingFunction(), disabled: false, states: true})
-		tc.procBus.proc[dev] = append(tc.procBus.proc[dev], &absFunc{proc: curState.GetMetricProcessingFunction(), disabled: false, states: true})
+		tc.procBus.addStateProc(dev, curState.GetInfoProcessingFunction())
+		tc.procBus.addStateProc(dev, curState.GetMetricProcessingFunction())
 	}
 }
 
@@ -2,19 +2,24 @@ package projects
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/eapps"
 	"github.com/lf-edge/eden/pkg/controller/eflowlog"
 	"github.com/lf-edge/eden/pkg/controller/einfo"
 	"github.com/lf-edge/eden/pkg/controller/elog"
 	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve/api/go/flowlog"
 	"github.com/lf-edge/eve/api/go/info"
+	"github.com/lf-edge/eve/api/go/logs"
 	"github.com/lf-edge/eve/api/go/metrics"
+	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -27,166 +32,459 @@ type ProcLogFunc func(log *elog.FullLogEntry) error
 //ProcLogFlowFunc provides callback to process flowLog
 type ProcLogFlowFunc func(log *flowlog.FlowMessage) error
 
+//ProcAppLogFunc provides callback to process log of app
+type ProcAppLogFunc func(log *logs.LogEntry) error
+
 //ProcMetricFunc provides callback to process metric
 type ProcMetricFunc func(metric *metrics.ZMetricMsg) error
 
+//ProcRequestFunc provides callback to process API request of EVE to controller
+type ProcRequestFunc func(request *types.APIRequest) error
+
 //Callback provides callback to process
 type Callback func()
 
 //ProcTimerFunc provides callback to process on timer event
 type ProcTimerFunc func() error
 
-type absFunc struct {
-	disabled bool
+//ProcOption modifies processor added to TestContext
+type ProcOption func(*processor)
+
+//WithProcTimeout fails test if processor is not done during timeout
+func WithProcTimeout(timeout time.Duration) ProcOption {
+	return func(p *processor) {
+		p.timeout = timeout
+	}
+}
+
+//WithProcName sets name of processor to use in messages instead of its type
+func WithProcName(name string) ProcOption {
+	return func(p *processor) {
+		p.name = name
+	}
+}
+
+//WithProcExisting delivers records existing in controller to processor in addition to new ones
+//existing records may be delivered after new ones and the same record may be delivered twice
+func WithProcExisting() ProcOption {
+	return func(p *processor) {
+		p.existing = true
+	}
+}
+
+//eventKind defines type of value delivered with event and processors to deliver it to
+type eventKind int
+
+const (
+	eventLog eventKind = iota
+	eventFlowLog
+	eventAppLog
+	eventInfo
+	eventMetric
+	eventRequest
+	eventTimer
+)
+
+//eventQueueSize is size of queue of events of one device
+const eventQueueSize = 1000
+
+type event struct {
+	kind  eventKind
+	appID uuid.UUID //defined for eventAppLog
+	value interface{}
+	proc  *processor //defined to deliver event to this processor only
+}
+
+type processor struct {
+	kind     eventKind
+	appID    uuid.UUID   //app to get logs for eventAppLog
+	proc     interface{} //original callback
+	name     string      //name to use in messages
+	step     string      //step processor added in
+	handle   func(value interface{}) error
 	states   bool
-	proc     interface{}
+	disabled bool
+	existing bool //deliver existing records too
+	timeout  time.Duration
+	timer    *time.Timer
+	wg       *sync.WaitGroup
+}
+
+//deviceBus delivers events of one device in order of their receiving
+type deviceBus struct {
+	events      chan *event
+	processors  []*processor
+	appLogs     map[uuid.UUID]bool //apps with started checkers of logs
+	lastRequest time.Time
 }
 
 type processingBus struct {
-	tc   *TestContext
-	wg   *sync.WaitGroup
-	proc map[*device.Ctx][]*absFunc
+	tc      *TestContext
+	mu      sync.Mutex
+	wg      *sync.WaitGroup
+	step    string //name of current step
+	devices map[*device.Ctx]*deviceBus
 }
 
 func initBus(tc *TestContext) *processingBus {
-	return &processingBus{tc: tc, proc: map[*device.Ctx][]*absFunc{}, wg: &sync.WaitGroup{}}
+	return &processingBus{tc: tc, devices: map[*device.Ctx]*deviceBus{}, wg: &sync.WaitGroup{}}
 }
 
+//newProcessor returns processor for events of type expected by procFunc
+func newProcessor(procFunc interface{}) *processor {
+	p := &processor{proc: procFunc}
+	switch pf := procFunc.(type) {
+	case ProcInfoFunc:
+		p.kind = eventInfo
+		p.handle = func(value interface{}) error { return pf(value.(*info.ZInfoMsg)) }
+	case ProcLogFunc:
+		p.kind = eventLog
+		p.handle = func(value interface{}) error { return pf(value.(*elog.FullLogEntry)) }
+	case ProcLogFlowFunc:
+		p.kind = eventFlowLog
+		p.handle = func(value interface{}) error { return pf(value.(*flowlog.FlowMessage)) }
+	case ProcAppLogFunc:
+		p.kind = eventAppLog
+		p.handle = func(value interface{}) error { return pf(value.(*logs.LogEntry)) }
+	case ProcMetricFunc:
+		p.kind = eventMetric
+		p.handle = func(value interface{}) error { return pf(value.(*metrics.ZMetricMsg)) }
+	case ProcRequestFunc:
+		p.kind = eventRequest
+		p.handle = func(value interface{}) error { return pf(value.(*types.APIRequest)) }
+	case ProcTimerFunc:
+		p.kind = eventTimer
+		p.handle = func(interface{}) error { return pf() }
+	default:
+		log.Fatalf("unsupported processor %T", procFunc)
+	}
+	return p
+}
+
+//String returns name of processor or its type with step added in
+func (p *processor) String() string {
+	name := p.name
+	if name == "" {
+		name = fmt.Sprintf("%T", p.proc)
+	}
+	if p.step != "" {
+		return fmt.Sprintf("%s (step %s)", name, p.step)
+	}
+	return name
+}
+
+//clean disables processors of current WaitGroup
 func (lb *processingBus) clean() {
-	for _, funcs := range lb.proc {
-		for _, el := range funcs {
-			if el.states || el.disabled {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	for _, db := range lb.devices {
+		for _, el := range db.processors {
+			if el.states || el.disabled || el.wg != lb.wg {
 				continue
 			}
 			el.disabled = true
+			if el.timer != nil {
+				el.timer.Stop()
+			}
 		}
 	}
 	lb.wg = &sync.WaitGroup{}
 }
 
-func (lb *processingBus) processReturn(edgeNode *device.Ctx, procFunc *absFunc, result error) {
+//beginStep starts to add processors to new WaitGroup tagged with name
+//returned function restores WaitGroup and name of previous step
+func (lb *processingBus) beginStep(name string) (end func()) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	wg, step := lb.wg, lb.step
+	lb.wg, lb.step = &sync.WaitGroup{}, name
+	return func() {
+		lb.mu.Lock()
+		defer lb.mu.Unlock()
+		lb.wg, lb.step = wg, step
+	}
+}
+
+//pending returns descriptions of processors of current WaitGroup which are not done yet
+func (lb *processingBus) pending() (result []string) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	for dev, db := range lb.devices {
+		for _, el := range db.processors {
+			if el.states || el.disabled || el.wg != lb.wg {
+				continue
+			}
+			result = append(result, fmt.Sprintf("%s for device %s", el, dev.GetID()))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+//waitGroup returns WaitGroup of active processors
+func (lb *processingBus) waitGroup() *sync.WaitGroup {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.wg
+}
+
+func (lb *processingBus) processReturn(edgeNode *device.Ctx, procFunc *processor, result error) {
 	if result != nil {
-		if lb.tc.addTime != 0 {
-			log.Infof("Expand timewait by %s", lb.tc.addTime)
-			lb.tc.stopTime.Add(lb.tc.addTime)
+		lb.mu.Lock()
+		defer lb.mu.Unlock()
+		if procFunc.disabled {
+			return
+		}
+		//only processors of current wait extend its deadline
+		if procFunc.wg == lb.wg {
+			lb.tc.extendDeadline()
 		}
 		procFunc.disabled = true
-		toRet := utils.AddTimestamp(fmt.Sprintf("%T done with return: %s", procFunc.proc, result.Error()))
+		if procFunc.timer != nil {
+			procFunc.timer.Stop()
+		}
+		toRet := utils.AddTimestamp(fmt.Sprintf("%s done with return: %s", procFunc, result.Error()))
 		if t, ok := lb.tc.tests[edgeNode]; ok {
 			t.Log(toRet)
 		}
 		log.Info(toRet)
-		lb.wg.Done()
-	}
-}
-
-func (lb *processingBus) process(edgeNode *device.Ctx, inp interface{}) bool {
-	for node, functions := range lb.proc {
-		if node == edgeNode {
-			for _, procFunc := range functions {
-				if !procFunc.disabled {
-					switch pf := procFunc.proc.(type) {
-					case ProcInfoFunc:
-						el, match := inp.(*info.ZInfoMsg)
-						if match {
-							lb.processReturn(edgeNode, procFunc, pf(el))
-						}
-					case ProcLogFunc:
-						el, match := inp.(*elog.FullLogEntry)
-						if match {
-							lb.processReturn(edgeNode, procFunc, pf(el))
-						}
-					case ProcMetricFunc:
-						el, match := inp.(*metrics.ZMetricMsg)
-						if match {
-							lb.processReturn(edgeNode, procFunc, pf(el))
-						}
-					}
-				}
-			}
-		}
+		procFunc.wg.Done()
 	}
-	return false
 }
 
-func (lb *processingBus) processTimers(edgeNode *device.Ctx) bool {
-	for node, functions := range lb.proc {
-		if node == edgeNode {
-			for _, procFunc := range functions {
-				if !procFunc.disabled {
-					switch pf := procFunc.proc.(type) {
-					case ProcTimerFunc:
-						lb.processReturn(edgeNode, procFunc, pf())
-					}
-				}
-			}
-		}
+//processTimeout fails test if processor is not done yet
+func (lb *processingBus) processTimeout(edgeNode *device.Ctx, procFunc *processor) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if procFunc.disabled {
+		return
 	}
-	return false
+	procFunc.disabled = true
+	toRet := utils.AddTimestamp(fmt.Sprintf("%s terminated by timeout %s", procFunc, procFunc.timeout))
+	if t, ok := lb.tc.tests[edgeNode]; ok {
+		t.Error(toRet)
+	} else {
+		log.Error(toRet)
+	}
+	procFunc.wg.Done()
 }
 
-func (lb *processingBus) getMainProcessorLog(dev *device.Ctx) elog.HandlerFunc {
-	return func(im *elog.FullLogEntry) bool {
-		return lb.process(dev, im)
+//dispatch delivers events of edgeNode to processors of the same kind one by one
+func (lb *processingBus) dispatch(edgeNode *device.Ctx, db *deviceBus) {
+	for ev := range db.events {
+		var active []*processor
+		lb.mu.Lock()
+		for _, procFunc := range db.processors {
+			if procFunc.disabled || procFunc.kind != ev.kind {
+				continue
+			}
+			if ev.kind == eventAppLog && procFunc.appID != ev.appID {
+				continue
+			}
+			if ev.proc != nil && ev.proc != procFunc {
+				continue
+			}
+			active = append(active, procFunc)
+		}
+		lb.mu.Unlock()
+		//processors may add new ones, so we call them without lock
+		for _, procFunc := range active {
+			lb.processReturn(edgeNode, procFunc, procFunc.handle(ev.value))
+		}
 	}
 }
 
-func (lb *processingBus) getMainProcessorFlowLog(dev *device.Ctx) eflowlog.HandlerFunc {
-	return func(msg *flowlog.FlowMessage) bool {
-		return lb.process(dev, msg)
+//hasProcessors checks if there are active processors of kind for edgeNode
+func (lb *processingBus) hasProcessors(db *deviceBus, kind eventKind) bool {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	for _, procFunc := range db.processors {
+		if !procFunc.disabled && procFunc.kind == kind {
+			return true
+		}
 	}
+	return false
 }
 
-func (lb *processingBus) getMainProcessorInfo(dev *device.Ctx) einfo.HandlerFunc {
-	return func(im *info.ZInfoMsg, ds []*einfo.ZInfoMsgInterface) bool {
-		return lb.process(dev, im)
+//processRequests sends requests of edgeNode received after the last processed one
+//controller provides only existing requests, so we call it on timer
+func (lb *processingBus) processRequests(edgeNode *device.Ctx, db *deviceBus) {
+	if !lb.hasProcessors(db, eventRequest) {
+		return
 	}
+	last := db.lastRequest
+	err := lb.tc.GetController().RequestLastCallback(edgeNode.GetID(), map[string]string{}, func(request *types.APIRequest) bool {
+		if request.Timestamp.After(db.lastRequest) {
+			db.events <- &event{kind: eventRequest, value: request}
+			if request.Timestamp.After(last) {
+				last = request.Timestamp
+			}
+		}
+		return false
+	})
+	if err != nil {
+		log.Errorf("RequestLastCallback for dev %s error %s", edgeNode.GetID(), err)
+	}
+	db.lastRequest = last
 }
 
-func (lb *processingBus) getMainProcessorMetric(dev *device.Ctx) emetric.HandlerFunc {
-	return func(msg *metrics.ZMetricMsg) bool {
-		return lb.process(dev, msg)
+//processExisting sends records of edgeNode existing in controller to procFunc
+func (lb *processingBus) processExisting(edgeNode *device.Ctx, db *deviceBus, procFunc *processor) {
+	var err error
+	send := func(value interface{}) {
+		db.events <- &event{kind: procFunc.kind, appID: procFunc.appID, value: value, proc: procFunc}
+	}
+	switch procFunc.kind {
+	case eventLog:
+		err = lb.tc.GetController().LogLastCallback(edgeNode.GetID(), map[string]string{}, func(im *elog.FullLogEntry) bool {
+			send(im)
+			return false
+		})
+	case eventFlowLog:
+		err = lb.tc.GetController().FlowLogLastCallback(edgeNode.GetID(), map[string]string{}, func(msg *flowlog.FlowMessage) bool {
+			send(msg)
+			return false
+		})
+	case eventAppLog:
+		err = lb.tc.GetController().LogAppsLastCallback(edgeNode.GetID(), procFunc.appID, map[string]string{}, func(le *logs.LogEntry) bool {
+			send(le)
+			return false
+		})
+	case eventInfo:
+		err = lb.tc.GetController().InfoLastCallback(edgeNode.GetID(), map[string]string{}, func(im *info.ZInfoMsg, ds []*einfo.ZInfoMsgInterface) bool {
+			send(im)
+			return false
+		})
+	case eventMetric:
+		err = lb.tc.GetController().MetricLastCallback(edgeNode.GetID(), map[string]string{}, func(msg *metrics.ZMetricMsg) bool {
+			send(msg)
+			return false
+		})
+	default:
+		log.Errorf("existing records are not supported for %s", procFunc)
+		return
+	}
+	if err != nil {
+		log.Errorf("processing of existing records for %s of dev %s error %s", procFunc, edgeNode.GetID(), err)
 	}
 }
 
-func (lb *processingBus) initCheckers(dev *device.Ctx) {
+func (lb *processingBus) initCheckers(dev *device.Ctx, db *deviceBus) {
+	go lb.dispatch(dev, db)
 	go func() {
-		err := lb.tc.GetController().LogChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorLog(dev), elog.LogNew, 0)
+		err := lb.tc.GetController().LogChecker(dev.GetID(), map[string]string{}, func(im *elog.FullLogEntry) bool {
+			db.events <- &event{kind: eventLog, value: im}
+			return false
+		}, elog.LogNew, 0)
 		if err != nil {
 			log.Errorf("LogChecker for dev %s error %s", dev.GetID(), err)
 		}
 	}()
 	go func() {
-		err := lb.tc.GetController().FlowLogChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorFlowLog(dev), eflowlog.FlowLogNew, 0)
+		err := lb.tc.GetController().FlowLogChecker(dev.GetID(), map[string]string{}, func(msg *flowlog.FlowMessage) bool {
+			db.events <- &event{kind: eventFlowLog, value: msg}
+			return false
+		}, eflowlog.FlowLogNew, 0)
 		if err != nil {
 			log.Errorf("FlowLogChecker for dev %s error %s", dev.GetID(), err)
 		}
 	}()
 	go func() {
-		err := lb.tc.GetController().InfoChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorInfo(dev), einfo.InfoNew, 0)
+		err := lb.tc.GetController().InfoChecker(dev.GetID(), map[string]string{}, func(im *info.ZInfoMsg, ds []*einfo.ZInfoMsgInterface) bool {
+			db.events <- &event{kind: eventInfo, value: im}
+			return false
+		}, einfo.InfoNew, 0)
 		if err != nil {
 			log.Errorf("InfoChecker for dev %s error %s", dev.GetID(), err)
 		}
 	}()
 	go func() {
-		err := lb.tc.GetController().MetricChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorMetric(dev), emetric.MetricNew, 0)
+		err := lb.tc.GetController().MetricChecker(dev.GetID(), map[string]string{}, func(msg *metrics.ZMetricMsg) bool {
+			db.events <- &event{kind: eventMetric, value: msg}
+			return false
+		}, emetric.MetricNew, 0)
 		if err != nil {
 			log.Errorf("MetricChecker for dev %s error %s", dev.GetID(), err)
 		}
 	}()
 	go func() {
-		for {
-			timer := time.NewTimer(defaults.DefaultRepeatTimeout * 2)
-			<-timer.C
-			lb.processTimers(dev)
+		ticker := time.NewTicker(defaults.DefaultRepeatTimeout * 2)
+		defer ticker.Stop()
+		for range ticker.C {
+			lb.processRequests(dev, db)
+			db.events <- &event{kind: eventTimer}
 		}
 	}()
 }
 
-func (lb *processingBus) addProc(dev *device.Ctx, procFunc interface{}) {
-	if _, exists := lb.proc[dev]; !exists {
-		lb.initCheckers(dev)
+func (lb *processingBus) initAppLogChecker(dev *device.Ctx, db *deviceBus, appID uuid.UUID) {
+	go func() {
+		err := lb.tc.GetController().LogAppsChecker(dev.GetID(), appID, map[string]string{}, func(le *logs.LogEntry) bool {
+			db.events <- &event{kind: eventAppLog, appID: appID, value: le}
+			return false
+		}, eapps.LogNew, 0)
+		if err != nil {
+			log.Errorf("LogAppsChecker for app %s of dev %s error %s", appID, dev.GetID(), err)
+		}
+	}()
+}
+
+//getDeviceBus returns bus of dev and starts it if not exists, must be called with lock
+func (lb *processingBus) getDeviceBus(dev *device.Ctx) *deviceBus {
+	db, exists := lb.devices[dev]
+	if !exists {
+		db = &deviceBus{
+			events:      make(chan *event, eventQueueSize),
+			appLogs:     map[uuid.UUID]bool{},
+			lastRequest: time.Now(),
+		}
+		lb.devices[dev] = db
+		lb.initCheckers(dev, db)
+	}
+	return db
+}
+
+func (lb *processingBus) addProcessor(dev *device.Ctx, procFunc *processor, opts ...ProcOption) {
+	for _, opt := range opts {
+		opt(procFunc)
 	}
-	lb.proc[dev] = append(lb.proc[dev], &absFunc{proc: procFunc, disabled: false})
-	lb.wg.Add(1)
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	db := lb.getDeviceBus(dev)
+	if procFunc.kind == eventAppLog && !db.appLogs[procFunc.appID] {
+		db.appLogs[procFunc.appID] = true
+		lb.initAppLogChecker(dev, db, procFunc.appID)
+	}
+	db.processors = append(db.processors, procFunc)
+	if procFunc.existing {
+		go lb.processExisting(dev, db, procFunc)
+	}
+	if procFunc.states {
+		return
+	}
+	procFunc.wg = lb.wg
+	procFunc.step = lb.step
+	procFunc.wg.Add(1)
+	if procFunc.timeout > 0 {
+		procFunc.timer = time.AfterFunc(procFunc.timeout, func() {
+			lb.processTimeout(dev, procFunc)
+		})
+	}
+}
+
+func (lb *processingBus) addProc(dev *device.Ctx, procFunc interface{}, opts ...ProcOption) {
+	lb.addProcessor(dev, newProcessor(procFunc), opts...)
+}
+
+func (lb *processingBus) addAppLogProc(dev *device.Ctx, appID uuid.UUID, procFunc ProcAppLogFunc, opts ...ProcOption) {
+	p := newProcessor(procFunc)
+	p.appID = appID
+	lb.addProcessor(dev, p, opts...)
+}
+
+//addStateProc adds processor to feed State, it is not waited and not removed by clean
+func (lb *processingBus) addStateProc(dev *device.Ctx, procFunc interface{}) {
+	p := newProcessor(procFunc)
+	p.states = true
+	lb.addProcessor(dev, p)
 }
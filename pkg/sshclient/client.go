@@ -0,0 +1,321 @@
+//Package sshclient provides SSH client to run commands, transfer files with SFTP and forward ports
+//without OpenSSH installed on the host
+package sshclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/term"
+)
+
+//HostKeyCheck defines verification of host keys with known hosts file
+type HostKeyCheck string
+
+//Modes of verification of host keys, they match values of StrictHostKeyChecking option of OpenSSH
+const (
+	HostKeyCheckNo        HostKeyCheck = "no"         //host keys are not verified
+	HostKeyCheckAcceptNew HostKeyCheck = "accept-new" //keys of unknown hosts are added, changed keys are rejected
+	HostKeyCheckYes       HostKeyCheck = "yes"        //only keys of known hosts are accepted
+)
+
+//Config contains settings of connection
+type Config struct {
+	Host           string
+	Port           int
+	User           string
+	KeyFile        string //private key for authentication
+	Password       string //password for authentication, used if not empty
+	KnownHostsFile string //file with known hosts, ~/.ssh/known_hosts if empty
+	HostKeyCheck   HostKeyCheck
+	Timeout        time.Duration //timeout of connection
+	KeepAlive      time.Duration //interval of keepalive requests, disabled if zero
+}
+
+//Client is connection to SSH server
+type Client struct {
+	*ssh.Client
+	done chan struct{}
+}
+
+//Addr returns address of server in host:port format
+func (cfg *Config) Addr() string {
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+}
+
+func (cfg *Config) authMethods() ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	if cfg.KeyFile != "" {
+		key, err := ioutil.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read key: %s", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse key %s: %s", cfg.KeyFile, err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		methods = append(methods, ssh.Password(cfg.Password))
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("no key or password provided")
+	}
+	return methods, nil
+}
+
+func (cfg *Config) knownHostsFile() (string, error) {
+	if cfg.KnownHostsFile != "" {
+		return cfg.KnownHostsFile, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ssh", "known_hosts"), nil
+}
+
+//hostKeyCallback returns callback to verify host keys according to HostKeyCheck
+func (cfg *Config) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if cfg.HostKeyCheck == "" || cfg.HostKeyCheck == HostKeyCheckNo {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	fileName, err := cfg.knownHostsFile()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = os.Stat(fileName); os.IsNotExist(err) {
+		if err = os.MkdirAll(filepath.Dir(fileName), 0700); err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(fileName, nil, 0600); err != nil {
+			return nil, err
+		}
+	}
+	callback, err := knownhosts.New(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load known hosts from %s: %s", fileName, err)
+	}
+	if cfg.HostKeyCheck != HostKeyCheckAcceptNew {
+		return callback, nil
+	}
+	var mu sync.Mutex
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		f, err := os.OpenFile(fileName, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		log.Infof("Adding key of %s to %s", hostname, fileName)
+		_, err = fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key))
+		return err
+	}, nil
+}
+
+//Dial connects to SSH server defined by cfg
+func Dial(cfg *Config) (*Client, error) {
+	auth, err := cfg.authMethods()
+	if err != nil {
+		return nil, err
+	}
+	hostKeyCallback, err := cfg.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := ssh.Dial("tcp", cfg.Addr(), &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         cfg.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{Client: conn, done: make(chan struct{})}
+	if cfg.KeepAlive > 0 {
+		go c.keepAlive(cfg.KeepAlive)
+	}
+	return c, nil
+}
+
+func (c *Client) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if _, _, err := c.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				log.Debugf("keepalive: %s", err)
+				_ = c.Client.Close()
+				return
+			}
+		}
+	}
+}
+
+//Close closes connection
+func (c *Client) Close() error {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	return c.Client.Close()
+}
+
+//exitCode returns exit code of remote command from result of its run
+func exitCode(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	var missingErr *ssh.ExitMissingError
+	if errors.As(err, &missingErr) {
+		return -1, nil
+	}
+	return -1, err
+}
+
+//Run runs command and returns its exit code, error is returned only if command cannot be run
+//stdin is not waited for end, so it may be terminal or pipe without EOF
+func (c *Client) Run(command string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return -1, err
+	}
+	defer session.Close()
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if stdin != nil {
+		pipe, err := session.StdinPipe()
+		if err != nil {
+			return -1, err
+		}
+		go func() {
+			_, _ = io.Copy(pipe, stdin)
+			_ = pipe.Close()
+		}()
+	}
+	return exitCode(session.Run(command))
+}
+
+//Output runs command and returns its stdout and exit code
+func (c *Client) Output(command string) ([]byte, int, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return nil, -1, err
+	}
+	defer session.Close()
+	out, err := session.Output(command)
+	code, err := exitCode(err)
+	return out, code, err
+}
+
+//Shell runs interactive shell with terminal if stdin is terminal and returns its exit code
+func (c *Client) Shell(stdin *os.File, stdout, stderr io.Writer) (int, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return -1, err
+	}
+	defer session.Close()
+	session.Stdout = stdout
+	session.Stderr = stderr
+	fd := int(stdin.Fd())
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return -1, err
+		}
+		defer func() {
+			_ = term.Restore(fd, state)
+		}()
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		termType := os.Getenv("TERM")
+		if termType == "" {
+			termType = "xterm"
+		}
+		if err = session.RequestPty(termType, height, width, ssh.TerminalModes{ssh.ECHO: 1}); err != nil {
+			return -1, err
+		}
+	}
+	pipe, err := session.StdinPipe()
+	if err != nil {
+		return -1, err
+	}
+	go func() {
+		_, _ = io.Copy(pipe, stdin)
+		_ = pipe.Close()
+	}()
+	if err = session.Shell(); err != nil {
+		return -1, err
+	}
+	return exitCode(session.Wait())
+}
+
+//Forward listens on localAddr and forwards connections to remoteAddr through server
+//forwarding stops after close of returned listener
+func (c *Client) Forward(localAddr, remoteAddr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", localAddr)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			local, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer local.Close()
+				remote, err := c.Dial("tcp", remoteAddr)
+				if err != nil {
+					log.Errorf("cannot forward connection to %s: %s", remoteAddr, err)
+					return
+				}
+				defer remote.Close()
+				done := make(chan struct{}, 2)
+				go func() {
+					_, _ = io.Copy(remote, local)
+					done <- struct{}{}
+				}()
+				go func() {
+					_, _ = io.Copy(local, remote)
+					done <- struct{}{}
+				}()
+				<-done
+			}()
+		}
+	}()
+	return listener, nil
+}
@@ -0,0 +1,83 @@
+package sshclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//SetOption applies option in OpenSSH format (Key=Value or "Key Value") to cfg
+//options not related to supported features are ignored
+func (cfg *Config) SetOption(option string) error {
+	option = strings.TrimSpace(option)
+	key, value := option, ""
+	if i := strings.IndexAny(option, "= "); i >= 0 {
+		key, value = option[:i], strings.TrimSpace(option[i+1:])
+	}
+	switch strings.ToLower(key) {
+	case "connecttimeout":
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("wrong ConnectTimeout %q: %s", value, err)
+		}
+		cfg.Timeout = time.Duration(seconds) * time.Second
+	case "serveraliveinterval":
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("wrong ServerAliveInterval %q: %s", value, err)
+		}
+		cfg.KeepAlive = time.Duration(seconds) * time.Second
+	case "stricthostkeychecking":
+		switch strings.ToLower(value) {
+		case "no", "off":
+			cfg.HostKeyCheck = HostKeyCheckNo
+		case "yes", "ask":
+			cfg.HostKeyCheck = HostKeyCheckYes
+		case "accept-new":
+			cfg.HostKeyCheck = HostKeyCheckAcceptNew
+		default:
+			return fmt.Errorf("wrong StrictHostKeyChecking %q", value)
+		}
+	case "userknownhostsfile":
+		cfg.KnownHostsFile = value
+	case "passwordauthentication":
+		if strings.ToLower(value) == "no" {
+			cfg.Password = ""
+		}
+	case "identityfile":
+		cfg.KeyFile = value
+	case "port":
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("wrong Port %q: %s", value, err)
+		}
+		cfg.Port = port
+	case "user":
+		cfg.User = value
+	default:
+		log.Debugf("ssh option %s ignored", key)
+	}
+	return nil
+}
+
+//ParseDestination returns user and host from destination in [user@]host format
+//defaultUser is returned if user is not defined
+func ParseDestination(destination, defaultUser string) (string, string) {
+	if i := strings.LastIndex(destination, "@"); i >= 0 {
+		return destination[:i], destination[i+1:]
+	}
+	return defaultUser, destination
+}
+
+//ParseRemotePath returns destination and path from argument in [user@]host:path format
+//ok is false for local paths
+func ParseRemotePath(arg string) (destination, path string, ok bool) {
+	i := strings.Index(arg, ":")
+	if i <= 0 || strings.ContainsAny(arg[:i], "/\\") {
+		return "", arg, false
+	}
+	return arg[:i], arg[i+1:], true
+}
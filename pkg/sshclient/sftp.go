@@ -0,0 +1,402 @@
+package sshclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//SFTP version 3 packet types and constants (draft-ietf-secsh-filexfer-02)
+const (
+	sftpInit     byte = 1
+	sftpVersion  byte = 2
+	sftpOpen     byte = 3
+	sftpClose    byte = 4
+	sftpRead     byte = 5
+	sftpWrite    byte = 6
+	sftpStat     byte = 17
+	sftpStatus   byte = 101
+	sftpHandle   byte = 102
+	sftpData     byte = 103
+	sftpAttrs    byte = 105
+	sftpProtocol      = 3
+
+	sftpFlagRead  = 0x01
+	sftpFlagWrite = 0x02
+	sftpFlagCreat = 0x08
+	sftpFlagTrunc = 0x10
+
+	sftpAttrSize        = 0x01
+	sftpAttrPermissions = 0x04
+
+	sftpStatusOK  = 0
+	sftpStatusEOF = 1
+
+	sftpChunkSize = 32 * 1024
+)
+
+//SFTP is client of SFTP subsystem of server
+type SFTP struct {
+	mu     sync.Mutex
+	w      io.WriteCloser
+	r      io.Reader
+	nextID uint32
+	close  func() error
+}
+
+//StatusError is error returned by SFTP server
+type StatusError struct {
+	Code    uint32
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("sftp: %s (code %d)", e.Message, e.Code)
+}
+
+//SFTP starts SFTP subsystem on server
+func (c *Client) SFTP() (*SFTP, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	if err = session.RequestSubsystem("sftp"); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("cannot start sftp subsystem: %s", err)
+	}
+	s, err := NewSFTP(r, w)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	s.close = session.Close
+	return s, nil
+}
+
+//NewSFTP initializes SFTP session over r and w
+func NewSFTP(r io.Reader, w io.WriteCloser) (*SFTP, error) {
+	s := &SFTP{r: r, w: w, nextID: 1}
+	if err := s.send(sftpInit, uint32(sftpProtocol)); err != nil {
+		return nil, err
+	}
+	typ, data, err := s.recv()
+	if err != nil {
+		return nil, err
+	}
+	if typ != sftpVersion || len(data) < 4 {
+		return nil, fmt.Errorf("sftp: unexpected packet %d instead of version", typ)
+	}
+	if version := binary.BigEndian.Uint32(data); version < sftpProtocol {
+		return nil, fmt.Errorf("sftp: unsupported version %d", version)
+	}
+	return s, nil
+}
+
+//Close closes SFTP session
+func (s *SFTP) Close() error {
+	err := s.w.Close()
+	if s.close != nil {
+		if closeErr := s.close(); closeErr != nil && closeErr != io.EOF {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+//marshal appends fields to packet, strings and byte slices are prefixed with length
+//it returns error for field of unsupported type
+func marshal(b []byte, fields ...interface{}) ([]byte, error) {
+	for _, field := range fields {
+		switch v := field.(type) {
+		case byte:
+			b = append(b, v)
+		case uint32:
+			b = appendUint32(b, v)
+		case uint64:
+			b = append(b, 0, 0, 0, 0, 0, 0, 0, 0)
+			binary.BigEndian.PutUint64(b[len(b)-8:], v)
+		case string:
+			b = appendUint32(b, uint32(len(v)))
+			b = append(b, v...)
+		case []byte:
+			b = appendUint32(b, uint32(len(v)))
+			b = append(b, v...)
+		default:
+			return nil, fmt.Errorf("sftp: cannot marshal %T", field)
+		}
+	}
+	return b, nil
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	b = append(b, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(b[len(b)-4:], v)
+	return b
+}
+
+func (s *SFTP) send(typ byte, fields ...interface{}) error {
+	payload, err := marshal([]byte{typ}, fields...)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(appendUint32(nil, uint32(len(payload))), payload...))
+	return err
+}
+
+func (s *SFTP) recv() (byte, []byte, error) {
+	var length uint32
+	if err := binary.Read(s.r, binary.BigEndian, &length); err != nil {
+		return 0, nil, err
+	}
+	if length == 0 || length > 1<<20 {
+		return 0, nil, fmt.Errorf("sftp: wrong packet length %d", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(s.r, data); err != nil {
+		return 0, nil, err
+	}
+	return data[0], data[1:], nil
+}
+
+//request sends request with new id and returns type and payload of response after id
+func (s *SFTP) request(typ byte, fields ...interface{}) (byte, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextID
+	s.nextID++
+	if err := s.send(typ, append([]interface{}{id}, fields...)...); err != nil {
+		return 0, nil, err
+	}
+	respType, data, err := s.recv()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) < 4 || binary.BigEndian.Uint32(data) != id {
+		return 0, nil, errors.New("sftp: unexpected response id")
+	}
+	return respType, data[4:], nil
+}
+
+//readString reads string prefixed with length from data and returns rest of data
+func readString(data []byte) (string, []byte, error) {
+	if len(data) < 4 {
+		return "", nil, errors.New("sftp: short packet")
+	}
+	length := binary.BigEndian.Uint32(data)
+	if uint32(len(data)-4) < length {
+		return "", nil, errors.New("sftp: short packet")
+	}
+	return string(data[4 : 4+length]), data[4+length:], nil
+}
+
+//statusError returns error from status response, nil for OK status
+func statusError(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("sftp: short status")
+	}
+	code := binary.BigEndian.Uint32(data)
+	if code == sftpStatusOK {
+		return nil
+	}
+	if code == sftpStatusEOF {
+		return io.EOF
+	}
+	msg, _, _ := readString(data[4:])
+	return &StatusError{Code: code, Message: msg}
+}
+
+//expectStatus checks response to be OK status
+func expectStatus(typ byte, data []byte, err error) error {
+	if err != nil {
+		return err
+	}
+	if typ != sftpStatus {
+		return fmt.Errorf("sftp: unexpected packet %d instead of status", typ)
+	}
+	return statusError(data)
+}
+
+func (s *SFTP) open(path string, flags uint32, perm os.FileMode) (string, error) {
+	typ, data, err := s.request(sftpOpen, path, flags, uint32(sftpAttrPermissions), uint32(perm.Perm()))
+	if err != nil {
+		return "", err
+	}
+	switch typ {
+	case sftpHandle:
+		handle, _, err := readString(data)
+		return handle, err
+	case sftpStatus:
+		if err = statusError(data); err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("sftp: unexpected packet %d instead of handle", typ)
+}
+
+func (s *SFTP) closeHandle(handle string) error {
+	return expectStatus(s.request(sftpClose, handle))
+}
+
+//Size returns size of remote file
+func (s *SFTP) Size(path string) (int64, error) {
+	typ, data, err := s.request(sftpStat, path)
+	if err != nil {
+		return 0, err
+	}
+	if typ == sftpStatus {
+		if err = statusError(data); err == nil {
+			err = errors.New("sftp: no attributes")
+		}
+		return 0, err
+	}
+	if typ != sftpAttrs || len(data) < 4 {
+		return 0, fmt.Errorf("sftp: unexpected packet %d instead of attributes", typ)
+	}
+	if binary.BigEndian.Uint32(data)&sftpAttrSize == 0 || len(data) < 12 {
+		return 0, errors.New("sftp: no size in attributes")
+	}
+	return int64(binary.BigEndian.Uint64(data[4:])), nil
+}
+
+//Put writes content of r into remote file with path and permissions perm
+func (s *SFTP) Put(r io.Reader, path string, perm os.FileMode) error {
+	handle, err := s.open(path, sftpFlagWrite|sftpFlagCreat|sftpFlagTrunc, perm)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %s", path, err)
+	}
+	buf := make([]byte, sftpChunkSize)
+	var offset uint64
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			if err = expectStatus(s.request(sftpWrite, handle, offset, buf[:n])); err != nil {
+				_ = s.closeHandle(handle)
+				return fmt.Errorf("cannot write %s: %s", path, err)
+			}
+			offset += uint64(n)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			_ = s.closeHandle(handle)
+			return readErr
+		}
+	}
+	return s.closeHandle(handle)
+}
+
+//Get writes content of remote file with path into w
+func (s *SFTP) Get(path string, w io.Writer) error {
+	handle, err := s.open(path, sftpFlagRead, 0)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %s", path, err)
+	}
+	var offset uint64
+	for {
+		typ, data, err := s.request(sftpRead, handle, offset, uint32(sftpChunkSize))
+		if err != nil {
+			return err
+		}
+		if typ == sftpStatus {
+			if err = statusError(data); err == io.EOF {
+				break
+			}
+			_ = s.closeHandle(handle)
+			return fmt.Errorf("cannot read %s: %v", path, err)
+		}
+		if typ != sftpData {
+			_ = s.closeHandle(handle)
+			return fmt.Errorf("sftp: unexpected packet %d instead of data", typ)
+		}
+		chunk, _, err := readString(data)
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write([]byte(chunk)); err != nil {
+			_ = s.closeHandle(handle)
+			return err
+		}
+		offset += uint64(len(chunk))
+	}
+	return s.closeHandle(handle)
+}
+
+//shellQuote quotes argument for POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+//Upload copies local file into remote path with permissions of local file
+//file is streamed with cat if server has no SFTP subsystem
+func (c *Client) Upload(localFile, remotePath string) error {
+	f, err := os.Open(localFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	s, err := c.SFTP()
+	if err != nil {
+		log.Debugf("%s, fallback to cat", err)
+		var stderr bytes.Buffer
+		command := fmt.Sprintf("cat > %s && chmod %o %s", shellQuote(remotePath), info.Mode().Perm(), shellQuote(remotePath))
+		code, err := c.Run(command, f, ioutil.Discard, &stderr)
+		if err == nil && code != 0 {
+			err = fmt.Errorf("cannot write %s: %s", remotePath, strings.TrimSpace(stderr.String()))
+		}
+		return err
+	}
+	defer s.Close()
+	return s.Put(f, remotePath, info.Mode())
+}
+
+//Download copies remote file into local file
+//file is streamed with cat if server has no SFTP subsystem
+func (c *Client) Download(remotePath, localFile string) error {
+	f, err := os.Create(localFile)
+	if err != nil {
+		return err
+	}
+	s, err := c.SFTP()
+	if err != nil {
+		log.Debugf("%s, fallback to cat", err)
+		var stderr bytes.Buffer
+		code, err := c.Run(fmt.Sprintf("cat %s", shellQuote(remotePath)), nil, f, &stderr)
+		if err == nil && code != 0 {
+			err = fmt.Errorf("cannot read %s: %s", remotePath, strings.TrimSpace(stderr.String()))
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	defer s.Close()
+	if err = s.Get(remotePath, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/spf13/viper"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -68,6 +69,11 @@ func RunTest(testApp string, args []string, testArgs string, testTimeout string,
 		tst := exec.Command(path, resultArgs...)
 		tst.Stdout = os.Stdout
 		tst.Stderr = os.Stderr
+		output := &syncBuffer{}
+		if report != nil {
+			tst.Stdout = io.MultiWriter(os.Stdout, output)
+			tst.Stderr = io.MultiWriter(os.Stderr, output)
+		}
 		tst.Env = append(os.Environ(), fmt.Sprintf("%s=%s",
 			defaults.DefaultConfigEnv, viper.Get("eve.name")))
 
@@ -76,7 +82,8 @@ func RunTest(testApp string, args []string, testArgs string, testTimeout string,
 			targs = fmt.Sprintf("%s -test.timeout=%s",
 				targs, testTimeout)
 		}
-		if verbosity != "info" {
+		if verbosity != "info" || report != nil {
+			//results of single tests are parsed for report from verbose output
 			targs = fmt.Sprintf("%s -test.v", targs)
 		}
 
@@ -87,18 +94,52 @@ func RunTest(testApp string, args []string, testArgs string, testTimeout string,
 					defaults.DefaultTestArgsEnv, targs))
 		}
 
+		start := time.Now()
 		err = tst.Run()
 		close(done)
+		var suite *SuiteResult
+		if report != nil {
+			if failedSuite != nil {
+				//test of fail scenario is reported inside of failed suite
+				report.addFailScenarioOutput(failedSuite, output.String())
+			} else {
+				suite = NewSuiteResult(strings.Join(append([]string{testApp}, resultArgs...), " "),
+					start, time.Since(start), output.String(), err)
+				report.Add(suite)
+			}
+		}
 
 		if err != nil && failScenario != "" {
 			log.Debug("failScenario: ", failScenario)
-			RunScenario("", "", testTimeout, "",
-				configFile, "")
+			if suite != nil {
+				report.addFailScenarioOutput(suite, fmt.Sprintf("%s%s\n", failScenarioHeader, failScenario))
+				failedSuite = suite
+			}
+			RunFailScenario(failScenario, testTimeout, configFile)
+			failedSuite = nil
+			if err := FinishReport(); err != nil {
+				log.Error(err)
+			}
 			os.Exit(1)
 		}
 	}
 }
 
+//RunFailScenario -- run a scenario after tests failed, its output is separated in reports
+func RunFailScenario(failScenario string, testTimeout string, configFile string) {
+	if failScenario == "" {
+		return
+	}
+	if _, err := os.Stat(failScenario); os.IsNotExist(err) {
+		if _, err = os.Stat(utils.ResolveAbsPath(failScenario)); os.IsNotExist(err) {
+			log.Warnf("Fail scenario file '%s' is not exist", failScenario)
+			return
+		}
+	}
+	fmt.Printf("%s%s\n", failScenarioHeader, failScenario)
+	RunScenario(failScenario, "", testTimeout, "", configFile, "")
+}
+
 //RunScenario -- run a scenario with a test suite
 func RunScenario(testScenario string, testArgs string, testTimeout string, failScenario string, configFile string, verbosity string) {
 	if testScenario == "" {
@@ -0,0 +1,326 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//failScenarioHeader is printed before output of fail scenario to separate it in reports
+const failScenarioHeader = "=== FAIL SCENARIO: "
+
+//Case statuses
+const (
+	CasePass = "pass"
+	CaseFail = "fail"
+	CaseSkip = "skip"
+)
+
+//resultLine matches result of test in verbose output of go test
+var resultLine = regexp.MustCompile(`^(\s*)--- (PASS|FAIL|SKIP): (\S+) \(([0-9.]+)s\)`)
+
+//markerLine matches line of verbose output of go test after which output of test is printed
+var markerLine = regexp.MustCompile(`^=== (RUN|PAUSE|CONT|NAME)\s+(\S+)`)
+
+//CaseResult is result of one test (escript file for eden.escript.test)
+type CaseResult struct {
+	Name   string  `json:"name"`
+	Status string  `json:"status"`
+	Time   float64 `json:"time"`
+	Output string  `json:"output,omitempty"`
+}
+
+//SuiteResult is result of one run of test binary (line of scenario)
+type SuiteResult struct {
+	Name               string        `json:"name"`
+	Timestamp          time.Time     `json:"timestamp"`
+	Time               float64       `json:"time"`
+	Failed             bool          `json:"failed"`
+	Error              string        `json:"error,omitempty"`
+	Output             string        `json:"output,omitempty"`
+	FailScenarioOutput string        `json:"fail_scenario_output,omitempty"`
+	Cases              []*CaseResult `json:"cases"`
+}
+
+//Report collects results of test binaries
+type Report struct {
+	mu       sync.Mutex
+	Tests    int            `json:"tests"`
+	Failures int            `json:"failures"`
+	Skipped  int            `json:"skipped"`
+	Time     float64        `json:"time"`
+	Suites   []*SuiteResult `json:"suites"`
+}
+
+//report collects results of RunTest if set with StartReport
+//failedSuite is set while fail scenario runs, outputs of its tests are attached to failedSuite
+var (
+	report      *Report
+	reportFile  string
+	failedSuite *SuiteResult
+)
+
+//NewSuiteResult returns result of test binary with name from its output, duration of run and returned error
+//results of single tests are parsed from output if test binary runs with -test.v
+func NewSuiteResult(name string, start time.Time, duration time.Duration, output string, err error) *SuiteResult {
+	suite := &SuiteResult{
+		Name:      name,
+		Timestamp: start,
+		Time:      duration.Seconds(),
+		Failed:    err != nil,
+		Output:    output,
+	}
+	if ind := strings.Index(output, failScenarioHeader); ind >= 0 {
+		suite.Output = output[:ind]
+		suite.FailScenarioOutput = output[ind:]
+	}
+	suite.Cases = parseTestOutput(suite.Output)
+	if err != nil {
+		suite.Error = err.Error()
+	}
+	failed := false
+	for _, el := range suite.Cases {
+		if el.Status == CaseFail {
+			failed = true
+		}
+	}
+	if len(suite.Cases) == 0 || (suite.Failed && !failed) {
+		//no results of single tests or test binary failed outside of them
+		c := &CaseResult{Name: name, Status: CasePass, Time: suite.Time}
+		if suite.Failed {
+			c.Status = CaseFail
+			c.Output = suite.Error
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	return suite
+}
+
+//parseTestOutput returns results of tests from verbose output of go test
+//output of test is collected from lines after its === RUN, === CONT or === NAME marker
+//and from lines with greater indent after its result line printed by older versions of go
+//tests with subtests are skipped to count only the innermost ones
+func parseTestOutput(output string) (result []*CaseResult) {
+	outputs := make(map[string][]string)
+	current := ""
+	lines := strings.Split(output, "\n")
+	for i := 0; i < len(lines); i++ {
+		if m := markerLine.FindStringSubmatch(lines[i]); m != nil {
+			current = m[2]
+			if m[1] == "PAUSE" {
+				current = ""
+			}
+			continue
+		}
+		m := resultLine.FindStringSubmatch(lines[i])
+		if m == nil {
+			if line := strings.TrimSpace(lines[i]); current != "" && line != "" {
+				outputs[current] = append(outputs[current], line)
+			}
+			continue
+		}
+		current = ""
+		indent := len(m[1])
+		seconds, _ := strconv.ParseFloat(m[4], 64)
+		c := &CaseResult{Name: m[3], Status: strings.ToLower(m[2]), Time: seconds}
+		for i+1 < len(lines) && !resultLine.MatchString(lines[i+1]) && !markerLine.MatchString(lines[i+1]) &&
+			len(lines[i+1])-len(strings.TrimLeft(lines[i+1], " \t")) > indent {
+			i++
+			outputs[c.Name] = append(outputs[c.Name], strings.TrimSpace(lines[i]))
+		}
+		result = append(result, c)
+	}
+	for _, el := range result {
+		el.Output = strings.Join(outputs[el.Name], "\n")
+	}
+	var innermost []*CaseResult
+	for _, el := range result {
+		parent := false
+		for _, sub := range result {
+			if strings.HasPrefix(sub.Name, el.Name+"/") {
+				parent = true
+				break
+			}
+		}
+		if !parent {
+			innermost = append(innermost, el)
+		}
+	}
+	return innermost
+}
+
+//Add appends suite to report
+func (r *Report) Add(suite *SuiteResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Suites = append(r.Suites, suite)
+	r.Time += suite.Time
+	for _, el := range suite.Cases {
+		r.Tests++
+		switch el.Status {
+		case CaseFail:
+			r.Failures++
+		case CaseSkip:
+			r.Skipped++
+		}
+	}
+}
+
+//addFailScenarioOutput appends output of fail scenario to suite
+func (r *Report) addFailScenarioOutput(suite *SuiteResult, output string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	suite.FailScenarioOutput += output
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Output  string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+type junitCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+	SystemOut string      `xml:"system-out,omitempty"`
+	SystemErr string      `xml:"system-err,omitempty"`
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+func junitTime(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
+
+//JUnit returns report in JUnit XML format
+func (r *Report) JUnit() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	suites := junitSuites{Tests: r.Tests, Failures: r.Failures, Skipped: r.Skipped, Time: junitTime(r.Time)}
+	for _, suite := range r.Suites {
+		js := junitSuite{
+			Name:      suite.Name,
+			Time:      junitTime(suite.Time),
+			Timestamp: suite.Timestamp.Format("2006-01-02T15:04:05"),
+			SystemOut: suite.Output,
+			SystemErr: suite.FailScenarioOutput,
+		}
+		for _, el := range suite.Cases {
+			jc := junitCase{Classname: suite.Name, Name: el.Name, Time: junitTime(el.Time)}
+			switch el.Status {
+			case CaseFail:
+				js.Failures++
+				jc.Failure = &junitFailure{Message: "Failed", Output: el.Output}
+			case CaseSkip:
+				js.Skipped++
+				jc.Skipped = &junitSkipped{Message: el.Output}
+			default:
+				jc.SystemOut = el.Output
+			}
+			js.Cases = append(js.Cases, jc)
+		}
+		js.Tests = len(js.Cases)
+		suites.Suites = append(suites.Suites, js)
+	}
+	data, err := xml.MarshalIndent(suites, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+//JSON returns report in JSON format
+func (r *Report) JSON() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return json.MarshalIndent(r, "", "\t")
+}
+
+//WriteFile writes report into file with format defined by extension: .xml for JUnit or .json
+func (r *Report) WriteFile(path string) error {
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		data, err = r.JUnit()
+	case ".json":
+		data, err = r.JSON()
+	default:
+		return fmt.Errorf("unsupported format of report %s: use .xml or .json", path)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot prepare report: %s", err)
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+//StartReport starts to collect results of RunTest into report which will be written into path by FinishReport
+func StartReport(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml", ".json":
+	default:
+		return fmt.Errorf("unsupported format of report %s: use .xml or .json", path)
+	}
+	report = &Report{}
+	reportFile = path
+	return nil
+}
+
+//FinishReport writes report started by StartReport
+func FinishReport() error {
+	if report == nil {
+		return nil
+	}
+	defer func() { report = nil }()
+	return report.WriteFile(reportFile)
+}
+
+//syncBuffer is bytes.Buffer to write into from several goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
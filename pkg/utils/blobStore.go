@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const blobStoreVersion = "2019-12-12"
+
+//BlobStore is a client of Azure blob storage with shared key authorization
+//endpoint may contain path of account for Azurite-like servers (http://host:10000/devstoreaccount1)
+type BlobStore struct {
+	Endpoint string
+	Account  string
+	key      []byte
+	client   *http.Client
+}
+
+//NewBlobStore returns client of Azure blob storage with endpoint in form http(s)://host[:port][/account]
+//key is base64 encoded key of account
+func NewBlobStore(endpoint, account, key string) (*BlobStore, error) {
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode key of account %s: %s", account, err)
+	}
+	return &BlobStore{
+		Endpoint: strings.TrimRight(endpoint, "/"),
+		Account:  account,
+		key:      decodedKey,
+		client:   &http.Client{},
+	}, nil
+}
+
+//blobURL returns url of blob inside container or url of container if blob is empty
+func (store *BlobStore) blobURL(container, blob string) (*url.URL, error) {
+	u, err := url.Parse(store.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + container
+	if blob != "" {
+		u.Path += "/" + strings.TrimLeft(blob, "/")
+	}
+	return u, nil
+}
+
+//SignRequest signs req with shared key of account
+func (store *BlobStore) SignRequest(req *http.Request, now time.Time) {
+	req.Header.Set("x-ms-date", now.UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", blobStoreVersion)
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	var msHeaders []string
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-ms-") {
+			msHeaders = append(msHeaders, k+":"+strings.TrimSpace(strings.Join(v, ",")))
+		}
+	}
+	sort.Strings(msHeaders)
+	resource := "/" + store.Account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	var queryKeys []string
+	for k := range query {
+		queryKeys = append(queryKeys, k)
+	}
+	sort.Strings(queryKeys)
+	for _, k := range queryKeys {
+		values := query[k]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(k) + ":" + strings.Join(values, ",")
+	}
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		req.Header.Get("Date"),
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		strings.Join(msHeaders, "\n"),
+		resource,
+	}, "\n")
+	h := hmac.New(sha256.New, store.key)
+	h.Write([]byte(stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", store.Account, base64.StdEncoding.EncodeToString(h.Sum(nil))))
+}
+
+//do sends signed request and returns response with successful status
+func (store *BlobStore) do(req *http.Request) (*http.Response, error) {
+	store.SignRequest(req, time.Now())
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return resp, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+//MakeBucket creates container if not exists
+func (store *BlobStore) MakeBucket(container string) error {
+	u, err := store.blobURL(container, "")
+	if err != nil {
+		return err
+	}
+	u.RawQuery = "restype=container"
+	req, err := http.NewRequest(http.MethodPut, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := store.do(req)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusConflict {
+			//container already exists
+			return nil
+		}
+		return err
+	}
+	return resp.Body.Close()
+}
+
+//PutObject uploads file from filePath into blob inside container
+func (store *BlobStore) PutObject(container, blob, filePath string) error {
+	u, err := store.blobURL(container, blob)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, u.String(), f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = fi.Size()
+	if req.ContentLength == 0 {
+		req.Body = http.NoBody
+	}
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	resp, err := store.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+//GetObject returns content of blob inside container
+func (store *BlobStore) GetObject(container, blob string) (io.ReadCloser, error) {
+	u, err := store.blobURL(container, blob)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := store.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
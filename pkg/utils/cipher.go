@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 
 	"crypto/aes"
@@ -23,6 +24,18 @@ func aesEncrypt(iv, symmetricKey, plaintext []byte) ([]byte, error) {
 	return ciphertext, nil
 }
 
+//internal decryption method
+func aesDecrypt(iv, symmetricKey, ciphertext []byte) ([]byte, error) {
+	plaintext := make([]byte, len(ciphertext))
+	aesBlockDecrypter, err := aes.NewCipher(symmetricKey)
+	if err != nil {
+		return nil, err
+	}
+	aesDecrypter := cipher.NewCFBDecrypter(aesBlockDecrypter, iv)
+	aesDecrypter.XORKeyStream(plaintext, ciphertext)
+	return plaintext, nil
+}
+
 //create cipher block
 func createCipherBlock(plainText []byte, cipherCtxID string, cmnCryptoCfg *CommonCryptoConfig, iv []byte) (*config.CipherBlock, error) {
 	if cmnCryptoCfg.DevCertHash == nil {
@@ -64,3 +77,20 @@ func CryptoConfigWrapper(encBlock *config.EncryptionBlock, cmnCryptoCfg *CommonC
 	}
 	return cipherBlock, nil
 }
+
+//DecryptCipherBlock decrypts cipherBlock created by CryptoConfigWrapper with the same cmnCryptoCfg
+func DecryptCipherBlock(cipherBlock *config.CipherBlock, cmnCryptoCfg *CommonCryptoConfig) (*config.EncryptionBlock, error) {
+	plainText, err := aesDecrypt(cipherBlock.InitialValue, cmnCryptoCfg.SymmetricKey, cipherBlock.CipherData)
+	if err != nil {
+		return nil, err
+	}
+	shaOfPlainText := sha256.Sum256(plainText)
+	if !bytes.Equal(shaOfPlainText[:], cipherBlock.ClearTextSha256) {
+		return nil, fmt.Errorf("sha256 mismatch of decrypted data")
+	}
+	encBlock := &config.EncryptionBlock{}
+	if err = proto.Unmarshal(plainText, encBlock); err != nil {
+		return nil, fmt.Errorf("error unmarshalling decrypted data: %v", err)
+	}
+	return encBlock, nil
+}
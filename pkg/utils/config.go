@@ -38,6 +38,8 @@ type ConfigVars struct {
 	EveSSID           string
 	EveUUID           string
 	EveName           string
+	EveDevice         string
+	EveOnboardCerts   map[string]string
 	EveRemote         bool
 	EveRemoteAddr     string
 	EveQemuPorts      map[string]string
@@ -61,6 +63,23 @@ type ConfigVars struct {
 	RegistryPort      string
 	LogLevel          string
 	AdamLogLevel      string
+	ControllerMode    string
+	ZedcloudURL       string
+	ZedcloudToken     string
+	ZedcloudCA        string
+	ZedcloudInsecure  bool
+	ProtoDir          string
+	ProtoReplaySpeed  float64
+
+	ObjectStoreEndpoint    string
+	ObjectStoreEVEEndpoint string
+	ObjectStoreRegion      string
+	ObjectStoreAccessKey   string
+	ObjectStoreSecretKey   string
+	AzureEndpoint          string
+	AzureEVEEndpoint       string
+	AzureAccount           string
+	AzureKey               string
 }
 
 //InitVars loads vars from viper
@@ -109,6 +128,8 @@ func InitVars() (*ConfigVars, error) {
 			DevModelFIle:      viper.GetString("eve.devmodelfile"),
 			EveName:           viper.GetString("eve.name"),
 			EveUUID:           viper.GetString("eve.uuid"),
+			EveDevice:         viper.GetString("eve.device"),
+			EveOnboardCerts:   getOnboardCerts(),
 			EveRemote:         viper.GetBool("eve.remote"),
 			EveRemoteAddr:     viper.GetString("eve.remote-addr"),
 			EveQemuPorts:      viper.GetStringMapString("eve.hostfwd"),
@@ -128,6 +149,23 @@ func InitVars() (*ConfigVars, error) {
 			RegistryPort:      viper.GetString("registry.port"),
 			LogLevel:          viper.GetString("eve.log-level"),
 			AdamLogLevel:      viper.GetString("eve.adam-log-level"),
+			ControllerMode:    viper.GetString("test.controller"),
+			ZedcloudURL:       viper.GetString("zedcloud.url"),
+			ZedcloudToken:     viper.GetString("zedcloud.token"),
+			ZedcloudCA:        ResolveAbsPath(viper.GetString("zedcloud.ca")),
+			ZedcloudInsecure:  viper.GetBool("zedcloud.insecure"),
+			ProtoDir:          ResolveAbsPath(viper.GetString("proto.dir")),
+			ProtoReplaySpeed:  viper.GetFloat64("proto.replay-speed"),
+
+			ObjectStoreEndpoint:    viper.GetString("objectstore.endpoint"),
+			ObjectStoreEVEEndpoint: viper.GetString("objectstore.eve-endpoint"),
+			ObjectStoreRegion:      viper.GetString("objectstore.region"),
+			ObjectStoreAccessKey:   viper.GetString("objectstore.access-key"),
+			ObjectStoreSecretKey:   viper.GetString("objectstore.secret-key"),
+			AzureEndpoint:          viper.GetString("objectstore.azure-endpoint"),
+			AzureEVEEndpoint:       viper.GetString("objectstore.azure-eve-endpoint"),
+			AzureAccount:           viper.GetString("objectstore.azure-account"),
+			AzureKey:               viper.GetString("objectstore.azure-key"),
 		}
 		viperAccessMutex.RUnlock()
 		redisPasswordFile := filepath.Join(globalCertsDir, defaults.DefaultRedisPasswordFile)
@@ -143,6 +181,24 @@ func InitVars() (*ConfigVars, error) {
 	return nil, nil
 }
 
+//getOnboardCerts returns onboarding certificates of nodes defined in test.eve section by their names
+func getOnboardCerts() map[string]string {
+	certs := make(map[string]string)
+	//additional instances of EVE started with 'eden eve start --count' are named <eve.name>-<index>
+	imagesDist := filepath.Dir(ResolveAbsPath(viper.GetString("eve.image-file")))
+	instanceCerts, _ := filepath.Glob(filepath.Join(imagesDist, defaults.DefaultEVEInstancesDir, "*", defaults.DefaultEVEInstanceConfigDir, "onboard.cert.pem"))
+	for _, cert := range instanceCerts {
+		index := filepath.Base(filepath.Dir(filepath.Dir(cert)))
+		certs[fmt.Sprintf("%s-%s", viper.GetString("eve.name"), index)] = cert
+	}
+	for name := range viper.GetStringMap("test.eve") {
+		if cert := viper.GetString(fmt.Sprintf("test.eve.%s.onboard-cert", name)); cert != "" {
+			certs[name] = ResolveAbsPath(cert)
+		}
+	}
+	return certs
+}
+
 //DefaultEdenDir returns path to default directory
 func DefaultEdenDir() (string, error) {
 	usr, err := user.Current()
@@ -337,6 +393,10 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return runtime.GOOS
 		case "eve.accel":
 			return true
+		case "eve.tpm":
+			return defaults.DefaultEVETPM
+		case "eve.net-mode":
+			return defaults.DefaultEVENetMode
 		case "eve.hv":
 			return defaults.DefaultEVEHV
 		case "eve.serial":
@@ -440,6 +500,32 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return ip
 		case "registry.dist":
 			return defaults.DefaultRegistryDist
+
+		case "lan.bridge":
+			return defaults.DefaultLANBridge
+		case "lan.subnet":
+			return defaults.DefaultLANSubnet
+		case "lan.dns":
+			return ""
+
+		case "objectstore.endpoint":
+			return fmt.Sprintf("http://%s:%d", ip, defaults.DefaultObjectStorePort)
+		case "objectstore.eve-endpoint":
+			return fmt.Sprintf("http://%s:%d", defaults.DefaultDomain, defaults.DefaultObjectStorePort)
+		case "objectstore.region":
+			return defaults.DefaultObjectStoreRegion
+		case "objectstore.access-key":
+			return defaults.DefaultObjectStoreAccessKey
+		case "objectstore.secret-key":
+			return defaults.DefaultObjectStoreSecretKey
+		case "objectstore.azure-endpoint":
+			return fmt.Sprintf("http://%s:%d/%s", ip, defaults.DefaultAzuritePort, defaults.DefaultAzureAccount)
+		case "objectstore.azure-eve-endpoint":
+			return fmt.Sprintf("http://%s:%d/%s", defaults.DefaultDomain, defaults.DefaultAzuritePort, defaults.DefaultAzureAccount)
+		case "objectstore.azure-account":
+			return defaults.DefaultAzureAccount
+		case "objectstore.azure-key":
+			return defaults.DefaultAzureKey
 		default:
 			log.Fatalf("Not found argument %s in config", inp)
 		}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	objectStoreService   = "s3"
+	objectStoreAlgorithm = "AWS4-HMAC-SHA256"
+	objectStoreTimestamp = "20060102T150405Z"
+	emptyPayloadHash     = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+//ObjectStore is a client of S3 compatible object storage
+//it uses path-style requests, so it works with MinIO-like servers
+type ObjectStore struct {
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	client    *http.Client
+}
+
+//NewObjectStore returns client of S3 compatible object storage with endpoint in form http(s)://host:port
+func NewObjectStore(endpoint, region, accessKey, secretKey string) *ObjectStore {
+	return &ObjectStore{
+		Endpoint:  strings.TrimRight(endpoint, "/"),
+		Region:    region,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		client:    &http.Client{},
+	}
+}
+
+//objectURL returns url of object inside bucket or url of bucket if key is empty
+func (store *ObjectStore) objectURL(bucket, key string) (*url.URL, error) {
+	u, err := url.Parse(store.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/" + bucket
+	if key != "" {
+		u.Path += "/" + strings.TrimLeft(key, "/")
+	}
+	return u, nil
+}
+
+//uriEncode encodes s according to rules of signature version 4
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+//SignRequest signs req with signature version 4 using payloadHash as hex encoded sha256 of body
+func (store *ObjectStore) SignRequest(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(objectStoreTimestamp)
+	date := amzDate[:8]
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	var headerNames []string
+	for k := range headers {
+		headerNames = append(headerNames, k)
+	}
+	sort.Strings(headerNames)
+	var canonicalHeaders strings.Builder
+	for _, k := range headerNames {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+	query := req.URL.Query()
+	var queryKeys []string
+	for k := range query {
+		queryKeys = append(queryKeys, k)
+	}
+	sort.Strings(queryKeys)
+	var canonicalQuery []string
+	for _, k := range queryKeys {
+		for _, v := range query[k] {
+			canonicalQuery = append(canonicalQuery, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(path, false),
+		strings.Join(canonicalQuery, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{date, store.Region, objectStoreService, "aws4_request"}, "/")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{objectStoreAlgorithm, amzDate, scope, hex.EncodeToString(canonicalHash[:])}, "\n")
+	key := hmacSHA256([]byte("AWS4"+store.SecretKey), date)
+	key = hmacSHA256(key, store.Region)
+	key = hmacSHA256(key, objectStoreService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		objectStoreAlgorithm, store.AccessKey, scope, signedHeaders, signature))
+}
+
+//do sends signed request and returns response with successful status
+func (store *ObjectStore) do(method, bucket, key string, body io.Reader, size int64, payloadHash string) (*http.Response, error) {
+	u, err := store.objectURL(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	store.SignRequest(req, payloadHash, time.Now())
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return resp, fmt.Errorf("%s %s: %s: %s", method, u, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+//MakeBucket creates bucket if not exists
+func (store *ObjectStore) MakeBucket(bucket string) error {
+	resp, err := store.do(http.MethodHead, bucket, "", nil, 0, emptyPayloadHash)
+	if err == nil {
+		return resp.Body.Close()
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return err
+	}
+	resp, err = store.do(http.MethodPut, bucket, "", nil, 0, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+//PutObject uploads file from filePath into key inside bucket
+func (store *ObjectStore) PutObject(bucket, key, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	resp, err := store.do(http.MethodPut, bucket, key, f, fi.Size(), hex.EncodeToString(h.Sum(nil)))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+//GetObject returns content of key inside bucket
+func (store *ObjectStore) GetObject(bucket, key string) (io.ReadCloser, error) {
+	resp, err := store.do(http.MethodGet, bucket, key, nil, 0, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -39,7 +39,11 @@ var funcs = template.FuncMap{
 		return runtime.GOOS
 	},
 	// Check libslirp version. Version 4.2 and later do not support communication between slirp interfaces
+	// Interfaces of EVE attached to bridge are inside one LAN, so communication is always supported
 	"EdenCheckSlirpSupportRouting": func() bool {
+		if viper.GetString("eve.net-mode") == "bridge" {
+			return true
+		}
 		pathToSearch := ""
 		if err := filepath.Walk("/usr/lib", func(path string, info os.FileInfo, err error) error {
 			if err == nil && info.Name() == "libslirp.so.0" {
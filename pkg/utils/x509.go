@@ -51,6 +51,11 @@ func genCert(template, parent *x509.Certificate, publicKey *rsa.PublicKey, priva
 	return cert
 }
 
+//GenSerial returns random 128-bit serial number for certificate
+func GenSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
 //GenCARoot gen root CA
 func GenCARoot() (*x509.Certificate, *rsa.PrivateKey) {
 	var rootTemplate = x509.Certificate{
@@ -45,7 +45,7 @@ func TestMain(m *testing.M) {
 
 	result := m.Run()
 	if result != 0 {
-		tests.RunScenario(*failScenario, "", "", "", "", "")
+		tests.RunFailScenario(*failScenario, "", "")
 	}
 	os.Exit(result)
 }
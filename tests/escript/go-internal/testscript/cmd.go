@@ -41,6 +41,7 @@ var scriptCmds = map[string]func(*TestScript, bool, []string){
 	"grep":    (*TestScript).cmdGrep,
 	"message": (*TestScript).cmdMsg,
 	"mkdir":   (*TestScript).cmdMkdir,
+	"podexec": (*TestScript).cmdPodExec,
 	"rm":      (*TestScript).cmdRm,
 	"unquote": (*TestScript).cmdUnquote,
 	"skip":    (*TestScript).cmdSkip,
@@ -276,6 +277,30 @@ func (ts *TestScript) cmdEden(neg bool, args []string) {
 	}
 }
 
+// podexec runs command in pod with 'eden pod exec' retrying until pod is accessible.
+func (ts *TestScript) cmdPodExec(neg bool, args []string) {
+	var edenArgs, podArgs []string
+	if len(args) > 1 && args[0] == "-t" {
+		// the same timeout for the process and for waiting of pod
+		edenArgs = []string{"-t", args[1]}
+		podArgs = []string{"--timeout", args[1]}
+		args = args[2:]
+	}
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		podArgs = append(podArgs, args[0])
+		args = args[1:]
+	}
+	if len(args) < 1 || args[len(args)-1] == "&" {
+		ts.Fatalf("usage: podexec [-t timeout] [--flag=value...] app [command...]")
+	}
+	podArgs = append(podArgs, args[0])
+	if len(args) > 1 {
+		podArgs = append(append(podArgs, "--"), args[1:]...)
+	}
+	edenArgs = append(append(edenArgs, "pod", "exec"), podArgs...)
+	ts.cmdEden(neg, edenArgs)
+}
+
 // eden execute EDEN's test commands.
 func (ts *TestScript) cmdTest(neg bool, args []string) {
 	if len(args) < 1 || (len(args) == 1 && args[0] == "&") {
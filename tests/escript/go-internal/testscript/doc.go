@@ -172,6 +172,13 @@ The predefined commands are:
 - mkdir path...
   Create the listed directories, if they do not already exists.
 
+- [!] podexec [-t timeout] [--flag=value...] app [command...]
+  Run command in the pod with the name app using 'eden pod exec'.
+  It retries to connect to the published ssh port of the pod until timeout
+  expires, and must (or must not) succeed. Flags are passed to 'eden pod exec'
+  and must be in --flag=value form. Standard output and standard error can be
+  checked with stdout and stderr commands.
+
 - unquote file...
   Rewrite each file by replacing any leading ">" characters from
   each line. This enables a file to contain substrings that look like
@@ -14,6 +14,7 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/einfo"
 	"github.com/lf-edge/eden/pkg/controller/elog"
 	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/projects"
 	"github.com/lf-edge/eden/pkg/tests"
@@ -49,15 +50,23 @@ var (
 )
 
 func mkquery() error {
+	var args []string
 	for _, a := range flag.Args() {
-		for _, f := range strings.Split(a, " ") {
-			s := strings.Split(f, ":")
-			if len(s) == 1 {
-				return fmt.Errorf("incorrect query: %s", f)
+		terms := strings.Split(a, " ")
+		for _, f := range terms {
+			if _, _, ok := equery.SimpleCondition(f); !ok {
+				// not a list of field:regexp, so use it as expression
+				terms = []string{a}
+				break
 			}
-			query[s[0]] = s[1]
 		}
+		args = append(args, terms...)
 	}
+	q, err := equery.FromArgs(args)
+	if err != nil {
+		return fmt.Errorf("incorrect query: %s", err)
+	}
+	query = q
 
 	return nil
 }
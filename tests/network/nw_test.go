@@ -4,12 +4,16 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/lf-edge/eden/pkg/eve"
 	"github.com/lf-edge/eden/pkg/projects"
 	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/flowlog"
 	"github.com/lf-edge/eve/api/go/info"
 )
 
@@ -20,10 +24,11 @@ type nwState struct {
 
 // This test wait for the network's state with a timewait.
 var (
-	timewait = flag.Duration("timewait", time.Minute, "Timewait for items waiting")
-	tc       *projects.TestContext
-	states   map[string][]nwState
-	eveState *eve.State
+	timewait  = flag.Duration("timewait", time.Minute, "Timewait for items waiting")
+	flowHosts = flag.String("flowlog", "", "Wait for DNS requests of comma-separated hosts in flow logs of networks instead of state")
+	tc        *projects.TestContext
+	states    map[string][]nwState
+	eveState  *eve.State
 )
 
 // TestMain is used to provide setup and teardown for the rest of the
@@ -119,6 +124,9 @@ func checkNet(state string, volNames []string) projects.ProcInfoFunc {
 //TestNetworkStatus wait for networks reaching the selected state
 //with a timewait
 func TestNetworkStatus(t *testing.T) {
+	if *flowHosts != "" {
+		t.Skip("flow logs are requested")
+	}
 	edgeNode := tc.GetEdgeNode(tc.WithTest(t))
 
 	args := flag.Args()
@@ -169,3 +177,92 @@ func TestNetworkStatus(t *testing.T) {
 		time.Sleep(1 * time.Second)
 	}
 }
+
+//flowHostsState keeps hosts found in flow logs, it is updated by processor and read on timeout
+type flowHostsState struct {
+	mu    sync.Mutex
+	hosts map[string]bool
+}
+
+//notFound returns hosts not found in flow logs yet
+func (st *flowHostsState) notFound() (result []string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for host, found := range st.hosts {
+		if !found {
+			result = append(result, host)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+//checkFlowLog wait for flow logs of networks with DNS requests of all hosts
+func checkFlowLog(netUUIDs map[string]string, st *flowHostsState) projects.ProcLogFlowFunc {
+	return func(msg *flowlog.FlowMessage) error {
+		netName, ok := netUUIDs[msg.GetScope().GetNetInstUUID()]
+		if !ok {
+			return nil
+		}
+		st.mu.Lock()
+		for _, req := range msg.GetDnsReqs() {
+			host := strings.TrimSuffix(req.GetHostName(), ".")
+			if found, ok := st.hosts[host]; ok && !found {
+				st.hosts[host] = true
+				fmt.Println(utils.AddTimestamp(fmt.Sprintf("DNS request of %s in flow log of network %s", host, netName)))
+			}
+		}
+		st.mu.Unlock()
+		if len(st.notFound()) > 0 {
+			return nil
+		}
+		return fmt.Errorf("DNS requests of %s found in flow logs", *flowHosts)
+	}
+}
+
+//TestNetworkFlowLog wait for DNS requests of hosts in flow logs of networks
+//with a timewait
+func TestNetworkFlowLog(t *testing.T) {
+	if *flowHosts == "" {
+		t.Skip("flow logs are not requested")
+	}
+	edgeNode := tc.GetEdgeNode(tc.WithTest(t))
+
+	netNames := flag.Args()
+	if len(netNames) == 0 {
+		t.Fatalf("Usage: %s [options] -flowlog host,... nw_name...\n", os.Args[0])
+	}
+	secs := int(timewait.Seconds())
+	t.Log(utils.AddTimestamp(fmt.Sprintf("networks: '%s' expected DNS requests: '%s' secs: %d\n",
+		netNames, *flowHosts, secs)))
+
+	// observe existing info object to get UUIDs of networks
+	if err := tc.GetController().InfoLastCallback(edgeNode.GetID(), nil, eveState.InfoCallback()); err != nil {
+		t.Fatal(err)
+	}
+	netUUIDs := make(map[string]string)
+	for _, net := range eveState.Networks() {
+		if _, inSlice := utils.FindEleInSlice(netNames, net.Name); inSlice {
+			netUUIDs[net.UUID] = net.Name
+		}
+	}
+	if len(netUUIDs) != len(netNames) {
+		t.Fatalf("not all networks %s found", netNames)
+	}
+	st := &flowHostsState{hosts: make(map[string]bool)}
+	for _, host := range strings.Split(*flowHosts, ",") {
+		st.hosts[host] = false
+	}
+
+	// DNS requests may be uploaded before the test starts, so we check existing flow logs too
+	tc.AddProcFlowLog(edgeNode, checkFlowLog(netUUIDs, st), projects.WithProcExisting())
+
+	callback := func() {
+		t.Errorf("ASSERTION FAILED (%s): expected DNS requests in flow logs of networks %s", time.Now().Format(time.RFC3339Nano), netNames)
+		for _, host := range st.notFound() {
+			t.Errorf("\tnot found: %s", host)
+		}
+	}
+
+	tc.WaitForProcWithErrorCallback(secs, callback)
+}
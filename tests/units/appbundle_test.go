@@ -0,0 +1,99 @@
+package templates
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve/api/go/config"
+)
+
+// These tests verify export of app with referenced objects into JSON and import of it with fresh UUIDs
+
+func TestAppBundleExportImport(t *testing.T) {
+	ctrl := &controller.CloudCtx{}
+	dev := device.CreateEdgeNode()
+	ds := &config.DatastoreConfig{Id: "ds-1", DType: config.DsType_DsContainerRegistry, Fqdn: "docker://index.docker.io"}
+	ni := &config.NetworkInstanceConfig{Uuidandversion: &config.UUIDandVersion{Uuid: "ni-1", Version: "1"}, Displayname: "net"}
+	ct := &config.ContentTree{Uuid: "ct-1", DsId: "ds-1", URL: "library/nginx"}
+	vol := &config.Volume{Uuid: "vol-1", Origin: &config.VolumeContentOrigin{DownloadContentTreeID: "ct-1"}}
+	app := &config.AppInstanceConfig{
+		Uuidandversion: &config.UUIDandVersion{Uuid: "app-1", Version: "3"},
+		Displayname:    "nginx",
+		Drives:         []*config.Drive{{Image: &config.Image{Uuidandversion: &config.UUIDandVersion{Uuid: "img-1"}, DsId: "ds-1"}}},
+		VolumeRefList:  []*config.VolumeRef{{Uuid: "vol-1"}},
+		Interfaces:     []*config.NetworkAdapter{{Name: "default", NetworkId: "ni-1"}},
+		Purge:          &config.InstanceOpsCmd{Counter: 2},
+	}
+	for _, err := range []error{
+		ctrl.AddDataStore(ds),
+		ctrl.AddNetworkInstanceConfig(ni),
+		ctrl.AddContentTree(ct),
+		ctrl.AddVolume(vol),
+		ctrl.AddApplicationInstanceConfig(app),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	dev.SetApplicationInstanceConfig([]string{"app-1"})
+	dev.SetNetworkInstanceConfig([]string{"ni-1"})
+
+	bundle, err := controller.GetAppBundle(ctrl, "app-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed controller.AppBundle
+	if err = json.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Volumes) != 1 || len(parsed.ContentTrees) != 1 || len(parsed.Images) != 1 ||
+		len(parsed.DataStores) != 1 || len(parsed.NetworkInstances) != 1 {
+		t.Fatalf("unexpected bundle: %s", data)
+	}
+
+	if _, err = controller.ImportAppBundle(ctrl, dev, &parsed, ""); err == nil {
+		t.Fatal("expected error for app with existing name")
+	}
+	imported, err := controller.ImportAppBundle(ctrl, dev, &parsed, "nginx-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Uuidandversion.Uuid == "app-1" || imported.Uuidandversion.Version != "1" || imported.Purge != nil {
+		t.Fatalf("app is not reset: %v", imported.Uuidandversion)
+	}
+	if imported.Interfaces[0].NetworkId != "ni-1" {
+		t.Fatalf("network instance with the same name must be reused, got %s", imported.Interfaces[0].NetworkId)
+	}
+	if imported.Drives[0].Image.DsId != "ds-1" {
+		t.Fatalf("datastore with the same location must be reused, got %s", imported.Drives[0].Image.DsId)
+	}
+	newVol, err := ctrl.GetVolume(imported.VolumeRefList[0].Uuid)
+	if err != nil || newVol.Uuid == "vol-1" {
+		t.Fatalf("volume is not re-created: %v", err)
+	}
+	newCt, err := ctrl.GetContentTree(newVol.Origin.DownloadContentTreeID)
+	if err != nil || newCt.Uuid == "ct-1" || newCt.DsId != "ds-1" {
+		t.Fatalf("content tree is not re-created: %v", err)
+	}
+	if len(dev.GetApplicationInstances()) != 2 || len(dev.GetVolumes()) != 1 || len(dev.GetContentTrees()) != 1 {
+		t.Fatal("imported objects are not assigned to device")
+	}
+	if orig, _ := ctrl.GetApplicationInstanceConfig("app-1"); orig.Displayname != "nginx" || orig.VolumeRefList[0].Uuid != "vol-1" {
+		t.Fatal("original app is modified")
+	}
+
+	other := device.CreateEdgeNode()
+	imported, err = controller.ImportAppBundle(ctrl, other, &parsed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Interfaces[0].NetworkId == "ni-1" || len(other.GetNetworkInstances()) != 1 {
+		t.Fatal("network instance must be re-created for another device")
+	}
+}
@@ -0,0 +1,180 @@
+package templates
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/protodir"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve/api/go/config"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// These tests verify config of device stored in proto controller
+// after update of image of app and after rollback of update
+
+type appUpdateEnv struct {
+	proto  *protodir.Ctx
+	ctrl   *controller.CloudCtx
+	dev    *device.Ctx
+	app    *config.AppInstanceConfig
+	newApp *config.AppInstanceConfig
+}
+
+//newAppUpdateEnv returns device with app "app" using volume "vol-old" from content tree "ct-old"
+//and app "other" using volume "vol-other" from the same content tree
+//objects for the new image ("app-new", "vol-new", "ct-new") are added as expectation adds them
+func newAppUpdateEnv(t *testing.T) (*appUpdateEnv, string) {
+	proto, dev, dir := newProtoDirCtx(t)
+	if err := proto.Register(dev); err != nil {
+		t.Fatal(err)
+	}
+	devUUID, err := proto.DeviceGetByOnboard(dev.GetOnboardKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.SetID(devUUID)
+	ctrl := &controller.CloudCtx{Controller: proto}
+	env := &appUpdateEnv{proto: proto, ctrl: ctrl, dev: dev}
+	env.app = &config.AppInstanceConfig{
+		Uuidandversion: &config.UUIDandVersion{Uuid: "app", Version: "1"},
+		Displayname:    "app",
+		VolumeRefList:  []*config.VolumeRef{{Uuid: "vol-old"}},
+	}
+	env.newApp = &config.AppInstanceConfig{
+		Uuidandversion: &config.UUIDandVersion{Uuid: "app-new", Version: "1"},
+		Displayname:    "app",
+		VolumeRefList:  []*config.VolumeRef{{Uuid: "vol-new"}},
+	}
+	other := &config.AppInstanceConfig{
+		Uuidandversion: &config.UUIDandVersion{Uuid: "other", Version: "1"},
+		Displayname:    "other",
+		VolumeRefList:  []*config.VolumeRef{{Uuid: "vol-other"}},
+	}
+	for _, err := range []error{
+		ctrl.AddDataStore(&config.DatastoreConfig{Id: "ds", DType: config.DsType_DsContainerRegistry, Fqdn: "docker://index.docker.io"}),
+		ctrl.AddContentTree(&config.ContentTree{Uuid: "ct-old", DsId: "ds", URL: "library/nginx:1"}),
+		ctrl.AddContentTree(&config.ContentTree{Uuid: "ct-new", DsId: "ds", URL: "library/nginx:2"}),
+		ctrl.AddVolume(&config.Volume{Uuid: "vol-old", Origin: &config.VolumeContentOrigin{DownloadContentTreeID: "ct-old"}}),
+		ctrl.AddVolume(&config.Volume{Uuid: "vol-other", Origin: &config.VolumeContentOrigin{DownloadContentTreeID: "ct-old"}}),
+		ctrl.AddVolume(&config.Volume{Uuid: "vol-new", Origin: &config.VolumeContentOrigin{DownloadContentTreeID: "ct-new"}}),
+		ctrl.AddApplicationInstanceConfig(env.app),
+		ctrl.AddApplicationInstanceConfig(other),
+		ctrl.AddApplicationInstanceConfig(env.newApp),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	dev.SetApplicationInstanceConfig([]string{"app", "other"})
+	dev.SetVolumeConfigs([]string{"vol-old", "vol-other", "vol-new"})
+	dev.SetContentTreeConfig([]string{"ct-old", "ct-new"})
+	return env, dir
+}
+
+//deviceConfig sends config of device into controller and reads it back
+func (env *appUpdateEnv) deviceConfig(t *testing.T) *config.EdgeDevConfig {
+	data, err := env.ctrl.GetConfigBytes(env.dev, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = env.proto.ConfigSet(env.dev.GetID(), data); err != nil {
+		t.Fatal(err)
+	}
+	out, err := env.proto.ConfigGet(env.dev.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var devConfig config.EdgeDevConfig
+	if err = protojson.Unmarshal([]byte(out), &devConfig); err != nil {
+		t.Fatal(err)
+	}
+	return &devConfig
+}
+
+//checkDeviceConfig checks volumes and content trees in config of device and volume of app
+func checkDeviceConfig(t *testing.T, devConfig *config.EdgeDevConfig, volumes, contentTrees []string, appVolume, appVersion string) {
+	t.Helper()
+	var receivedVolumes, receivedContentTrees []string
+	for _, el := range devConfig.Volumes {
+		receivedVolumes = append(receivedVolumes, el.Uuid)
+	}
+	for _, el := range devConfig.ContentInfo {
+		receivedContentTrees = append(receivedContentTrees, el.Uuid)
+	}
+	sort.Strings(receivedVolumes)
+	sort.Strings(receivedContentTrees)
+	if !equalStrings(receivedVolumes, volumes) {
+		t.Errorf("expected volumes %v, received %v", volumes, receivedVolumes)
+	}
+	if !equalStrings(receivedContentTrees, contentTrees) {
+		t.Errorf("expected content trees %v, received %v", contentTrees, receivedContentTrees)
+	}
+	if len(devConfig.Apps) != 2 {
+		t.Fatalf("expected 2 apps, received %d", len(devConfig.Apps))
+	}
+	for _, app := range devConfig.Apps {
+		if app.Uuidandversion.Uuid != "app" {
+			continue
+		}
+		if len(app.VolumeRefList) != 1 || app.VolumeRefList[0].Uuid != appVolume {
+			t.Errorf("expected app with volume %s, received %v", appVolume, app.VolumeRefList)
+		}
+		if app.Uuidandversion.Version != appVersion {
+			t.Errorf("expected app version %s, received %s", appVersion, app.Uuidandversion.Version)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAppUpdateFinish(t *testing.T) {
+	env, dir := newAppUpdateEnv(t)
+	defer os.RemoveAll(dir)
+	update := controller.StartAppUpdate(env.ctrl, env.dev, env.app, env.newApp)
+	//both images are kept until app runs with the new one
+	checkDeviceConfig(t, env.deviceConfig(t), []string{"vol-new", "vol-old", "vol-other"}, []string{"ct-new", "ct-old"}, "vol-new", "2")
+	if err := update.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	//content tree of the old image is still used by other app
+	checkDeviceConfig(t, env.deviceConfig(t), []string{"vol-new", "vol-other"}, []string{"ct-new", "ct-old"}, "vol-new", "2")
+	if contentTrees := env.dev.GetContentTrees(); !equalStrings(contentTrees, []string{"ct-old", "ct-new"}) {
+		t.Errorf("unexpected content trees of device: %v", contentTrees)
+	}
+	if _, err := env.ctrl.GetApplicationInstanceConfig("app-new"); err == nil {
+		t.Error("config created for the new image must be removed")
+	}
+}
+
+func TestAppUpdateRollback(t *testing.T) {
+	env, dir := newAppUpdateEnv(t)
+	defer os.RemoveAll(dir)
+	update := controller.StartAppUpdate(env.ctrl, env.dev, env.app, env.newApp)
+	checkDeviceConfig(t, env.deviceConfig(t), []string{"vol-new", "vol-old", "vol-other"}, []string{"ct-new", "ct-old"}, "vol-new", "2")
+	if err := update.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	checkDeviceConfig(t, env.deviceConfig(t), []string{"vol-old", "vol-other"}, []string{"ct-old"}, "vol-old", "3")
+	if contentTrees := env.dev.GetContentTrees(); !equalStrings(contentTrees, []string{"ct-old"}) {
+		t.Errorf("unexpected content trees of device: %v", contentTrees)
+	}
+	if env.app.Purge.Counter != 2 {
+		t.Errorf("expected purge counter 2, received %d", env.app.Purge.Counter)
+	}
+	if _, err := env.ctrl.GetApplicationInstanceConfig("app-new"); err == nil {
+		t.Error("config created for the new image must be removed")
+	}
+}
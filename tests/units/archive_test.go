@@ -0,0 +1,195 @@
+package templates
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/archive"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve/api/go/logs"
+	"github.com/lf-edge/eve/api/go/metrics"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// These tests verify writing of objects into archive and replay of archive with loader
+
+func writeMetrics(t *testing.T, root string, devUUID uuid.UUID, start time.Time, count int) {
+	writer := archive.NewWriter(root)
+	for i := 0; i < count; i++ {
+		data, err := protojson.Marshal(&metrics.ZMetricMsg{
+			DevID:       devUUID.String(),
+			AtTimeStamp: timestamppb.New(start.Add(time.Duration(i) * time.Second)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = writer.CheckAndSave(devUUID, uuid.Nil, types.MetricsType, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveReplay(t *testing.T) {
+	root, err := ioutil.TempDir("", "eden-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	devUUID := uuid.NewV5(uuid.Nil, "archive")
+	start := time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)
+	//two runs of recording must be read as one archive
+	writeMetrics(t, root, devUUID, start, 3)
+	writeMetrics(t, root, devUUID, start.Add(3*time.Second), 2)
+	if !archive.Exists(root) {
+		t.Fatal("archive not found")
+	}
+
+	loader := loaders.NewArchiveLoader(root, 0, time.Now())
+	loader.SetUUID(devUUID)
+	var seen []time.Time
+	collect := func(data []byte) (bool, error) {
+		var mm metrics.ZMetricMsg
+		if err := protojson.Unmarshal(data, &mm); err != nil {
+			return false, err
+		}
+		seen = append(seen, mm.AtTimeStamp.AsTime())
+		return true, nil
+	}
+	if err = loader.ProcessExisting(collect, types.MetricsType); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 5 || !seen[0].Equal(start.Add(4*time.Second)) {
+		t.Fatalf("unexpected replay of existing: %v", seen)
+	}
+
+	seen = nil
+	loader.SetTimeRange(types.TimeRange{Since: start.Add(time.Second), Until: start.Add(2 * time.Second)})
+	if err = loader.ProcessExisting(collect, types.MetricsType); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("unexpected replay inside time range: %v", seen)
+	}
+
+	//replay with high speed must stream all objects except the first one
+	seen = nil
+	loader = loaders.NewArchiveLoader(root, 100, time.Now())
+	loader.SetUUID(devUUID)
+	if err = loader.ProcessStream(collect, types.MetricsType, 2); err == nil {
+		t.Fatal("expected timeout of stream")
+	}
+	if len(seen) != 4 || !seen[0].Equal(start.Add(time.Second)) {
+		t.Fatalf("unexpected replay of stream: %v", seen)
+	}
+}
+
+func TestArchiveStreamWithoutTimeout(t *testing.T) {
+	root, err := ioutil.TempDir("", "eden-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	devUUID := uuid.NewV5(uuid.Nil, "archive")
+	writeMetrics(t, root, devUUID, time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC), 3)
+	for _, speed := range []float64{0, 100} {
+		loader := loaders.NewArchiveLoader(root, speed, time.Now())
+		loader.SetUUID(devUUID)
+		count := 0
+		done := make(chan error, 1)
+		go func() {
+			done <- loader.ProcessStream(func([]byte) (bool, error) {
+				count++
+				return true, nil
+			}, types.MetricsType, 0)
+		}()
+		select {
+		case err = <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("stream with speed %f and zero timeout not returned", speed)
+		}
+		if speed > 0 && count != 2 {
+			t.Fatalf("unexpected count of streamed objects: %d", count)
+		}
+	}
+}
+
+func TestArchiveLogMetadata(t *testing.T) {
+	le := &elog.FullLogEntry{
+		LogEntry:   logs.LogEntry{Severity: "info", Source: "zedagent", Content: "started"},
+		Image:      "IMGA",
+		EveVersion: "6.0.0",
+	}
+	data, err := elog.MarshalFullLogEntry(le)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := elog.ParseFullLogEntry(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Image != le.Image || parsed.EveVersion != le.EveVersion || parsed.Content != le.Content || parsed.Source != le.Source {
+		t.Fatalf("unexpected log after marshal: %s", data)
+	}
+}
+
+func TestArchiveReplayLogMetadata(t *testing.T) {
+	root, err := ioutil.TempDir("", "eden-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	devUUID := uuid.NewV5(uuid.Nil, "archive")
+	le := &elog.FullLogEntry{
+		LogEntry: logs.LogEntry{
+			Severity:  "info",
+			Source:    "zedagent",
+			Content:   "started",
+			Timestamp: timestamppb.New(time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)),
+		},
+		Image:      "IMGA",
+		EveVersion: "6.0.0",
+	}
+	data, err := elog.MarshalFullLogEntry(le)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := archive.NewWriter(root)
+	if err = writer.CheckAndSave(devUUID, uuid.Nil, types.LogsType, data); err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := loaders.NewArchiveLoader(root, 0, time.Now())
+	loader.SetUUID(devUUID)
+	var seen []*elog.FullLogEntry
+	if err = loader.ProcessExisting(func(data []byte) (bool, error) {
+		parsed, err := elog.ParseFullLogEntry(data)
+		if err != nil {
+			return false, err
+		}
+		seen = append(seen, parsed)
+		return true, nil
+	}, types.LogsType); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1 {
+		t.Fatalf("unexpected count of replayed logs: %d", len(seen))
+	}
+	if seen[0].Image != le.Image || seen[0].EveVersion != le.EveVersion || seen[0].Content != le.Content {
+		t.Fatalf("unexpected replayed log: %+v", seen[0])
+	}
+}
@@ -0,0 +1,191 @@
+package templates
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	eattest "github.com/lf-edge/eden/pkg/attest"
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/protodir"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/attest"
+	"github.com/lf-edge/eve/api/go/certs"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// These tests verify checking of attestation certificates against device certificate,
+// verification of TPM quotes (also fetched from controller) and comparison of PCR values with baseline
+
+func writeTPM2B(buf *bytes.Buffer, data []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(data)))
+	buf.Write(data)
+}
+
+//makeQuote returns quote of PCR 0 from SHA256 bank signed by key
+func makeQuote(t *testing.T, key *ecdsa.PrivateKey, nonce, pcr0 []byte) *attest.ZAttestQuote {
+	attestData := new(bytes.Buffer)
+	_ = binary.Write(attestData, binary.BigEndian, uint32(0xff544347))
+	_ = binary.Write(attestData, binary.BigEndian, uint16(0x8018))
+	writeTPM2B(attestData, nil)
+	writeTPM2B(attestData, nonce)
+	attestData.Write(make([]byte, 17+8))
+	_ = binary.Write(attestData, binary.BigEndian, uint32(1))
+	_ = binary.Write(attestData, binary.BigEndian, uint16(0x000B))
+	attestData.Write([]byte{3, 1, 0, 0})
+	pcrDigest := sha256.Sum256(pcr0)
+	writeTPM2B(attestData, pcrDigest[:])
+
+	digest := sha256.Sum256(attestData.Bytes())
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := new(bytes.Buffer)
+	_ = binary.Write(signature, binary.BigEndian, uint16(0x0018))
+	_ = binary.Write(signature, binary.BigEndian, uint16(0x000B))
+	writeTPM2B(signature, r.Bytes())
+	writeTPM2B(signature, s.Bytes())
+	return &attest.ZAttestQuote{
+		AttestData: attestData.Bytes(),
+		Signature:  signature.Bytes(),
+		PcrValues: []*attest.TpmPCRValue{{
+			Index:    0,
+			HashAlgo: attest.TpmHashAlgo_TPM_HASH_ALGO_SHA256,
+			Value:    pcr0,
+		}},
+	}
+}
+
+func TestAttestVerify(t *testing.T) {
+	rootCert, rootKey := utils.GenCARoot()
+	devCertPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootCert.Raw})
+	akCert, akKey := utils.GenServerCertElliptic(rootCert, rootKey, big.NewInt(2), nil, nil, "ak")
+	otherRoot, otherKey := utils.GenCARoot()
+	otherCert, _ := utils.GenServerCertElliptic(otherRoot, otherKey, big.NewInt(3), nil, nil, "other")
+	zcerts := []*certs.ZCert{{
+		Type: certs.ZCertType_CERT_TYPE_DEVICE_RESTRICTED_SIGNING,
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: akCert.Raw}),
+	}, {
+		Type: certs.ZCertType_CERT_TYPE_DEVICE_ECDH_EXCHANGE,
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: otherCert.Raw}),
+	}}
+
+	t.Run("certs", func(t *testing.T) {
+		statuses, err := eattest.VerifyCerts(devCertPEM, zcerts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(statuses) != 2 {
+			t.Fatalf("expected 2 statuses, got %d", len(statuses))
+		}
+		if statuses[0].Error != "" {
+			t.Errorf("attestation key certificate must be valid: %s", statuses[0].Error)
+		}
+		if statuses[1].Error == "" {
+			t.Error("certificate signed by another CA must not be valid")
+		}
+	})
+
+	nonce := []byte("nonce")
+	pcr0 := bytes.Repeat([]byte{1}, sha256.Size)
+
+	t.Run("quote", func(t *testing.T) {
+		quote := makeQuote(t, akKey, nonce, pcr0)
+		info, err := eattest.VerifyQuote(quote, akCert, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(info.Nonce, nonce) {
+			t.Errorf("unexpected nonce %x", info.Nonce)
+		}
+		if len(info.Selection) != 1 || len(info.Selection[0].PCRs) != 1 || info.Selection[0].PCRs[0] != 0 {
+			t.Errorf("unexpected selection %v", info.Selection)
+		}
+		if _, err = eattest.VerifyQuote(quote, akCert, []byte("another")); err == nil {
+			t.Error("quote with another nonce must not be valid")
+		}
+		quote.PcrValues[0].Value = bytes.Repeat([]byte{2}, sha256.Size)
+		if _, err = eattest.VerifyQuote(quote, akCert, nonce); err == nil {
+			t.Error("quote with tampered PCR value must not be valid")
+		}
+		quote = makeQuote(t, akKey, nonce, pcr0)
+		if _, err = eattest.VerifyQuote(quote, otherCert, nonce); err == nil {
+			t.Error("quote must not be valid with another certificate")
+		}
+	})
+
+	t.Run("baseline", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "eden-attest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		quote := makeQuote(t, akKey, nonce, pcr0)
+		baselineFile := filepath.Join(dir, "baseline.json")
+		if err = eattest.NewBaseline(quote.PcrValues).Save(baselineFile); err != nil {
+			t.Fatal(err)
+		}
+		baseline, err := eattest.LoadBaseline(baselineFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := baseline.Compare(quote.PcrValues); len(diff) != 0 {
+			t.Errorf("unexpected difference: %v", diff)
+		}
+		quote.PcrValues[0].Value = bytes.Repeat([]byte{2}, sha256.Size)
+		if diff := baseline.Compare(quote.PcrValues); len(diff) != 1 {
+			t.Errorf("expected one difference, got %v", diff)
+		}
+		if diff := baseline.Compare(nil); len(diff) != 1 {
+			t.Errorf("expected not reported PCR, got %v", diff)
+		}
+	})
+	t.Run("controller", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "eden-attest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		proto := &protodir.Ctx{}
+		if err = proto.InitWithVars(&utils.ConfigVars{ProtoDir: dir}); err != nil {
+			t.Fatal(err)
+		}
+		ctrl := &controller.CloudCtx{Controller: proto}
+		dev := device.CreateEdgeNode()
+		devUUID, _ := uuid.NewV4()
+		dev.SetID(devUUID)
+		if _, err = eattest.GetDeviceQuote(ctrl, dev); err == nil {
+			t.Error("expected error for device without quote")
+		}
+		quote := makeQuote(t, akKey, nonce, pcr0)
+		data, err := protojson.Marshal(&attest.ZAttestReq{ReqType: attest.ZAttestReqType_ATTEST_REQ_QUOTE, Quote: quote})
+		if err != nil {
+			t.Fatal(err)
+		}
+		devDir := filepath.Join(dir, "devices", devUUID.String())
+		if err = os.MkdirAll(devDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(devDir, "attest.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		received, err := eattest.GetDeviceQuote(ctrl, dev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = eattest.VerifyQuote(received, akCert, nonce); err != nil {
+			t.Errorf("quote from controller must be valid: %s", err)
+		}
+	})
+}
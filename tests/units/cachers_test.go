@@ -0,0 +1,94 @@
+package templates
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/cachers"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve/api/go/flowlog"
+	"github.com/lf-edge/eve/api/go/logs"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// These tests verify caching of flow logs and app logs into directory
+
+func newFileCache(dir string) *cachers.FileCache {
+	return cachers.NewFileCache(types.DirGetters{
+		FlowLogGetter: func(devUUID uuid.UUID) string {
+			return filepath.Join(dir, devUUID.String(), "flowlogs")
+		},
+		AppsGetter: func(devUUID uuid.UUID, appUUID uuid.UUID) string {
+			return filepath.Join(dir, devUUID.String(), "apps", appUUID.String())
+		},
+	})
+}
+
+func checkFilesCount(t *testing.T, dir string, count int) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != count {
+		t.Fatalf("expected %d files in %s, got %d", count, dir, len(files))
+	}
+}
+
+func TestFileCacheFlowLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eden-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	devUUID, _ := uuid.NewV4()
+	cache := newFileCache(dir)
+	msg := &flowlog.FlowMessage{
+		DevId: devUUID.String(),
+		Flows: []*flowlog.FlowRecord{{
+			StartTime: timestamppb.New(time.Unix(100, 0)),
+			EndTime:   timestamppb.New(time.Unix(200, 0)),
+		}},
+	}
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = cache.CheckAndSave(devUUID, uuid.Nil, types.FlowLogType, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkFilesCount(t, filepath.Join(dir, devUUID.String(), "flowlogs"), 1)
+	if err = cache.CheckAndSave(devUUID, uuid.Nil, types.FlowLogType, []byte("{}")); err == nil {
+		t.Fatal("expected error for flow log without timestamp")
+	}
+}
+
+func TestFileCacheAppLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eden-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	devUUID, _ := uuid.NewV4()
+	appUUID, _ := uuid.NewV4()
+	cache := newFileCache(dir)
+	for i := 0; i < 3; i++ {
+		data, err := protojson.Marshal(&logs.LogEntry{
+			Content:   "test",
+			Timestamp: timestamppb.New(time.Unix(int64(i), 0)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = cache.CheckAndSave(devUUID, appUUID, types.AppsType, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkFilesCount(t, filepath.Join(dir, devUUID.String(), "apps", appUUID.String()), 3)
+}
@@ -0,0 +1,77 @@
+package templates
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/config"
+)
+
+// These tests verify decryption of cipher blocks used to re-encrypt them during rotation of certs
+// and inspection of certificates
+
+func TestCertsDecryptCipherBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eden-certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rootCert, rootKey := utils.GenCARoot()
+	devCert, devKey := utils.GenServerCertElliptic(rootCert, rootKey, big.NewInt(2), nil, nil, "device")
+	if err = utils.WriteToFiles(devCert, devKey, filepath.Join(dir, "device.pem"), filepath.Join(dir, "device-key.pem")); err != nil {
+		t.Fatal(err)
+	}
+	devCertData, err := ioutil.ReadFile(filepath.Join(dir, "device.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cryptoConfigs []*utils.CommonCryptoConfig
+	for _, name := range []string{"old", "new"} {
+		cert, key := utils.GenServerCertElliptic(rootCert, rootKey, big.NewInt(1), nil, nil, name)
+		certPath, keyPath := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+		if err = utils.WriteToFiles(cert, key, certPath, keyPath); err != nil {
+			t.Fatal(err)
+		}
+		cryptoConfig, err := utils.GetCommonCryptoConfig(devCertData, certPath, keyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cryptoConfigs = append(cryptoConfigs, cryptoConfig)
+	}
+	cipherCtx, err := utils.CreateCipherCtx(cryptoConfigs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipherBlock, err := utils.CryptoConfigWrapper(&config.EncryptionBlock{DsAPIKey: "user", DsPassword: "secret"}, cryptoConfigs[0], cipherCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encBlock, err := utils.DecryptCipherBlock(cipherBlock, cryptoConfigs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encBlock.DsAPIKey != "user" || encBlock.DsPassword != "secret" {
+		t.Fatalf("unexpected decrypted block: %v", encBlock)
+	}
+	if _, err = utils.DecryptCipherBlock(cipherBlock, cryptoConfigs[1]); err == nil {
+		t.Fatal("expected error for decryption with another controller certificate")
+	}
+
+	certsInfo, err := eden.InspectCerts(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certsInfo) != 3 {
+		t.Fatalf("expected 3 certificates, got %d", len(certsInfo))
+	}
+	for _, el := range certsInfo {
+		if el.ExpiresIn() <= 0 {
+			t.Fatalf("certificate %s already expired", el.File)
+		}
+	}
+}
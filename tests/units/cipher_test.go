@@ -0,0 +1,89 @@
+package templates
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/expect"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/certs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// These tests verify encryption of credentials of datastore with certificate of device,
+// decryption of them with signing key of controller and matching of datastore by credentials
+
+//certsController returns attestation certificates of device
+type certsController struct {
+	controller.Controller
+	certs string
+}
+
+func (c *certsController) CertsGet(_ uuid.UUID) (string, error) {
+	return c.certs, nil
+}
+
+//newCertsCloud returns controller with generated DEVICE_ECDH_EXCHANGE certificate of device
+//and signing certificate of controller inside dir
+func newCertsCloud(t *testing.T, dir string) controller.Cloud {
+	rootCert, rootKey := utils.GenCARoot()
+	signingCert, signingKey := utils.GenServerCertElliptic(rootCert, rootKey, big.NewInt(2), nil, nil, "signing")
+	if err := utils.WriteToFiles(signingCert, signingKey, filepath.Join(dir, "signing.pem"), filepath.Join(dir, "signing-key.pem")); err != nil {
+		t.Fatal(err)
+	}
+	ecdhCert, _ := utils.GenServerCertElliptic(rootCert, rootKey, big.NewInt(3), nil, nil, "ecdh")
+	data, err := json.Marshal(&types.Zcerts{Certs: []*certs.ZCert{{
+		Type: certs.ZCertType_CERT_TYPE_DEVICE_ECDH_EXCHANGE,
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ecdhCert.Raw}),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := &controller.CloudCtx{Controller: &certsController{certs: string(data)}}
+	ctrl.SetVars(&utils.ConfigVars{ZArch: "amd64", AdamCA: filepath.Join(dir, "root-certificate.pem")})
+	return ctrl
+}
+
+func TestDataStoreCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eden-cipher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctrl := newCertsCloud(t, dir)
+	dev := device.CreateEdgeNode()
+
+	exp := expect.AppExpectationFromURL(ctrl, dev, "docker://lfedge/eden-eclient", "app",
+		expect.WithRegistryCredentials("user", "password"))
+	ds := exp.DataStore()
+	if ds.CipherData == nil || ds.ApiKey != "" || ds.Password != "" {
+		t.Fatalf("credentials of datastore must be encrypted: %v", ds)
+	}
+	encBlock, err := expect.DecryptCipherBlock(ctrl, dev, ds.CipherData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encBlock.DsAPIKey != "user" || encBlock.DsPassword != "password" {
+		t.Fatalf("unexpected decrypted credentials: %s/%s", encBlock.DsAPIKey, encBlock.DsPassword)
+	}
+	if same := exp.DataStore(); same.Id != ds.Id {
+		t.Errorf("datastore with the same credentials must be reused: %s != %s", same.Id, ds.Id)
+	}
+
+	other := expect.AppExpectationFromURL(ctrl, dev, "docker://lfedge/eden-eclient", "app",
+		expect.WithRegistryCredentials("user", "other"))
+	if otherDs := other.DataStore(); otherDs.Id == ds.Id {
+		t.Error("datastore with different credentials must not be reused")
+	}
+	if count := len(ctrl.ListDataStore()); count != 2 {
+		t.Errorf("expected 2 datastores, got %d", count)
+	}
+}
@@ -0,0 +1,56 @@
+package templates
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/projects"
+)
+
+// These tests verify that deadline of context used for waits in tests can be extended
+
+func TestDeadlineExpires(t *testing.T) {
+	ctx, cancel := projects.WithExtendableDeadline(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context is not done after deadline")
+	}
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Fatalf("expected %s, got %v", context.DeadlineExceeded, ctx.Err())
+	}
+}
+
+func TestDeadlineExtend(t *testing.T) {
+	start := time.Now()
+	ctx, cancel := projects.WithExtendableDeadline(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ctx.Extend(200 * time.Millisecond)
+	if deadline, ok := ctx.Deadline(); !ok || deadline.Before(start.Add(300*time.Millisecond)) {
+		t.Fatalf("deadline is not extended: %s", deadline)
+	}
+	select {
+	case <-ctx.Done():
+		t.Fatalf("context is done before extended deadline: %s", ctx.Err())
+	case <-time.After(200 * time.Millisecond):
+	}
+	<-ctx.Done()
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Fatalf("context is done after %s", elapsed)
+	}
+}
+
+func TestDeadlineCancel(t *testing.T) {
+	ctx, cancel := projects.WithExtendableDeadline(context.Background(), time.Minute)
+	cancel()
+	<-ctx.Done()
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("expected %s, got %v", context.Canceled, ctx.Err())
+	}
+	ctx.Extend(time.Minute)
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("extend of done context changes it: %v", ctx.Err())
+	}
+}
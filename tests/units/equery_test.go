@@ -0,0 +1,103 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/equery"
+	"github.com/lf-edge/eve/api/go/info"
+	"github.com/lf-edge/eve/api/go/logs"
+	"github.com/lf-edge/eve/api/go/metrics"
+)
+
+// These tests verify parsing of queries and matching of objects with them
+
+func TestQueryParse(t *testing.T) {
+	for _, el := range []string{
+		"severity>=warning",
+		"source:zedagent OR NOT (severity==info AND content:\"some text\")",
+		"exists(filename) && !iid:^1",
+		"content:(a|b) source!:^zed",
+	} {
+		if _, err := equery.Parse(el); err != nil {
+			t.Errorf("cannot parse %q: %s", el, err)
+		}
+	}
+	for _, el := range []string{
+		"severity",
+		"(source:zedagent",
+		"source:zedagent OR",
+		"content:\"unterminated",
+		"content:[",
+	} {
+		if _, err := equery.Parse(el); err == nil {
+			t.Errorf("expected error for %q", el)
+		}
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	le := &elog.FullLogEntry{LogEntry: logs.LogEntry{Severity: "error", Source: "zedagent", Content: "some text", Msgid: 42}}
+	for expression, expected := range map[string]bool{
+		"severity>=warning":                          true,
+		"severity<warning":                           false,
+		"source:zedagent AND msgid>40":               true,
+		"source:^zed msgid>50":                       false,
+		"source:newlogd OR content:text":             true,
+		"NOT source:zedagent":                        false,
+		"source!:zedagent || exists(content)":        true,
+		"exists(filename)":                           false,
+		"NOT (severity==info OR severity==debug)":    true,
+		"content==\"some text\" AND msgid!=41":       true,
+		"(source:newlogd OR msgid<=42) AND iid:^$|0": true,
+	} {
+		expr, err := equery.Parse(expression)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", expression, err)
+		}
+		if expr.Match(le) != expected {
+			t.Errorf("expected %t for %q", expected, expression)
+		}
+	}
+	if !elog.LogItemFind(le, map[string]string{"source": "zed", equery.ExprKey: "severity>info"}) {
+		t.Error("expected match of map query with expression")
+	}
+	mm := &metrics.ZMetricMsg{Am: []*metrics.AppMetric{{AppName: "a1", Cpu: &metrics.AppCpuMetric{Total: 90}}, {AppName: "a2", Cpu: &metrics.AppCpuMetric{Total: 10}}}}
+	if !emetric.MetricItemFind(mm, map[string]string{equery.ExprKey: "am[].cpu.total>80"}) {
+		t.Error("expected match of app with cpu > 80")
+	}
+	if emetric.MetricItemFind(mm, map[string]string{equery.ExprKey: "am[].cpu.total>95"}) {
+		t.Error("unexpected match of app with cpu > 95")
+	}
+	im := &info.ZInfoApp{AppName: "a1", State: info.ZSwState_HALTED}
+	expr, err := equery.Parse("state!=RUNNING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expr.Match(im) {
+		t.Error("expected match of app which is not running")
+	}
+}
+
+func TestQueryFromArgs(t *testing.T) {
+	q, err := equery.FromArgs([]string{"source:zed agent", "(severity>=warning OR msgid>40)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q["source"] != "zed agent" || q[equery.ExprKey] != "(severity>=warning OR msgid>40)" {
+		t.Fatalf("unexpected query: %v", q)
+	}
+	q, err = equery.FromArgs([]string{"content:cats OR dogs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q["content"] != "cats OR dogs" || q[equery.ExprKey] != "" {
+		t.Fatalf("pattern with keywords must be literal: %v", q)
+	}
+	for _, el := range []string{"severity", "severity>=warning OR msgid>40"} {
+		if _, err = equery.FromArgs([]string{el}); err == nil {
+			t.Fatalf("expected error for query %s without brackets", el)
+		}
+	}
+}
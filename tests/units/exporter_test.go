@@ -0,0 +1,48 @@
+package templates
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eve/api/go/metrics"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// These tests verify exposing of EVE metrics in Prometheus format
+
+func TestExporter(t *testing.T) {
+	exporter := emetric.NewExporter()
+	handler := exporter.HandleFactory("eve1")
+	handler(&metrics.ZMetricMsg{
+		AtTimeStamp: timestamppb.Now(),
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Memory: &metrics.MemoryMetric{UsedMem: 100, AvailMem: 200},
+		}},
+		Am: []*metrics.AppMetric{{AppName: "nginx", Cpu: &metrics.AppCpuMetric{Total: 42}}},
+		Vm: []*metrics.ZMetricVolume{{DisplayName: "vol", UsedBytes: 1024}},
+	})
+	srv := httptest.NewServer(exporter.Handler())
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`eve_device_memory_used_bytes{device="eve1"} 1.048576e+08`,
+		`eve_app_cpu_seconds_total{app="nginx",device="eve1"} 42`,
+		`eve_volume_used_bytes{device="eve1",volume="vol"} 1024`,
+		"# TYPE eve_app_cpu_seconds_total counter",
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("expected %q in output:\n%s", expected, body)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package templates
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eden"
+)
+
+// These tests verify allocation of ports, serials and directories for additional instances of EVE
+
+func TestEVEInstances(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eden-instances")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	instances, err := eden.ListEVEInstances(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 0 {
+		t.Fatalf("expected no instances, got %d", len(instances))
+	}
+	for _, i := range []int{2, 1} {
+		if err = os.MkdirAll(eden.NewEVEInstance(dir, i).ConfigDir(), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = os.MkdirAll(filepath.Join(eden.EVEInstancesDir(dir), "wrong"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if instances, err = eden.ListEVEInstances(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 2 || instances[0].Index != 1 || instances[1].Index != 2 {
+		t.Fatalf("unexpected instances: %v", instances)
+	}
+	inst := instances[1]
+	if name := inst.Name("default"); name != "default-2" {
+		t.Errorf("unexpected name %s", name)
+	}
+	if serial := inst.Serial("31415926"); serial != "314159262" {
+		t.Errorf("unexpected serial %s", serial)
+	}
+	if port := inst.Port(7777); port != 7977 {
+		t.Errorf("unexpected port %d", port)
+	}
+	if port := inst.Port(0); port != 0 {
+		t.Errorf("not defined port must not be shifted, got %d", port)
+	}
+	hostFwd := inst.HostFwd(map[string]string{"2222": "22", "8027": "8027"})
+	if hostFwd["2422"] != "22" || hostFwd["8227"] != "8027" || len(hostFwd) != 2 {
+		t.Errorf("unexpected hostfwd %v", hostFwd)
+	}
+	if imageFile := inst.ImageFile(filepath.Join(dir, "live.img")); imageFile != filepath.Join(dir, "instances", "2", "live.img") {
+		t.Errorf("unexpected image file %s", imageFile)
+	}
+}
@@ -0,0 +1,151 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/lan"
+)
+
+// These tests verify allocation of addresses by DHCP responder of virtual LAN
+// and resolving of names by its DNS responder
+
+//dhcpRequest returns DHCP message of type from client with mac with options
+func dhcpRequest(t *testing.T, msgType byte, mac string, options map[byte][]byte) *lan.DHCPPacket {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := map[byte][]byte{53: {msgType}}
+	for k, v := range options {
+		opts[k] = v
+	}
+	req := &lan.DHCPPacket{Op: 1, XID: 42, CHAddr: hw, CIAddr: net.IPv4zero, Options: opts}
+	//check serialization with the same path as packets from network
+	parsed, err := lan.ParseDHCP(req.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+//dnsQuery returns DNS query of A record of name
+func dnsQuery(name string) []byte {
+	query := []byte{0x12, 0x34, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0}
+	for _, label := range strings.Split(name, ".") {
+		query = append(query, byte(len(label)))
+		query = append(query, label...)
+	}
+	return append(query, 0, 0, 1, 0, 1)
+}
+
+func TestLAN(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eden-lan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	_, subnet, err := net.ParseCIDR("192.168.94.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leasesFile := filepath.Join(dir, "leases.json")
+	server, err := lan.NewServer(&lan.Config{
+		Bridge:     "eden0",
+		Subnet:     subnet,
+		Hosts:      map[string]net.IP{"mydomain.adam": net.ParseIP("192.168.94.1")},
+		LeasesFile: leasesFile,
+		LeaseTime:  time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gw := lan.Gateway(subnet); gw.String() != "192.168.94.1" {
+		t.Fatalf("unexpected gateway %s", gw)
+	}
+
+	t.Run("dhcp", func(t *testing.T) {
+		offer, err := server.HandleDHCP(dhcpRequest(t, lan.DHCPDiscover, "52:54:00:00:00:01", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if offer.MessageType() != lan.DHCPOffer || offer.YIAddr.String() != "192.168.94.2" {
+			t.Fatalf("unexpected offer %d of %s", offer.MessageType(), offer.YIAddr)
+		}
+		if !bytes.Equal(offer.Options[3], net.ParseIP("192.168.94.1").To4()) {
+			t.Errorf("unexpected router %v", offer.Options[3])
+		}
+		ack, err := server.HandleDHCP(dhcpRequest(t, lan.DHCPRequest, "52:54:00:00:00:01", map[byte][]byte{
+			50: offer.YIAddr.To4(),
+			12: []byte("eve"),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ack.MessageType() != lan.DHCPAck || !ack.YIAddr.Equal(offer.YIAddr) {
+			t.Fatalf("unexpected ack %d of %s", ack.MessageType(), ack.YIAddr)
+		}
+		if binary.BigEndian.Uint32(ack.Options[51]) != 3600 {
+			t.Errorf("unexpected lease time %v", ack.Options[51])
+		}
+		nak, err := server.HandleDHCP(dhcpRequest(t, lan.DHCPRequest, "52:54:00:00:00:01", map[byte][]byte{
+			50: net.ParseIP("192.168.94.100").To4(),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nak.MessageType() != lan.DHCPNak {
+			t.Errorf("request of another address must be rejected, got %d", nak.MessageType())
+		}
+		other, err := server.HandleDHCP(dhcpRequest(t, lan.DHCPDiscover, "52:54:00:00:00:02", map[byte][]byte{
+			50: offer.YIAddr.To4(),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other.YIAddr.String() != "192.168.94.3" {
+			t.Errorf("leased address must not be offered to another device, got %s", other.YIAddr)
+		}
+		if reply, _ := server.HandleDHCP(dhcpRequest(t, lan.DHCPRequest, "52:54:00:00:00:02", map[byte][]byte{
+			54: net.ParseIP("192.168.94.254").To4(),
+		})); reply != nil {
+			t.Error("request to another server must be ignored")
+		}
+		leases, err := lan.LoadLeases(leasesFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(leases) != 2 || leases[0].IP != "192.168.94.2" || leases[0].Hostname != "eve" {
+			t.Errorf("unexpected leases %+v", leases)
+		}
+	})
+
+	t.Run("dns", func(t *testing.T) {
+		for name, expected := range map[string]string{"mydomain.adam": "192.168.94.1", "EVE": "192.168.94.2"} {
+			answer, err := server.HandleDNS(dnsQuery(name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if answer == nil {
+				t.Fatalf("no answer for %s", name)
+			}
+			if binary.BigEndian.Uint16(answer[6:8]) != 1 || !bytes.HasSuffix(answer, net.ParseIP(expected).To4()) {
+				t.Errorf("unexpected answer for %s: %x", name, answer)
+			}
+		}
+		answer, err := server.HandleDNS(dnsQuery("example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if answer != nil {
+			t.Error("unknown name must be forwarded upstream")
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/manifest"
+)
+
+// These tests verify parsing of device manifests and computing diff with current state,
+// including fields of existing apps and networks of apps not defined in manifest
+
+const testManifest = `
+config-items:
+  timer.config.interval: "10"
+networks:
+  - name: n1
+    subnet: 10.11.12.0/24
+apps:
+  - name: nginx
+    image: docker://nginx
+    networks: [n1]
+    ports: ["8028:80"]
+    memory: 512MB
+`
+
+func TestManifestParse(t *testing.T) {
+	m, err := manifest.Parse([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Networks) != 1 || len(m.Apps) != 1 || m.ConfigItems["timer.config.interval"] != "10" {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	if _, err := manifest.Parse([]byte("apps:\n  - name: a\n    image: docker://nginx\n    networks: [unknown]\n")); err == nil {
+		t.Fatal("expected error for undefined network")
+	}
+	if _, err := manifest.Parse([]byte("unknown-field: 1\n")); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+func TestManifestDiff(t *testing.T) {
+	desired, err := manifest.Parse([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := manifest.Diff(desired, desired); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+	current, err := manifest.Parse([]byte(`
+networks:
+  - name: n1
+    subnet: 10.11.12.0/24
+  - name: n2
+    subnet: 10.11.13.0/24
+apps:
+  - name: nginx
+    image: docker://docker.io/library/nginx:latest
+    networks: [n1]
+    ports: ["8028:80"]
+    memory: 512000kB
+    stopped: true
+  - name: old
+    image: docker://alpine
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]manifest.Action{
+		"config-item/timer.config.interval": manifest.ActionAdd,
+		"network/n2":                        manifest.ActionRemove,
+		"app/nginx":                         manifest.ActionUpdate,
+		"app/old":                           manifest.ActionRemove,
+	}
+	changes := manifest.Diff(desired, current)
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %v", len(expected), changes)
+	}
+	for _, c := range changes {
+		action, ok := expected[string(c.Kind)+"/"+c.Name]
+		if !ok || action != c.Action {
+			t.Errorf("unexpected change: %s", c)
+		}
+		if c.Kind == manifest.KindApp && c.Name == "nginx" && c.Details != "start" {
+			t.Errorf("expected only start of nginx, got: %s", c)
+		}
+	}
+}
+
+const testCurrentApp = `
+networks:
+  - name: n1
+    subnet: 10.11.12.0/24
+apps:
+  - name: app
+    image: docker://docker.io/library/nginx:latest
+    networks: [n1]
+    acl: ["n1:github.com"]
+    disk-size: "1000000"
+    format: container
+    mounts: ["src=docker://index.docker.io/library/alpine:latest,dst=/data"]
+    metadata: "key=value"
+`
+
+func TestManifestDiffAppFields(t *testing.T) {
+	current, err := manifest.Parse([]byte(testCurrentApp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		app      string
+		expected string
+	}{
+		{"equal", `image: docker://nginx
+    networks: [n1]
+    acl: [github.com]
+    disk-size: 1MB
+    mounts: ["src=docker://alpine,dst=/data"]
+    metadata: "key=value"`, ""},
+		{"acl", `image: docker://nginx
+    acl: [google.com]
+    mounts: ["src=docker://alpine,dst=/data"]
+    metadata: "key=value"`, "acl"},
+		{"mounts", `image: docker://nginx
+    acl: [github.com]
+    mounts: ["src=docker://alpine,dst=/mnt"]
+    metadata: "key=value"`, "mounts"},
+		{"disk-size", `image: docker://nginx
+    acl: [github.com]
+    disk-size: 2MB
+    mounts: ["src=docker://alpine,dst=/data"]
+    metadata: "key=value"`, "disk size"},
+		{"metadata", `image: docker://nginx
+    acl: [github.com]
+    mounts: ["src=docker://alpine,dst=/data"]
+    metadata: "key=other"`, "metadata"},
+		{"format", `image: docker://nginx
+    acl: [github.com]
+    format: qcow2
+    mounts: ["src=docker://alpine,dst=/data"]
+    metadata: "key=value"`, "format"},
+		{"registry", `image: docker://nginx
+    registry: localhost:5000
+    acl: [github.com]
+    mounts: ["src=docker://alpine,dst=/data"]
+    metadata: "key=value"`, "image"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired, err := manifest.Parse([]byte("networks:\n  - name: n1\n    subnet: 10.11.12.0/24\napps:\n  - name: app\n    " + tt.app + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes := manifest.Diff(desired, current)
+			if tt.expected == "" {
+				if len(changes) != 0 {
+					t.Fatalf("expected no changes, got %v", changes)
+				}
+				return
+			}
+			if len(changes) != 1 || changes[0].Kind != manifest.KindApp || changes[0].Action != manifest.ActionUpdate ||
+				!strings.Contains(changes[0].Details, tt.expected) {
+				t.Fatalf("expected update of %s of app, got %v", tt.expected, changes)
+			}
+		})
+	}
+}
+
+func TestManifestDiffAppCurrentNetworks(t *testing.T) {
+	current, err := manifest.Parse([]byte(testCurrentApp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//app omits networks, but it is attached to recreated network
+	desired, err := manifest.Parse([]byte(`
+networks:
+  - name: n1
+    subnet: 10.11.13.0/24
+apps:
+  - name: app
+    image: docker://nginx
+    acl: [github.com]
+    mounts: ["src=docker://alpine,dst=/data"]
+    metadata: "key=value"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := manifest.Diff(desired, current)
+	found := false
+	for _, c := range changes {
+		if c.Kind == manifest.KindApp && c.Name == "app" && strings.Contains(c.Details, "network n1 replaced") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected recreation of app attached to replaced network, got %v", changes)
+	}
+}
@@ -0,0 +1,43 @@
+package templates
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eve/api/go/config"
+)
+
+// These tests verify structured output of EVE objects states
+
+func TestOutputFormats(t *testing.T) {
+	states := []*eve.NetInstState{{Name: "n1", UUID: "uuid", NetworkType: config.ZNetworkInstType_ZnetInstLocal, CIDR: "10.1.0.0/24"}}
+	for format, expected := range map[string]string{
+		"json":                  `"networkType": "ZnetInstLocal"`,
+		"yaml":                  "  networkType: ZnetInstLocal\n",
+		"template={{.CIDR}}":    "10.1.0.0/24\n",
+		"go-template={{.Name}}": "n1\n",
+	} {
+		output, err := eve.ParseOutput(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output.IsTable() {
+			t.Fatalf("%s must not be table", format)
+		}
+		var buf bytes.Buffer
+		if err = output.Print(&buf, states); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("%s: expected %q in output, got %q", format, expected, buf.String())
+		}
+	}
+	if output, err := eve.ParseOutput("wide"); err != nil || !output.IsTable() || !output.IsWide() {
+		t.Errorf("wrong wide output: %v %v", output, err)
+	}
+	if _, err := eve.ParseOutput("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
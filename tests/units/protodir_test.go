@@ -0,0 +1,141 @@
+package templates
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/protodir"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/config"
+	"github.com/lf-edge/eve/api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// These tests verify the functionality of proto controller
+// which stores configs and reads objects from directory
+
+func newProtoDirCtx(t *testing.T) (*protodir.Ctx, *device.Ctx, string) {
+	dir, err := ioutil.TempDir("", "eden-proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &protodir.Ctx{}
+	if err = ctx.InitWithVars(&utils.ConfigVars{ProtoDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	onboardUUID, _ := uuid.NewV4()
+	rootCert, rootKey := utils.GenCARoot()
+	onboardCert, onboardKey := utils.GenServerCertElliptic(rootCert, rootKey, big.NewInt(2), nil, nil, onboardUUID.String())
+	certFile := filepath.Join(dir, "onboard.cert.pem")
+	if err = utils.WriteToFiles(onboardCert, onboardKey, certFile, filepath.Join(dir, "onboard.key.pem")); err != nil {
+		t.Fatal(err)
+	}
+	dev := device.CreateEdgeNode()
+	dev.SetOnboardKey(certFile)
+	dev.SetSerial("31415926")
+	return ctx, dev, dir
+}
+
+//TestProtoDirRegister try to register device and find it by onboard certificate
+func TestProtoDirRegister(t *testing.T) {
+	ctx, dev, dir := newProtoDirCtx(t)
+	defer os.RemoveAll(dir)
+	if err := ctx.Register(dev); err != nil {
+		t.Fatal(err)
+	}
+	// second registration must not create new device
+	if err := ctx.Register(dev); err != nil {
+		t.Fatal(err)
+	}
+	devices, err := ctx.DeviceList(types.RegisteredDeviceFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("expected %d devices, received %d", 1, len(devices))
+	}
+	devUUID, err := ctx.DeviceGetByOnboard(dev.GetOnboardKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if devUUID.String() != devices[0] {
+		t.Errorf("expected: %s, received: %s", devices[0], devUUID)
+	}
+}
+
+//TestProtoDirConfig try to set config and read it back from directory
+func TestProtoDirConfig(t *testing.T) {
+	ctx, dev, dir := newProtoDirCtx(t)
+	defer os.RemoveAll(dir)
+	if err := ctx.Register(dev); err != nil {
+		t.Fatal(err)
+	}
+	devUUID, err := ctx.DeviceGetByOnboard(dev.GetOnboardKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	devConfig := &config.EdgeDevConfig{
+		Id:          &config.UUIDandVersion{Uuid: devUUID.String(), Version: "2"},
+		ConfigItems: []*config.ConfigItem{{Key: "timer.config.interval", Value: "5"}},
+	}
+	data, err := protojson.Marshal(devConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ctx.ConfigSet(devUUID, data); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ctx.ConfigGet(devUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received config.EdgeDevConfig
+	if err = protojson.Unmarshal([]byte(out), &received); err != nil {
+		t.Fatal(err)
+	}
+	if received.Id.Version != "2" || len(received.ConfigItems) != 1 {
+		t.Errorf("unexpected config received: %s", out)
+	}
+}
+
+//TestProtoDirInfo try to replay info files placed into directory of device
+func TestProtoDirInfo(t *testing.T) {
+	ctx, dev, dir := newProtoDirCtx(t)
+	defer os.RemoveAll(dir)
+	if err := ctx.Register(dev); err != nil {
+		t.Fatal(err)
+	}
+	devUUID, err := ctx.DeviceGetByOnboard(dev.GetOnboardKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoDir := filepath.Join(dir, "devices", devUUID.String(), "info")
+	if err = os.MkdirAll(infoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := protojson.Marshal(&info.ZInfoMsg{DevId: devUUID.String(), Ztype: info.ZInfoTypes_ZiApp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(infoDir, "1"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	handler := func(im *info.ZInfoMsg, _ []*einfo.ZInfoMsgInterface) bool {
+		found = im.Ztype == info.ZInfoTypes_ZiApp
+		return true
+	}
+	if err = ctx.InfoLastCallback(devUUID, map[string]string{"devId": devUUID.String()}, handler); err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Error("info message not found")
+	}
+}
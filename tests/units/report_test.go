@@ -0,0 +1,137 @@
+package templates
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/tests"
+)
+
+// These tests verify collecting of results of test binaries into JUnit XML and JSON reports
+
+//escriptOutput is verbose output of eden.escript.test with parallel tests of escript files
+const escriptOutput = `=== RUN   TestEdenScripts
+    escript_test.go:36: testData directory: testdata
+=== RUN   TestEdenScripts/log_test
+=== PAUSE TestEdenScripts/log_test
+=== RUN   TestEdenScripts/ssh_test
+=== PAUSE TestEdenScripts/ssh_test
+=== CONT  TestEdenScripts/log_test
+    testscript.go:397:
+        > eden log
+=== CONT  TestEdenScripts/ssh_test
+    testscript.go:397:
+        > eden eve ssh
+        FAIL: testdata/ssh_test.txt:1: unexpected command failure
+--- FAIL: TestEdenScripts (12.50s)
+    --- PASS: TestEdenScripts/log_test (2.50s)
+    --- FAIL: TestEdenScripts/ssh_test (10.00s)
+FAIL
+=== FAIL SCENARIO: failScenario.txt
+eden info
+`
+
+func TestReportSuite(t *testing.T) {
+	suite := tests.NewSuiteResult("eden.escript.test", time.Now(), 13*time.Second, escriptOutput, errors.New("exit status 1"))
+	if len(suite.Cases) != 2 {
+		t.Fatalf("expected 2 cases, got %d", len(suite.Cases))
+	}
+	if suite.Cases[0].Name != "TestEdenScripts/log_test" || suite.Cases[0].Status != tests.CasePass || suite.Cases[0].Time != 2.5 {
+		t.Errorf("unexpected case %+v", suite.Cases[0])
+	}
+	if !strings.Contains(suite.Cases[0].Output, "> eden log") || strings.Contains(suite.Cases[0].Output, "eden eve ssh") {
+		t.Errorf("unexpected output of case %+v", suite.Cases[0])
+	}
+	if suite.Cases[1].Status != tests.CaseFail || !strings.Contains(suite.Cases[1].Output, "unexpected command failure") {
+		t.Errorf("unexpected case %+v", suite.Cases[1])
+	}
+	if strings.Contains(suite.Cases[1].Output, "> eden log") || strings.Contains(suite.Cases[1].Output, "FAIL\n") {
+		t.Errorf("unexpected output of case %+v", suite.Cases[1])
+	}
+	if !strings.Contains(suite.FailScenarioOutput, "eden info") || strings.Contains(suite.Output, "eden info") {
+		t.Errorf("fail scenario output is not separated: %q", suite.FailScenarioOutput)
+	}
+}
+
+func TestReportSuiteSkip(t *testing.T) {
+	output := "=== RUN   TestLog\n    log_test.go:20: logs are not requested\n--- SKIP: TestLog (0.00s)\nPASS\n"
+	suite := tests.NewSuiteResult("eden.log.test", time.Now(), time.Second, output, nil)
+	if len(suite.Cases) != 1 || suite.Cases[0].Status != tests.CaseSkip || suite.Cases[0].Output != "log_test.go:20: logs are not requested" {
+		t.Fatalf("unexpected cases %+v", suite.Cases)
+	}
+}
+
+func TestReportSuiteWithoutCases(t *testing.T) {
+	suite := tests.NewSuiteResult("eden.lim.test", time.Now(), time.Second, "panic: crash\n", errors.New("exit status 2"))
+	if len(suite.Cases) != 1 || suite.Cases[0].Status != tests.CaseFail || suite.Cases[0].Output != "exit status 2" {
+		t.Fatalf("unexpected cases %+v", suite.Cases)
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eden-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	report := &tests.Report{}
+	report.Add(tests.NewSuiteResult("eden.escript.test", time.Now(), 13*time.Second, escriptOutput, errors.New("exit status 1")))
+	report.Add(tests.NewSuiteResult("eden.lim.test", time.Now(), time.Second, "--- SKIP: TestLog (0.00s)\n", nil))
+
+	xmlFile := filepath.Join(dir, "junit.xml")
+	if err = report.WriteFile(xmlFile); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(xmlFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var junit struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name    string    `xml:"name,attr"`
+				Failure *struct{} `xml:"failure"`
+				Skipped *struct{} `xml:"skipped"`
+			} `xml:"testcase"`
+			SystemErr string `xml:"system-err"`
+		} `xml:"testsuite"`
+	}
+	if err = xml.Unmarshal(data, &junit); err != nil {
+		t.Fatal(err)
+	}
+	if junit.Tests != 3 || junit.Failures != 1 || len(junit.Suites) != 2 {
+		t.Fatalf("unexpected report %s", data)
+	}
+	if junit.Suites[0].Cases[1].Failure == nil || junit.Suites[1].Cases[0].Skipped == nil || junit.Suites[0].SystemErr == "" {
+		t.Errorf("unexpected report %s", data)
+	}
+
+	jsonFile := filepath.Join(dir, "report.json")
+	if err = report.WriteFile(jsonFile); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = ioutil.ReadFile(jsonFile); err != nil {
+		t.Fatal(err)
+	}
+	var decoded tests.Report
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Tests != 3 || decoded.Skipped != 1 || len(decoded.Suites) != 2 || decoded.Suites[0].Cases[1].Status != tests.CaseFail {
+		t.Errorf("unexpected report %s", data)
+	}
+
+	if err = report.WriteFile(filepath.Join(dir, "report.txt")); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,245 @@
+package templates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/sshclient"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// These tests verify built-in ssh client against in-process ssh server which runs commands with sh:
+// exit codes, verification of host keys, copying of files and parsing of OpenSSH options
+
+//sshTestKey returns new private key
+func sshTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+//sshTestServer starts ssh server which accepts clientKey and returns its address
+//sftp subsystem is not supported, so files are copied with cat
+func sshTestServer(t *testing.T, hostKey *ecdsa.PrivateKey, clientKey ssh.PublicKey) string {
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, os.ErrPermission
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sshTestServe(conn, config)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func sshTestServe(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			defer channel.Close()
+			for req := range requests {
+				if req.Type != "exec" {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				var payload struct{ Command string }
+				if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				_ = req.Reply(true, nil)
+				cmd := exec.Command("sh", "-c", payload.Command)
+				cmd.Stdin = channel
+				cmd.Stdout = channel
+				cmd.Stderr = channel.Stderr()
+				status := struct{ Status uint32 }{}
+				if err := cmd.Run(); err != nil {
+					status.Status = 255
+					if exitErr, ok := err.(*exec.ExitError); ok {
+						status.Status = uint32(exitErr.ExitCode())
+					}
+				}
+				_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(&status))
+				return
+			}
+		}()
+	}
+}
+
+func TestSSHClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eden-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	clientKey := sshTestKey(t)
+	der, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "id_ecdsa")
+	if err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	clientPub, err := ssh.NewPublicKey(&clientKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey := sshTestKey(t)
+	addr := sshTestServer(t, hostKey, clientPub)
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	cfg := func(check sshclient.HostKeyCheck) *sshclient.Config {
+		return &sshclient.Config{
+			Host:           host,
+			Port:           port,
+			User:           "root",
+			KeyFile:        keyFile,
+			KnownHostsFile: knownHostsFile,
+			HostKeyCheck:   check,
+			Timeout:        5 * time.Second,
+		}
+	}
+
+	t.Run("known_hosts", func(t *testing.T) {
+		if _, err := sshclient.Dial(cfg(sshclient.HostKeyCheckYes)); err == nil {
+			t.Fatal("unknown host must be rejected")
+		}
+		client, err := sshclient.Dial(cfg(sshclient.HostKeyCheckAcceptNew))
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.Close()
+		client, err = sshclient.Dial(cfg(sshclient.HostKeyCheckYes))
+		if err != nil {
+			t.Fatalf("added host must be accepted: %s", err)
+		}
+		client.Close()
+		otherKey, err := ssh.NewPublicKey(&sshTestKey(t).PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, otherKey)
+		if err = ioutil.WriteFile(knownHostsFile, []byte(line+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sshclient.Dial(cfg(sshclient.HostKeyCheckAcceptNew)); err == nil {
+			t.Fatal("changed host key must be rejected")
+		}
+	})
+
+	client, err := sshclient.Dial(cfg(sshclient.HostKeyCheckNo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	t.Run("run", func(t *testing.T) {
+		out, code, err := client.Output("echo test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != 0 || strings.TrimSpace(string(out)) != "test" {
+			t.Errorf("unexpected output %q with code %d", out, code)
+		}
+		code, err = client.Run("exit 3", nil, ioutil.Discard, ioutil.Discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != 3 {
+			t.Errorf("unexpected exit code %d", code)
+		}
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		content := []byte("test content\n")
+		local := filepath.Join(dir, "local")
+		if err := ioutil.WriteFile(local, content, 0640); err != nil {
+			t.Fatal(err)
+		}
+		remote := filepath.Join(dir, "remote 'file'")
+		if err := client.Upload(local, remote); err != nil {
+			t.Fatal(err)
+		}
+		if info, err := os.Stat(remote); err != nil || info.Mode().Perm() != 0640 {
+			t.Fatalf("remote file is not created with permissions of local one: %v", err)
+		}
+		downloaded := filepath.Join(dir, "downloaded")
+		if err := client.Download(remote, downloaded); err != nil {
+			t.Fatal(err)
+		}
+		if b, err := ioutil.ReadFile(downloaded); err != nil || string(b) != string(content) {
+			t.Errorf("unexpected content %q: %v", b, err)
+		}
+		if err := client.Download(filepath.Join(dir, "absent"), downloaded); err == nil {
+			t.Error("absent file must not be downloaded")
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		c := &sshclient.Config{Password: "passw0rd"}
+		for _, option := range []string{"ConnectTimeout=10", "StrictHostKeyChecking accept-new", "PasswordAuthentication=no", "Port=2222", "ForwardAgent=yes"} {
+			if err := c.SetOption(option); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if c.Timeout != 10*time.Second || c.HostKeyCheck != sshclient.HostKeyCheckAcceptNew || c.Password != "" || c.Port != 2222 {
+			t.Errorf("unexpected config %+v", c)
+		}
+		if err := c.SetOption("StrictHostKeyChecking=maybe"); err == nil {
+			t.Error("wrong option value must be rejected")
+		}
+		if dest, path, ok := sshclient.ParseRemotePath("root@host:/persist/perf.data"); !ok || dest != "root@host" || path != "/persist/perf.data" {
+			t.Errorf("unexpected remote path %s %s", dest, path)
+		}
+		if _, _, ok := sshclient.ParseRemotePath("./dir:name"); ok {
+			t.Error("local path must not be parsed as remote")
+		}
+	})
+}
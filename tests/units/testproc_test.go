@@ -0,0 +1,335 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/erequest"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/projects"
+	"github.com/lf-edge/eve/api/go/flowlog"
+	"github.com/lf-edge/eve/api/go/info"
+	uuid "github.com/satori/go.uuid"
+)
+
+// These tests verify delivery of events of controller to processors of test context:
+// order of events, filtering by kind, timeout of processor and delivery of existing records,
+// and steps of test: processors waited by step, pending processors and extending of deadline of step
+
+//fakeController delivers flow logs and info sent into its channels to checkers
+type fakeController struct {
+	controller.Controller
+	flowLogs         chan *flowlog.FlowMessage
+	infos            chan *info.ZInfoMsg
+	existingFlowLogs []*flowlog.FlowMessage
+	done             chan struct{}
+}
+
+func newFakeController() *fakeController {
+	return &fakeController{
+		flowLogs: make(chan *flowlog.FlowMessage, 10),
+		infos:    make(chan *info.ZInfoMsg, 10),
+		done:     make(chan struct{}),
+	}
+}
+
+func (f *fakeController) FlowLogChecker(_ uuid.UUID, _ map[string]string, handler eflowlog.HandlerFunc, _ eflowlog.FlowLogCheckerMode, _ time.Duration) error {
+	for {
+		select {
+		case msg := <-f.flowLogs:
+			handler(msg)
+		case <-f.done:
+			return nil
+		}
+	}
+}
+
+func (f *fakeController) FlowLogLastCallback(_ uuid.UUID, _ map[string]string, handler eflowlog.HandlerFunc) error {
+	for _, msg := range f.existingFlowLogs {
+		handler(msg)
+	}
+	return nil
+}
+
+func (f *fakeController) InfoChecker(_ uuid.UUID, _ map[string]string, handler einfo.HandlerFunc, _ einfo.InfoCheckerMode, _ time.Duration) error {
+	for {
+		select {
+		case msg := <-f.infos:
+			handler(msg, nil)
+		case <-f.done:
+			return nil
+		}
+	}
+}
+
+func (f *fakeController) LogChecker(_ uuid.UUID, _ map[string]string, _ elog.HandlerFunc, _ elog.LogCheckerMode, _ time.Duration) error {
+	<-f.done
+	return nil
+}
+
+func (f *fakeController) MetricChecker(_ uuid.UUID, _ map[string]string, _ emetric.HandlerFunc, _ emetric.MetricCheckerMode, _ time.Duration) error {
+	<-f.done
+	return nil
+}
+
+func (f *fakeController) RequestLastCallback(_ uuid.UUID, _ map[string]string, _ erequest.HandlerFunc) error {
+	return nil
+}
+
+func newFakeTestContext() (*projects.TestContext, *fakeController, *device.Ctx) {
+	ctrl := newFakeController()
+	tc := projects.NewTestContextWithController(&controller.CloudCtx{Controller: ctrl})
+	return tc, ctrl, device.CreateEdgeNode()
+}
+
+func TestProcOrder(t *testing.T) {
+	tc, ctrl, dev := newFakeTestContext()
+	defer close(ctrl.done)
+	var received []string
+	err := tc.RunStep("order", 5*time.Second, func() {
+		tc.AddProcFlowLog(dev, func(msg *flowlog.FlowMessage) error {
+			received = append(received, msg.DevId)
+			if len(received) == 3 {
+				return fmt.Errorf("all received")
+			}
+			return nil
+		})
+		for _, id := range []string{"first", "second", "third"} {
+			ctrl.flowLogs <- &flowlog.FlowMessage{DevId: id}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(received, ",") != "first,second,third" {
+		t.Errorf("unexpected order of events: %v", received)
+	}
+}
+
+func TestProcKind(t *testing.T) {
+	tc, ctrl, dev := newFakeTestContext()
+	defer close(ctrl.done)
+	var flowLogs, infos []string
+	err := tc.RunStep("kind", 5*time.Second, func() {
+		tc.AddProcFlowLog(dev, func(msg *flowlog.FlowMessage) error {
+			flowLogs = append(flowLogs, msg.DevId)
+			return fmt.Errorf("flow log received")
+		})
+		tc.AddProcInfo(dev, func(msg *info.ZInfoMsg) error {
+			infos = append(infos, msg.DevId)
+			if len(infos) == 2 {
+				return fmt.Errorf("info received")
+			}
+			return nil
+		})
+		ctrl.infos <- &info.ZInfoMsg{DevId: "info1"}
+		ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "flowlog"}
+		ctrl.infos <- &info.ZInfoMsg{DevId: "info2"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(flowLogs, ",") != "flowlog" {
+		t.Errorf("unexpected flow logs: %v", flowLogs)
+	}
+	if strings.Join(infos, ",") != "info1,info2" {
+		t.Errorf("unexpected info: %v", infos)
+	}
+}
+
+func TestProcTimeout(t *testing.T) {
+	tc, ctrl, dev := newFakeTestContext()
+	defer close(ctrl.done)
+	start := time.Now()
+	err := tc.RunStep("timeout", 5*time.Second, func() {
+		tc.AddProcFlowLog(dev, func(msg *flowlog.FlowMessage) error {
+			return nil
+		}, projects.WithProcTimeout(100*time.Millisecond))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("processor is not terminated by its timeout, step is done in %s", elapsed)
+	}
+	if pending := tc.PendingProcs(); len(pending) != 0 {
+		t.Errorf("unexpected pending processors: %v", pending)
+	}
+}
+
+func TestProcExisting(t *testing.T) {
+	tc, ctrl, dev := newFakeTestContext()
+	defer close(ctrl.done)
+	ctrl.existingFlowLogs = []*flowlog.FlowMessage{{DevId: "existing"}}
+	var mu sync.Mutex
+	var withExisting, withoutExisting []string
+	err := tc.RunStep("existing", 5*time.Second, func() {
+		tc.AddProcFlowLog(dev, func(msg *flowlog.FlowMessage) error {
+			mu.Lock()
+			defer mu.Unlock()
+			withoutExisting = append(withoutExisting, msg.DevId)
+			return fmt.Errorf("flow log received")
+		})
+		tc.AddProcFlowLog(dev, func(msg *flowlog.FlowMessage) error {
+			mu.Lock()
+			defer mu.Unlock()
+			withExisting = append(withExisting, msg.DevId)
+			if len(withExisting) == 2 {
+				return fmt.Errorf("flow logs received")
+			}
+			return nil
+		}, projects.WithProcExisting())
+		ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "new"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(withoutExisting, ",") != "new" {
+		t.Errorf("unexpected flow logs of processor without existing records: %v", withoutExisting)
+	}
+	if len(withExisting) != 2 || !strings.Contains(strings.Join(withExisting, ","), "existing") {
+		t.Errorf("unexpected flow logs of processor with existing records: %v", withExisting)
+	}
+}
+
+//waitFlowLog returns processor done on flow log from device with id
+func waitFlowLog(id string) projects.ProcLogFlowFunc {
+	return func(msg *flowlog.FlowMessage) error {
+		if msg.DevId == id {
+			return fmt.Errorf("%s received", id)
+		}
+		return nil
+	}
+}
+
+func TestRunStepTimeout(t *testing.T) {
+	tc, ctrl, dev := newFakeTestContext()
+	defer close(ctrl.done)
+	err := tc.RunStep("timeout", 200*time.Millisecond, func() {
+		tc.AddProcFlowLog(dev, waitFlowLog("never"), projects.WithProcName("never"))
+	})
+	if err == nil {
+		t.Fatal("step must be terminated by timeout")
+	}
+	if !strings.Contains(err.Error(), "never (step timeout)") {
+		t.Errorf("pending processor not found in error: %s", err)
+	}
+	if pending := tc.PendingProcs(); len(pending) != 0 {
+		t.Errorf("processors of step must be cleaned on timeout: %v", pending)
+	}
+}
+
+func TestRunStepPendingProcs(t *testing.T) {
+	tc, ctrl, dev := newFakeTestContext()
+	defer close(ctrl.done)
+	tc.AddProcFlowLog(dev, waitFlowLog("outer"), projects.WithProcName("outer"))
+	err := tc.RunStep("inner", 5*time.Second, func() {
+		tc.AddProcFlowLog(dev, waitFlowLog("inner"), projects.WithProcName("inner"))
+		expected := fmt.Sprintf("inner (step inner) for device %s", dev.GetID())
+		if pending := tc.PendingProcs(); len(pending) != 1 || pending[0] != expected {
+			t.Errorf("expected pending processors of step [%s], received %v", expected, pending)
+		}
+		ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "inner"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fmt.Sprintf("outer for device %s", dev.GetID())
+	if pending := tc.PendingProcs(); len(pending) != 1 || pending[0] != expected {
+		t.Errorf("expected pending processors [%s] after step, received %v", expected, pending)
+	}
+}
+
+func TestRunStepExpandOnSuccess(t *testing.T) {
+	tc, ctrl, dev := newFakeTestContext()
+	defer close(ctrl.done)
+	err := tc.RunStep("expand", 300*time.Millisecond, func() {
+		tc.ExpandOnSuccess(1)
+		tc.AddProcFlowLog(dev, waitFlowLog("first"))
+		tc.AddProcFlowLog(dev, waitFlowLog("second"))
+		ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "first"}
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "second"}
+		}()
+	})
+	if err != nil {
+		t.Fatalf("deadline of step must be extended on success: %s", err)
+	}
+}
+
+func TestRunStepNotExpandedByOuterProcs(t *testing.T) {
+	tc, ctrl, dev := newFakeTestContext()
+	defer close(ctrl.done)
+	tc.AddProcFlowLog(dev, waitFlowLog("outer"))
+	start := time.Now()
+	err := tc.RunStep("inner", 300*time.Millisecond, func() {
+		tc.ExpandOnSuccess(1)
+		tc.AddProcFlowLog(dev, waitFlowLog("inner"))
+		ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "outer"}
+	})
+	if err == nil {
+		t.Fatal("step must be terminated by timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("deadline of step must not be extended by processors added before step, step is done in %s", elapsed)
+	}
+}
+
+func TestRunStepNested(t *testing.T) {
+	tc, ctrl, dev := newFakeTestContext()
+	defer close(ctrl.done)
+	err := tc.RunStep("outer", 300*time.Millisecond, func() {
+		tc.ExpandOnSuccess(1)
+		tc.AddProcFlowLog(dev, waitFlowLog("first"))
+		tc.AddProcFlowLog(dev, waitFlowLog("second"))
+		if err := tc.RunStep("inner", 5*time.Second, func() {
+			tc.AddProcFlowLog(dev, waitFlowLog("inner"))
+			ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "inner"}
+		}); err != nil {
+			t.Error(err)
+		}
+		//deadline of outer step must be extended after inner step is done
+		ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "first"}
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "second"}
+		}()
+	})
+	if err != nil {
+		t.Fatalf("deadline of outer step must be extended after inner step: %s", err)
+	}
+}
+
+func TestRunStepExpandNotKept(t *testing.T) {
+	tc, ctrl, dev := newFakeTestContext()
+	defer close(ctrl.done)
+	tc.ExpandOnSuccess(1)
+	tc.AddProcFlowLog(dev, waitFlowLog("expand"))
+	ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "expand"}
+	tc.WaitForProcWithErrorCallback(5, func() {
+		t.Fatal("processor of wait not done")
+	})
+	err := tc.RunStep("next", 300*time.Millisecond, func() {
+		tc.AddProcFlowLog(dev, waitFlowLog("first"))
+		tc.AddProcFlowLog(dev, waitFlowLog("second"))
+		ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "first"}
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			ctrl.flowLogs <- &flowlog.FlowMessage{DevId: "second"}
+		}()
+	})
+	if err == nil {
+		t.Fatal("deadline of step must not be extended by ExpandOnSuccess of previous wait")
+	}
+}
@@ -0,0 +1,124 @@
+package templates
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve/api/go/logs"
+	"github.com/lf-edge/eve/api/go/metrics"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// These tests verify parsing of time ranges and filtering of objects by timestamps in loaders
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)
+	tm, err := types.ParseTime("10m", now)
+	if err != nil || !tm.Equal(now.Add(-10*time.Minute)) {
+		t.Fatalf("unexpected relative time %s: %v", tm, err)
+	}
+	tm, err = types.ParseTime("2021-03-04T09:00:00Z", now)
+	if err != nil || !tm.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("unexpected absolute time %s: %v", tm, err)
+	}
+	if tm, err = types.ParseTime("", now); err != nil || !tm.IsZero() {
+		t.Fatalf("expected zero time for empty value, got %s: %v", tm, err)
+	}
+	if _, err = types.ParseTime("yesterday", now); err == nil {
+		t.Fatal("expected error for wrong time")
+	}
+	if _, err = types.ParseTimeRange("1h", "2h"); err == nil {
+		t.Fatal("expected error for until before since")
+	}
+}
+
+func TestFileLoaderTimeRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eden-timerange")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		msg := &metrics.ZMetricMsg{AtTimeStamp: timestamppb.New(now.Add(-time.Duration(i) * time.Hour))}
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.json", i)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loader := loaders.NewFileLoader(types.DirGetters{
+		MetricsGetter: func(devUUID uuid.UUID) string {
+			return dir
+		},
+	})
+	loader.SetTimeRange(types.TimeRange{Since: now.Add(-150 * time.Minute), Until: now.Add(-30 * time.Minute)})
+	count := 0
+	process := func(data []byte) (bool, error) {
+		var msg metrics.ZMetricMsg
+		if err := protojson.Unmarshal(data, &msg); err != nil {
+			return false, err
+		}
+		if ts := msg.GetAtTimeStamp().AsTime(); ts.Before(now.Add(-150*time.Minute)) || ts.After(now.Add(-30*time.Minute)) {
+			t.Errorf("object with timestamp %s is out of range", ts)
+		}
+		count++
+		return true, nil
+	}
+	if err = loader.ProcessExisting(process, types.MetricsType); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 objects in range, got %d", count)
+	}
+}
+
+func TestFileLoaderTimeRangeLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eden-timerange")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		msg := &logs.LogEntry{Content: fmt.Sprintf("log %d", i), Timestamp: timestamppb.New(now.Add(-time.Duration(i) * time.Hour))}
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.json", i)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loader := loaders.NewFileLoader(types.DirGetters{
+		LogsGetter: func(devUUID uuid.UUID) string {
+			return dir
+		},
+	})
+	loader.SetTimeRange(types.TimeRange{Since: now.Add(-150 * time.Minute), Until: now.Add(-30 * time.Minute)})
+	var received []string
+	process := func(data []byte) (bool, error) {
+		var msg logs.LogEntry
+		if err := protojson.Unmarshal(data, &msg); err != nil {
+			return false, err
+		}
+		received = append(received, msg.Content)
+		return true, nil
+	}
+	if err = loader.ProcessExisting(process, types.LogsType); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 {
+		t.Fatalf("expected 2 logs in range, got %v", received)
+	}
+}
@@ -0,0 +1,143 @@
+package templates
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/controller/zedcloud"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve/api/go/info"
+	uuid "github.com/satori/go.uuid"
+)
+
+// These tests verify the functionality of zedcloud controller
+// against local stand-in of zedcloud API with recorded responses from testdata/zedcloud
+
+const (
+	zedcloudToken    = "test-token"
+	zedcloudDeviceID = "2f2b7ac0-7d3b-4a4e-9f2c-7c2f8a1f2a01"
+)
+
+//zedcloudStandIn serves recorded responses and saves config received from controller
+type zedcloudStandIn struct {
+	sync.Mutex
+	t      *testing.T
+	config []byte
+}
+
+func (s *zedcloudStandIn) serveFile(w http.ResponseWriter, name string) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "zedcloud", name))
+	if err != nil {
+		s.t.Errorf("cannot read recorded response: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write(data)
+}
+
+func (s *zedcloudStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+zedcloudToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	devicePath := "/api/v1/devices/id/" + zedcloudDeviceID
+	switch {
+	case r.URL.Path == "/api/v1/devices" && r.Method == http.MethodGet:
+		s.serveFile(w, "devices.json")
+	case r.URL.Path == devicePath+"/config" && r.Method == http.MethodGet:
+		s.Lock()
+		defer s.Unlock()
+		if s.config != nil {
+			_, _ = w.Write(s.config)
+			return
+		}
+		s.serveFile(w, "config.json")
+	case r.URL.Path == devicePath+"/config" && r.Method == http.MethodPut:
+		s.Lock()
+		defer s.Unlock()
+		s.config, _ = ioutil.ReadAll(r.Body)
+	case r.URL.Path == devicePath+"/info" && r.Method == http.MethodGet:
+		s.serveFile(w, "info.json")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newZedcloudCtx(t *testing.T) (*zedcloud.Ctx, func()) {
+	srv := httptest.NewServer(&zedcloudStandIn{t: t})
+	ctx := &zedcloud.Ctx{}
+	if err := ctx.InitWithVars(&utils.ConfigVars{ZedcloudURL: srv.URL, ZedcloudToken: zedcloudToken}); err != nil {
+		t.Fatal(err)
+	}
+	return ctx, srv.Close
+}
+
+//TestZedcloudDeviceList try to get lists of devices with different filters
+func TestZedcloudDeviceList(t *testing.T) {
+	ctx, closeFunc := newZedcloudCtx(t)
+	defer closeFunc()
+	for filter, expected := range map[types.DeviceStateFilter]int{
+		types.AllDevicesFilter:          2,
+		types.RegisteredDeviceFilter:    1,
+		types.NotRegisteredDeviceFilter: 1,
+	} {
+		devices, err := ctx.DeviceList(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(devices) != expected {
+			t.Errorf("filter %d: expected %d devices, received %d", filter, expected, len(devices))
+		}
+	}
+}
+
+//TestZedcloudConfig try to get recorded config and set new one
+func TestZedcloudConfig(t *testing.T) {
+	ctx, closeFunc := newZedcloudCtx(t)
+	defer closeFunc()
+	devUUID := uuid.FromStringOrNil(zedcloudDeviceID)
+	cfg, err := ctx.ConfigGet(devUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg) == 0 {
+		t.Fatal("empty config received")
+	}
+	newConfig := `{"id":{"uuid":"` + zedcloudDeviceID + `","version":"5"}}`
+	if err = ctx.ConfigSet(devUUID, []byte(newConfig)); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = ctx.ConfigGet(devUUID); err != nil {
+		t.Fatal(err)
+	}
+	if cfg != newConfig {
+		t.Errorf("expected: %s, received: %s", newConfig, cfg)
+	}
+}
+
+//TestZedcloudInfo try to process recorded info messages
+//  expected to fire callback for every message
+func TestZedcloudInfo(t *testing.T) {
+	ctx, closeFunc := newZedcloudCtx(t)
+	defer closeFunc()
+	var received []info.ZInfoTypes
+	handler := func(im *info.ZInfoMsg, _ []*einfo.ZInfoMsgInterface) bool {
+		if im.DevId != zedcloudDeviceID {
+			t.Errorf("expected: %s, received: %s", zedcloudDeviceID, im.DevId)
+		}
+		received = append(received, im.Ztype)
+		return false
+	}
+	if err := ctx.InfoLastCallback(uuid.FromStringOrNil(zedcloudDeviceID), map[string]string{}, handler); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 {
+		t.Errorf("expected %d values, received %d", 2, len(received))
+	}
+}